@@ -145,20 +145,20 @@ func (r *ConnectReply) ConfigurablePeriod() time.Duration {
 func uintPtr(x uint) *uint { return &x }
 
 // DefaultEventHarvestConfig provides faster event harvest defaults.
-func DefaultEventHarvestConfig(maxTxnEvents, maxLogEvents, maxCustomEvents int) EventHarvestConfig {
+func DefaultEventHarvestConfig(maxTxnEvents, maxLogEvents, maxCustomEvents, maxErrorEvents int) EventHarvestConfig {
 	cfg := EventHarvestConfig{}
 	cfg.ReportPeriodMs = DefaultConfigurableEventHarvestMs
 	cfg.Limits.TxnEvents = uintPtr(uint(maxTxnEvents))
 	cfg.Limits.CustomEvents = uintPtr(uint(maxCustomEvents))
 	cfg.Limits.LogEvents = uintPtr(uint(maxLogEvents))
-	cfg.Limits.ErrorEvents = uintPtr(uint(MaxErrorEvents))
+	cfg.Limits.ErrorEvents = uintPtr(uint(maxErrorEvents))
 	return cfg
 }
 
 // DefaultEventHarvestConfigWithDT is an extended version of DefaultEventHarvestConfig,
 // with the addition that it takes into account distributed tracer span event harvest limits.
-func DefaultEventHarvestConfigWithDT(maxTxnEvents, maxLogEvents, maxCustomEvents, spanEventLimit int, dtEnabled bool) EventHarvestConfig {
-	cfg := DefaultEventHarvestConfig(maxTxnEvents, maxLogEvents, maxCustomEvents)
+func DefaultEventHarvestConfigWithDT(maxTxnEvents, maxLogEvents, maxCustomEvents, maxErrorEvents, spanEventLimit int, dtEnabled bool) EventHarvestConfig {
+	cfg := DefaultEventHarvestConfig(maxTxnEvents, maxLogEvents, maxCustomEvents, maxErrorEvents)
 	if dtEnabled {
 		cfg.Limits.SpanEvents = uintPtr(uint(spanEventLimit))
 	}
@@ -174,7 +174,7 @@ func TestNegativeHarvestLimits(t *testing.T) {
 }
 
 func TestDefaultEventHarvestConfigJSON(t *testing.T) {
-	js, err := json.Marshal(DefaultEventHarvestConfig(MaxTxnEvents, MaxLogEvents, MaxCustomEvents))
+	js, err := json.Marshal(DefaultEventHarvestConfig(MaxTxnEvents, MaxLogEvents, MaxCustomEvents, MaxErrorEvents))
 	if err != nil {
 		t.Error(err)
 	}
@@ -106,5 +106,5 @@ var AppLogEnabledCfgFn = func(cfg *newrelic.Config) {
 var SampleEverythingReplyFn = func(reply *internal.ConnectReply) {
 	reply.SetSampleEverything()
 	reply.EntityGUID = TestEntityGUID
-	reply.EventData = internal.DefaultEventHarvestConfig(internal.MaxTxnEvents, internal.MaxLogEvents, internal.MaxCustomEvents)
+	reply.EventData = internal.DefaultEventHarvestConfig(internal.MaxTxnEvents, internal.MaxLogEvents, internal.MaxCustomEvents, internal.MaxErrorEvents)
 }
@@ -5,6 +5,9 @@ const (
 	// LogSeverityFieldName is the name of the log level field in New Relic logging JSON
 	LogSeverityFieldName = "level"
 
+	// LogSeverityLevelFieldName is the name of the numeric log level field in New Relic logging JSON
+	LogSeverityLevelFieldName = "level.number"
+
 	// LogMessageFieldName is the name of the log message field in New Relic logging JSON
 	LogMessageFieldName = "message"
 
@@ -17,6 +20,30 @@ const (
 	// LogTraceIDFieldName is the name of the trace ID field in the New Relic logging JSON
 	LogTraceIDFieldName = "trace.id"
 
+	// LogTraceFlagsFieldName is the name of the W3C trace flags field in the New Relic logging JSON
+	LogTraceFlagsFieldName = "trace.flags"
+
+	// LogLoggerNameFieldName is the name of the logger name field in the New Relic logging JSON
+	LogLoggerNameFieldName = "logger.name"
+
+	// LogServiceNameFieldName is the name of the service name field in the New Relic logging JSON
+	LogServiceNameFieldName = "service.name"
+
+	// LogFileNameFieldName is the name of the source file name field in the New Relic logging JSON
+	LogFileNameFieldName = "file.name"
+
+	// LogLineNumberFieldName is the name of the source line number field in the New Relic logging JSON
+	LogLineNumberFieldName = "line.number"
+
+	// LogStackTraceFieldName is the name of the stack trace field in the New Relic logging JSON
+	LogStackTraceFieldName = "error.stack"
+
+	// LogErrorClassFieldName is the name of the correlated error class field in the New Relic logging JSON
+	LogErrorClassFieldName = "error.class"
+
+	// LogErrorIDFieldName is the name of the correlated error ID field in the New Relic logging JSON
+	LogErrorIDFieldName = "error.id"
+
 	// LogSeverityUnknown is the value the log severity should be set to if no log severity is known
 	LogSeverityUnknown = "UNKNOWN"
 
@@ -240,6 +240,49 @@ func BenchmarkTxnEventsCollectorJSON(b *testing.B) {
 	analyticsEventBenchmarkHelper(b, event)
 }
 
+// manyAttributesTxnEvent returns a transaction event with 64 user attributes.
+func manyAttributesTxnEvent(b *testing.B) *txnEvent {
+	cfg := config{Config: defaultConfig()}
+	cfg.MaxAttributesPerTransaction = 128
+	attrs := newAttributes(createAttributeConfig(cfg, true))
+	for i := 0; i < 64; i++ {
+		key := "attribute" + strconv.Itoa(i)
+		if err := addUserAttribute(attrs, key, "value"+strconv.Itoa(i), destAll); nil != err {
+			b.Fatal(err)
+		}
+	}
+	attrs.Agent.Add(AttributeRequestURI, "/zip/zap", nil)
+	attrs.Agent.Add(AttributeRequestMethod, "GET", nil)
+	attrs.Agent.Add(AttributeResponseCode, "", 200)
+	return &txnEvent{
+		FinalName: "WebTransaction/Go/zip/zap",
+		Start:     time.Now(),
+		Duration:  2 * time.Second,
+		Zone:      apdexSatisfying,
+		Attrs:     attrs,
+	}
+}
+
+func BenchmarkTxnEventsCollectorJSONManyAttributes(b *testing.B) {
+	analyticsEventBenchmarkHelper(b, manyAttributesTxnEvent(b))
+}
+
+// BenchmarkTxnEventWriteJSONManyAttributes checks that the fields of an event
+// are streamed into the output buffer without intermediate allocations.
+func BenchmarkTxnEventWriteJSONManyAttributes(b *testing.B) {
+	event := manyAttributesTxnEvent(b)
+	buf := &bytes.Buffer{}
+	event.WriteJSON(buf)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		buf.Reset()
+		event.WriteJSON(buf)
+	}
+}
+
 func BenchmarkCustomEventsCollectorJSON(b *testing.B) {
 	now := time.Now()
 	ce, err := createCustomEvent("myEventType", map[string]interface{}{
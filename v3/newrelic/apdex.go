@@ -3,7 +3,10 @@
 
 package newrelic
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // apdexZone is a transaction classification.
 type apdexZone int
@@ -16,6 +19,20 @@ const (
 	apdexFailing
 )
 
+// ApdexZone is the Apdex classification of a transaction, as returned by
+// Transaction.CurrentApdexZone.
+type ApdexZone string
+
+// These are the possible values of ApdexZone.  ApdexNone is used for
+// transactions that do not get an Apdex classification, such as non-web
+// transactions.
+const (
+	ApdexNone       ApdexZone = ""
+	ApdexSatisfying ApdexZone = "S"
+	ApdexTolerating ApdexZone = "T"
+	ApdexFailing    ApdexZone = "F"
+)
+
 // apdexFailingThreshold calculates the threshold at which the transaction is
 // considered a failure.
 func apdexFailingThreshold(threshold time.Duration) time.Duration {
@@ -49,3 +66,37 @@ func (zone apdexZone) label() string {
 		return ""
 	}
 }
+
+// apdexIgnored returns true if the transaction name matches one of the
+// patterns of Config.Apdex.IgnoreTransactions.
+func apdexIgnored(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchTransactionNamePattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTransactionNamePattern returns true if the name matches the pattern,
+// in which each '*' matches any sequence of characters, including none.  A
+// pattern without a '*' must match the name exactly.
+func matchTransactionNamePattern(pattern, name string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == name
+	}
+	first, last := parts[0], parts[len(parts)-1]
+	if !strings.HasPrefix(name, first) {
+		return false
+	}
+	name = name[len(first):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, part)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(part):]
+	}
+	return strings.HasSuffix(name, last)
+}
@@ -47,3 +47,43 @@ func TestApdexLabel(t *testing.T) {
 		t.Fatal(out)
 	}
 }
+
+func TestMatchTransactionNamePattern(t *testing.T) {
+	testcases := []struct {
+		pattern string
+		name    string
+		expect  bool
+	}{
+		{pattern: "WebTransaction/Go/healthz", name: "WebTransaction/Go/healthz", expect: true},
+		{pattern: "WebTransaction/Go/healthz", name: "WebTransaction/Go/healthz/deep", expect: false},
+		{pattern: "WebTransaction/Go/health*", name: "WebTransaction/Go/healthz", expect: true},
+		{pattern: "WebTransaction/Go/health*", name: "WebTransaction/Go/health", expect: true},
+		{pattern: "WebTransaction/Go/health*", name: "WebTransaction/Go/hello", expect: false},
+		{pattern: "*/healthz", name: "WebTransaction/Go/healthz", expect: true},
+		{pattern: "*/healthz", name: "WebTransaction/Go/healthz/deep", expect: false},
+		{pattern: "WebTransaction/*/internal/*", name: "WebTransaction/Go/internal/status", expect: true},
+		{pattern: "WebTransaction/*/internal/*", name: "WebTransaction/Go/status", expect: false},
+		{pattern: "a*a", name: "a", expect: false},
+		{pattern: "a*a", name: "aa", expect: true},
+		{pattern: "*", name: "WebTransaction/Go/hello", expect: true},
+		{pattern: "", name: "WebTransaction/Go/hello", expect: false},
+	}
+	for _, tc := range testcases {
+		if out := matchTransactionNamePattern(tc.pattern, tc.name); out != tc.expect {
+			t.Errorf("pattern %q, name %q: got %v, want %v", tc.pattern, tc.name, out, tc.expect)
+		}
+	}
+}
+
+func TestApdexIgnored(t *testing.T) {
+	if apdexIgnored(nil, "WebTransaction/Go/hello") {
+		t.Error("nothing should be ignored without patterns")
+	}
+	patterns := []string{"WebTransaction/Go/healthz", "WebTransaction/Go/internal/*"}
+	if !apdexIgnored(patterns, "WebTransaction/Go/internal/status") {
+		t.Error("name matching a pattern should be ignored")
+	}
+	if apdexIgnored(patterns, "WebTransaction/Go/hello") {
+		t.Error("name matching no pattern should not be ignored")
+	}
+}
@@ -229,7 +229,7 @@ func (run *appRun) MaxLogEvents() int {
 	return run.limit(internal.MaxLogEvents, run.ptrLogEvents)
 }
 func (run *appRun) MaxErrorEvents() int {
-	return run.limit(internal.MaxErrorEvents, run.ptrErrorEvents)
+	return run.limit(run.Config.maxErrorEvents(), run.ptrErrorEvents)
 }
 
 func (run *appRun) LoggingConfig() (config loggingConfig) {
@@ -240,6 +240,7 @@ func (run *appRun) LoggingConfig() (config loggingConfig) {
 	config.maxLogEvents = run.MaxLogEvents()
 	config.collectMetrics = logging.Enabled && logging.Metrics.Enabled
 	config.localEnrichment = logging.Enabled && logging.LocalDecorating.Enabled
+	config.rfc3339Time = logging.Forwarding.TimestampFormat == LogTimestampFormatRFC3339
 
 	return config
 }
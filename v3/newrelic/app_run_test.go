@@ -406,6 +406,27 @@ func TestConfigurableTxnEvents_configMoreThanMax(t *testing.T) {
 	}
 }
 
+func TestConfigurableErrorEvents(t *testing.T) {
+	reply, err := internal.UnmarshalConnectReply([]byte(
+		`{"return_value":{
+			"event_harvest_config": {
+				"report_period_ms": 10000
+			}
+        }}`), internal.PreconnectReply{})
+	if nil != err {
+		t.Fatal(err)
+	}
+	cfg := config{Config: defaultConfig()}
+	cfg.ErrorCollector.MaxEventsStored = 250
+	run := newAppRun(cfg, reply)
+	if result := run.MaxErrorEvents(); result != 250 {
+		t.Errorf("Unexpected max number of error events, expected %d but got %d", 250, result)
+	}
+	if c := newHarvest(time.Now(), run.harvestConfig).ErrorEvents.capacity(); c != 250 {
+		t.Errorf("Unexpected error event reservoir capacity, expected %d but got %d", 250, c)
+	}
+}
+
 type expectHarvestConfig struct {
 	maxTxnEvents    int
 	maxCustomEvents int
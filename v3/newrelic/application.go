@@ -4,6 +4,7 @@
 package newrelic
 
 import (
+	"context"
 	"os"
 	"time"
 )
@@ -23,6 +24,17 @@ func (app *Application) StartTransaction(name string, opts ...TraceOption) *Tran
 	return app.app.StartTransaction(name, opts...)
 }
 
+// StartTransactionWithOptions begins a Transaction with the given name,
+// configured by the TransactionOptions.  This allows a transaction to be
+// configured in one place when it is created, rather than by calling
+// Transaction methods after it has started.
+func (app *Application) StartTransactionWithOptions(name string, opts TransactionOptions) *Transaction {
+	if app == nil {
+		return nil
+	}
+	return app.app.StartTransactionWithOptions(name, opts)
+}
+
 // RecordCustomEvent adds a custom event.
 //
 // eventType must consist of alphanumeric characters, underscores, and
@@ -49,7 +61,8 @@ func (app *Application) RecordCustomEvent(eventType string, params map[string]in
 }
 
 // RecordCustomMetric records a custom metric.  The metric name you
-// provide will be prefixed by "Custom/".  Custom metrics are not
+// provide will be prefixed by Config.CustomMetricPrefix, which is "Custom/"
+// by default.  Custom metrics are not
 // currently supported in serverless mode.
 //
 // See
@@ -72,14 +85,22 @@ func (app *Application) RecordCustomMetric(name string, value float64) {
 // This consumes a LogData object that should be configured
 // with data taken from a logging framework.
 //
+// Logs recorded with this method are not associated with any transaction,
+// so they have no trace or span IDs.  Use Transaction.RecordLog for logs
+// written while handling a transaction.
+//
 // Certian parts of this feature can be turned off based on your
 // config settings. Record log is capable of recording log events,
 // as well as log metrics depending on how your application is
-// configured.
+// configured.  Log events are only forwarded when
+// Config.ApplicationLogging.Forwarding.Enabled is true.
 func (app *Application) RecordLog(logEvent LogData) {
 	if app == nil || app.app == nil {
 		return
 	}
+	if app.app.config.ApplicationLogging.Forwarding.CaptureSource {
+		logEvent.captureLogSource()
+	}
 	err := app.app.RecordLog(&logEvent)
 	if err != nil {
 		app.app.Error("unable to record log", map[string]interface{}{
@@ -88,6 +109,36 @@ func (app *Application) RecordLog(logEvent LogData) {
 	}
 }
 
+// MergeLogEventsOptions configures Application.MergeLogEvents.
+type MergeLogEventsOptions struct {
+	// ServiceName is used for logs whose LogData.ServiceName is empty, in
+	// place of the first of the application's Config.AppName names.
+	ServiceName string
+}
+
+// MergeLogEvents records a batch of logs, such as those buffered and flushed
+// periodically by a logging library.  It is equivalent to calling RecordLog
+// for each log, but the logs are added to the harvest together rather than
+// one at a time.  Unlike RecordLog, the source of the logs is not captured.
+//
+// MergeLogEvents returns the number of logs accepted and the number dropped
+// because they were invalid, for example because their message is longer
+// than MaxLogLength, or because application logging is disabled.  Accepted
+// logs are still subject to sampling and the forwarding configuration.
+func (app *Application) MergeLogEvents(logs []LogData, opts MergeLogEventsOptions) (accepted, dropped int) {
+	if app == nil || app.app == nil {
+		return 0, len(logs)
+	}
+	accepted, dropped, err := app.app.MergeLogEvents(logs, opts)
+	if err != nil {
+		app.app.Error("unable to record logs", map[string]interface{}{
+			"reason":  err.Error(),
+			"dropped": dropped,
+		})
+	}
+	return accepted, dropped
+}
+
 // WaitForConnection blocks until the application is connected, is
 // incapable of being connected, or the timeout has been reached.  This
 // method is useful for short-lived processes since the application will
@@ -128,6 +179,106 @@ func (app *Application) Shutdown(timeout time.Duration) {
 	app.app.Shutdown(timeout)
 }
 
+// GracefulShutdown flushes data to New Relic's servers and stops all agent
+// goroutines, like Shutdown, but is bounded by a context rather than a
+// timeout.  A final harvest of all data types is performed, and
+// GracefulShutdown blocks until it completes or the context is done, in
+// which case the context's error is returned.  It is safe to call before the
+// application has connected, in which case there is nothing to harvest.
+//
+// This allows data to be sent during the grace period between SIGTERM and
+// SIGKILL, such as when a Kubernetes pod is terminated:
+//
+//	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+//	defer stop()
+//	<-ctx.Done()
+//	shutdownCtx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+//	defer cancel()
+//	app.GracefulShutdown(shutdownCtx)
+func (app *Application) GracefulShutdown(ctx context.Context) error {
+	if app == nil || app.app == nil {
+		return nil
+	}
+	return app.app.GracefulShutdown(ctx)
+}
+
+// FlushLogs immediately sends the log events collected so far to New Relic,
+// including those of transactions which have already ended, rather than
+// waiting for the next harvest.  Other data types are harvested as usual.
+// FlushLogs blocks until the log events have been sent, and does nothing
+// if the application is not connected.  Combined with Shutdown, it provides
+// control over the delivery of log events when the process exits.
+func (app *Application) FlushLogs() {
+	if app == nil || app.app == nil {
+		return
+	}
+	app.app.FlushLogs()
+}
+
+// PendingMetricNames returns the sorted names of the metrics collected since
+// the last harvest, without their values.  It is intended for diagnostics,
+// such as checking that custom metrics are being recorded, and should not be
+// called on hot paths: the snapshot is coordinated with the goroutine that
+// owns the harvest.  Nil is returned if the application is not connected.
+func (app *Application) PendingMetricNames() []string {
+	if app == nil || app.app == nil {
+		return nil
+	}
+	return app.app.PendingMetricNames()
+}
+
+// RecentTransactions returns summaries of the most recently finished
+// transactions, oldest first.  At most Config.RecentTransactionBufferSize
+// summaries are returned.  Nil is returned if the buffer is disabled.  The
+// returned slice is a copy and may be modified by the caller.
+func (app *Application) RecentTransactions() []TransactionSummary {
+	if app == nil || app.app == nil {
+		return nil
+	}
+	return app.app.recentTxns.snapshot()
+}
+
+// ErrorRate returns the fraction of transactions, between 0 and 1, which
+// finished within the last Config.ErrorRateWindow and noticed errors.
+// Expected errors are not counted.  Zero is returned if no transactions
+// finished within the window or if Config.ErrorRateWindow is not set.  The
+// rate is computed in-process, eg. for autoscaling decisions, and is not
+// sent to New Relic.
+func (app *Application) ErrorRate() float64 {
+	if app == nil || app.app == nil {
+		return 0
+	}
+	return app.app.errRate.rate(timeNow())
+}
+
+// RunID returns the identifier of the application's current connection to
+// New Relic, which may be useful when correlating logs with New Relic
+// support.  The empty string is returned if the application is not
+// connected, including while it is reconnecting.
+func (app *Application) RunID() string {
+	if app == nil || app.app == nil {
+		return ""
+	}
+	run, _ := app.app.getState()
+	return run.Reply.RunID.String()
+}
+
+// AttributeDestinations returns the destinations that an attribute with the
+// given name would be sent to under the application's attribute
+// configuration, which includes the Config.Attributes include and exclude
+// rules, the destination specific rules, and any security policies received
+// from New Relic.  It is intended to help diagnose why an attribute is
+// missing from a destination.  Config.AttributeFilter is not consulted since
+// its decision depends on the attribute's value.  Nil is returned if the
+// attribute would not be sent anywhere.
+func (app *Application) AttributeDestinations(name string) []AttributeDestination {
+	if app == nil || app.app == nil {
+		return nil
+	}
+	run, _ := app.app.getState()
+	return destinationNames(run.AttributeConfig.destinations(name))
+}
+
 // Config returns a copy of the application's configuration data in case
 // that information is needed (but since it is a copy, this function cannot
 // be used to alter the application's configuration).
@@ -35,6 +35,14 @@ const (
 	AttributeRequestContentLength = "request.headers.contentLength"
 	// AttributeRequestHost is the request's "Host" header.
 	AttributeRequestHost = "request.headers.host"
+	// AttributeRequestHeaders is a JSON object containing the request
+	// headers which are otherwise recorded as separate attributes.  It is
+	// recorded in place of those attributes when
+	// Config.CaptureRequestHeadersAsJSON is enabled.  Only headers whose
+	// own attribute is sent to every destination of this attribute are
+	// included, so the "User-Agent" and "Referer" headers are left out by
+	// default.
+	AttributeRequestHeaders = "request.headers"
 	// AttributeRequestURI is the request's URL without query parameters,
 	// fragment, user, or password.
 	AttributeRequestURI = "request.uri"
@@ -52,10 +60,112 @@ const (
 	AttributeCodeFilepath = "code.filepath"
 	// AttributeCodeLineno contains the Code Level Metrics source file line number name.
 	AttributeCodeLineno = "code.lineno"
-	// AttributeErrorGroupName contains the error group name set by the user defined callback function.
+	// AttributeErrorGroupName contains the error group name set by the user defined callback function,
+	// or given to Transaction.NoticeErrorWithGroupAndAttributes.
 	AttributeErrorGroupName = "error.group.name"
 	// AttributeUserID tracks the user a transaction and its child events are impacting
 	AttributeUserID = "enduser.id"
+	// AttributeRoutePattern contains the route template set by
+	// Transaction.SetRoutePattern, such as "/users/:id".
+	AttributeRoutePattern = "http.route"
+	// AttributeOperationName contains the operation name set by
+	// Transaction.SetOperationName.
+	AttributeOperationName = "operation.name"
+	// AttributeRequestID contains the request identifier set by
+	// Transaction.SetRequestID.
+	AttributeRequestID = "request.id"
+	// AttributeTransactionStartTime contains the start time of the
+	// transaction formatted using RFC3339.  It is recorded when
+	// Config.RecordTransactionStartTime is enabled.
+	AttributeTransactionStartTime = "transaction.startTime"
+	// AttributeRequestTLSVersion is the TLS version negotiated for the
+	// request, eg. "TLS 1.3".  It is absent for plaintext requests.
+	AttributeRequestTLSVersion = "request.tls.version"
+	// AttributeRequestTLSCipherSuite is the name of the cipher suite
+	// negotiated for the request, eg. "TLS_AES_128_GCM_SHA256".  It is
+	// absent for plaintext requests.
+	AttributeRequestTLSCipherSuite = "request.tls.cipherSuite"
+	// AttributeRequestScheme is the scheme of the original request, "http"
+	// or "https".  It is taken from the X-Forwarded-Proto header when
+	// present, so that requests received through a TLS-terminating proxy
+	// are reported correctly, and otherwise is "https" for requests received
+	// over TLS.  It is absent when neither is available.
+	AttributeRequestScheme = "request.scheme"
+	// AttributeRequestClientRegion is the client's geographic region, taken
+	// from the request header named by Config.ClientRegionHeader.  It is
+	// absent when the header is not configured or is empty.
+	AttributeRequestClientRegion = "request.clientRegion"
+	// AttributeRequestBodyBytesRead is the number of request body bytes
+	// recorded using Transaction.RecordBytesRead.
+	AttributeRequestBodyBytesRead = "request.bodyBytesRead"
+	// AttributeRequestBody is the request body captured by
+	// Config.ErrorCollector.CaptureRequestBody.  It is only recorded on
+	// traced errors, and not on error events.
+	AttributeRequestBody = "request.body"
+	// AttributeResponseRetryAfter is the number of seconds from the
+	// response's "Retry-After" header.  It is recorded for 429 and 503
+	// responses only.
+	AttributeResponseRetryAfter = "response.retryAfter"
+)
+
+// Attributes destined for Transaction Events only:
+const (
+	// AttributeGoroutineCount is the number of goroutines sampled at the end
+	// of a transaction whose duration exceeded
+	// Config.CaptureGoroutineCountThreshold.
+	AttributeGoroutineCount = "goroutine.count"
+	// AttributeHeapAlloc is the number of bytes of allocated heap objects
+	// sampled at the end of a transaction whose duration exceeded
+	// Config.CaptureMemStatsThreshold.
+	AttributeHeapAlloc = "memory.heapAlloc"
+	// AttributeApdexThreshold is the Apdex T value, in milliseconds, used to
+	// calculate the Apdex zone of a web transaction.  It is absent for
+	// non-web transactions.
+	AttributeApdexThreshold = "apdex.threshold"
+	// AttributeTransactionLockWait is the total time, in seconds, that the
+	// transaction spent waiting to acquire its internal lock.  It is
+	// recorded when Config.RecordTransactionLockWait is enabled.
+	AttributeTransactionLockWait = "transaction.lockWait"
+	// AttributeMetLatencyTarget is true if the transaction's duration was
+	// within the target given to Transaction.SetLatencyTarget, and false
+	// otherwise.  It is absent if no target was set.
+	AttributeMetLatencyTarget = "metLatencyTarget"
+	// AttributeTotalErrors is the number of errors noticed by the
+	// transaction, including those beyond the limit of errors recorded per
+	// transaction.  It is absent if no errors were noticed.
+	AttributeTotalErrors = "totalErrors"
+	// AttributeDistinctErrorClasses is the number of distinct error classes
+	// noticed by the transaction, up to a limit of 64.  It is absent if no
+	// errors were noticed.
+	AttributeDistinctErrorClasses = "distinctErrorClasses"
+	// AttributeAttemptNumber is the attempt number given to
+	// Transaction.SetAttemptNumber, such as the number of times a
+	// background job has been tried.
+	AttributeAttemptNumber = "attemptNumber"
+	// AttributeQueueDuration is the time in milliseconds the request spent
+	// queued before reaching the application, as given by the
+	// X-Queue-Start or X-Request-Start header.  It is absent if neither
+	// header was present.
+	AttributeQueueDuration = "queueDuration"
+	// AttributeBusyTime is the total time, in seconds, given to
+	// Transaction.AddBusyTime.  It is absent if no busy time was added.
+	AttributeBusyTime = "busyTime"
+)
+
+// Attributes counting the logs recorded by Transaction.RecordLog, by
+// severity:
+//
+// These attributes are only added to transaction events, and are absent when
+// no logs of the severity were recorded.  Severities are grouped by their
+// numeric level, as given by LogData.SeverityLevel or LogSeverityLevel.
+const (
+	AttributeLogCountTrace   = "log.count.trace"
+	AttributeLogCountDebug   = "log.count.debug"
+	AttributeLogCountInfo    = "log.count.info"
+	AttributeLogCountWarn    = "log.count.warn"
+	AttributeLogCountError   = "log.count.error"
+	AttributeLogCountFatal   = "log.count.fatal"
+	AttributeLogCountUnknown = "log.count.unknown"
 )
 
 // Attributes destined for Errors and Transaction Traces:
@@ -83,6 +193,17 @@ const (
 	AttributeAWSLambdaEventSourceARN = "aws.lambda.eventSource.arn"
 )
 
+// Attributes for transactions started by New Relic Synthetics:
+//
+// These attributes are decoded from the inbound X-NewRelic-Synthetics header
+// when it is present, well formed, and sent from a trusted account.  They are
+// not added when high security mode is enabled.
+const (
+	AttributeSyntheticsResourceID = "synthetics.resourceId"
+	AttributeSyntheticsJobID      = "synthetics.jobId"
+	AttributeSyntheticsMonitorID  = "synthetics.monitorId"
+)
+
 // Attributes for consumed message transactions:
 //
 // When a message is consumed (for example from Kafka or RabbitMQ), supported
@@ -144,6 +265,8 @@ const (
 	SpanAttributeDBStatement             = "db.statement"
 	SpanAttributeDBInstance              = "db.instance"
 	SpanAttributeDBCollection            = "db.collection"
+	SpanAttributeDBRowsAffected          = "db.rowsAffected"
+	SpanAttributeDBRowsReturned          = "db.rowsReturned"
 	SpanAttributePeerAddress             = "peer.address"
 	SpanAttributePeerHostname            = "peer.hostname"
 	SpanAttributeHTTPURL                 = "http.url"
@@ -157,6 +280,21 @@ const (
 	SpanAttributeParentAccount           = "parent.account"
 	SpanAttributeParentTransportDuration = "parent.transportDuration"
 	SpanAttributeParentTransportType     = "parent.transportType"
+	// SpanAttributeThreadIndex identifies the Transaction.NewGoroutine
+	// goroutine which recorded a segment.  Each goroutine created with
+	// Transaction.NewGoroutine is assigned the next index, starting from 1.
+	// Segments recorded by the transaction's original goroutine do not
+	// have this attribute.
+	SpanAttributeThreadIndex = "thread.index"
+	// The following attributes break down the time of an outbound HTTP
+	// request started with WithConnectionTimings.  Durations are in
+	// seconds.  Phases that did not occur, such as the TLS handshake of a
+	// plaintext request, are absent.
+	SpanAttributeHTTPDNSDuration      = "http.dnsDuration"
+	SpanAttributeHTTPConnectDuration  = "http.connectDuration"
+	SpanAttributeHTTPTLSDuration      = "http.tlsDuration"
+	SpanAttributeHTTPTimeToFirstByte  = "http.timeToFirstByte"
+	SpanAttributeHTTPConnectionReused = "http.connectionReused"
 
 	// Deprecated: This attribute is a duplicate of AttributeResponseCode and
 	// will be removed in a later release.
@@ -165,3 +303,30 @@ const (
 	// will be removed in a later release.
 	SpanAttributeAWSRequestID = "aws.requestId"
 )
+
+// AttributeDestination identifies a destination of attributes.  It is passed
+// to the Config.AttributeFilter callback.
+type AttributeDestination string
+
+// These are the possible values of AttributeDestination.
+const (
+	AttributeDestinationTransactionEvents AttributeDestination = "transaction_events"
+	AttributeDestinationErrors            AttributeDestination = "errors"
+	AttributeDestinationTransactionTraces AttributeDestination = "transaction_traces"
+	AttributeDestinationBrowser           AttributeDestination = "browser_monitoring"
+	AttributeDestinationSpanEvents        AttributeDestination = "span_events"
+	AttributeDestinationSegments          AttributeDestination = "transaction_segments"
+)
+
+// AttributeFilter is a user defined callback function that decides whether an
+// attribute is sent to a destination.  It is called after the include and
+// exclude rules of the attribute destination configuration have been applied,
+// once for each destination the attribute would otherwise be sent to.
+// Returning false drops the attribute from that destination.
+//
+// example function:
+//
+//	func dropEmptyAttributes(name string, value interface{}, dest newrelic.AttributeDestination) bool {
+//		return value != ""
+//	}
+type AttributeFilter func(name string, value interface{}, dest AttributeDestination) bool
@@ -5,6 +5,7 @@ package newrelic
 
 import (
 	"bytes"
+	"crypto/tls"
 	"fmt"
 	"math"
 	"net/http"
@@ -12,6 +13,9 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal/jsonx"
 )
 
 const (
@@ -35,6 +39,7 @@ var (
 		AttributeRequestContentType:         usualDests,
 		AttributeRequestContentLength:       usualDests,
 		AttributeRequestHost:                usualDests,
+		AttributeRequestHeaders:             usualDests,
 		AttributeRequestUserAgent:           tracesDests,
 		AttributeRequestUserAgentDeprecated: tracesDests,
 		AttributeRequestReferer:             tracesDests,
@@ -47,6 +52,9 @@ var (
 		AttributeAWSLambdaARN:               usualDests,
 		AttributeAWSLambdaColdStart:         usualDests,
 		AttributeAWSLambdaEventSourceARN:    usualDests,
+		AttributeSyntheticsResourceID:       usualDests,
+		AttributeSyntheticsJobID:            usualDests,
+		AttributeSyntheticsMonitorID:        usualDests,
 		AttributeMessageRoutingKey:          usualDests,
 		AttributeMessageQueueName:           usualDests,
 		AttributeMessageHeaders:             usualDests,
@@ -58,11 +66,41 @@ var (
 		AttributeCodeFilepath:               usualDests,
 		AttributeCodeLineno:                 usualDests,
 		AttributeUserID:                     usualDests,
+		AttributeRoutePattern:               usualDests,
+		AttributeRequestID:                  usualDests,
+		AttributeTransactionStartTime:       usualDests,
+		AttributeRequestTLSVersion:          usualDests,
+		AttributeRequestTLSCipherSuite:      usualDests,
+		AttributeRequestScheme:              usualDests,
+		AttributeRequestClientRegion:        usualDests,
+		AttributeRequestBodyBytesRead:       usualDests,
+		AttributeRequestBody:                destError,
+		AttributeOperationName:              usualDests,
+		AttributeResponseRetryAfter:         usualDests,
+		AttributeGoroutineCount:             destTxnEvent,
+		AttributeHeapAlloc:                  destTxnEvent,
+		AttributeTransactionLockWait:        destTxnEvent,
+		AttributeMetLatencyTarget:           destTxnEvent,
+		AttributeTotalErrors:                destTxnEvent,
+		AttributeDistinctErrorClasses:       destTxnEvent,
+		AttributeAttemptNumber:              destTxnEvent,
+		AttributeQueueDuration:              destTxnEvent,
+		AttributeBusyTime:                   destTxnEvent,
+		AttributeLogCountTrace:              destTxnEvent,
+		AttributeLogCountDebug:              destTxnEvent,
+		AttributeLogCountInfo:               destTxnEvent,
+		AttributeLogCountWarn:               destTxnEvent,
+		AttributeLogCountError:              destTxnEvent,
+		AttributeLogCountFatal:              destTxnEvent,
+		AttributeLogCountUnknown:            destTxnEvent,
+		AttributeApdexThreshold:             destTxnEvent,
 
 		// Span specific attributes
 		SpanAttributeDBStatement:             usualDests,
 		SpanAttributeDBInstance:              usualDests,
 		SpanAttributeDBCollection:            usualDests,
+		SpanAttributeDBRowsAffected:          usualDests,
+		SpanAttributeDBRowsReturned:          usualDests,
 		SpanAttributePeerAddress:             usualDests,
 		SpanAttributePeerHostname:            usualDests,
 		SpanAttributeHTTPURL:                 usualDests,
@@ -77,6 +115,12 @@ var (
 		SpanAttributeParentAccount:           usualDests,
 		SpanAttributeParentTransportDuration: usualDests,
 		SpanAttributeParentTransportType:     usualDests,
+		SpanAttributeThreadIndex:             usualDests,
+		SpanAttributeHTTPDNSDuration:         usualDests,
+		SpanAttributeHTTPConnectDuration:     usualDests,
+		SpanAttributeHTTPTLSDuration:         usualDests,
+		SpanAttributeHTTPTimeToFirstByte:     usualDests,
+		SpanAttributeHTTPConnectionReused:    usualDests,
 	}
 )
 
@@ -93,6 +137,19 @@ const (
 	destSegment
 )
 
+// attributeDestinations maps each destination to its public name.
+var attributeDestinations = []struct {
+	dest destinationSet
+	name AttributeDestination
+}{
+	{dest: destTxnEvent, name: AttributeDestinationTransactionEvents},
+	{dest: destError, name: AttributeDestinationErrors},
+	{dest: destTxnTrace, name: AttributeDestinationTransactionTraces},
+	{dest: destBrowser, name: AttributeDestinationBrowser},
+	{dest: destSpan, name: AttributeDestinationSpanEvents},
+	{dest: destSegment, name: AttributeDestinationSegments},
+}
+
 const (
 	destNone destinationSet = 0
 	// destAll contains all destinations.
@@ -123,6 +180,12 @@ type attributeConfig struct {
 	// over modifiers appearing earlier.
 	wildcardModifiers []*attributeModifier
 	agentDests        map[string]destinationSet
+	// filter is Config.AttributeFilter, which may be nil.
+	filter AttributeFilter
+	// logger is used to log panics in the filter.
+	logger Logger
+	// userLimit is the maximum number of user attributes.
+	userLimit int
 }
 
 type includeExclude struct {
@@ -156,6 +219,62 @@ func applyAttributeConfig(c *attributeConfig, key string, d destinationSet) dest
 	return d
 }
 
+// destinations returns the destinations that an attribute with the given name
+// would be sent to after the include and exclude rules are applied.  Agent
+// attributes start from their default destinations, and user attributes from
+// all destinations.  Config.AttributeFilter is not applied since it depends on
+// the attribute's value.
+func (c *attributeConfig) destinations(name string) destinationSet {
+	if d, ok := c.agentDests[name]; ok {
+		return d
+	}
+	return applyAttributeConfig(c, name, destAll)
+}
+
+// destinationNames returns the public names of the destinations in the set.
+func destinationNames(d destinationSet) []AttributeDestination {
+	var names []AttributeDestination
+	for _, ad := range attributeDestinations {
+		if d&ad.dest != 0 {
+			names = append(names, ad.name)
+		}
+	}
+	return names
+}
+
+// applyAttributeFilter removes the destinations rejected by the
+// Config.AttributeFilter callback.
+func applyAttributeFilter(c *attributeConfig, key string, val interface{}, d destinationSet) destinationSet {
+	if nil == c || nil == c.filter {
+		return d
+	}
+	for _, ad := range attributeDestinations {
+		if d&ad.dest != 0 && !c.callFilter(key, val, ad.name) {
+			d &^= ad.dest
+		}
+	}
+	return d
+}
+
+// callFilter calls the Config.AttributeFilter callback.  If the callback
+// panics, the panic is recovered and logged, and the attribute is dropped
+// from the destination.
+func (c *attributeConfig) callFilter(key string, val interface{}, dest AttributeDestination) (keep bool) {
+	defer func() {
+		if r := recover(); nil != r {
+			keep = false
+			if nil != c.logger {
+				c.logger.Error("panic in attribute filter", map[string]interface{}{
+					"attribute":   key,
+					"destination": string(dest),
+					"panic":       fmt.Sprint(r),
+				})
+			}
+		}
+	}()
+	return c.filter(key, val, dest)
+}
+
 func addModifier(c *attributeConfig, match string, d includeExclude) {
 	if "" == match {
 		return
@@ -222,11 +341,23 @@ func createAttributeConfig(input config, includeEnabled bool) *attributeConfig {
 	processDest(c, includeEnabled, &input.TransactionTracer.Segments.Attributes, destSegment)
 
 	sort.Sort(byMatch(c.wildcardModifiers))
+	c.filter = input.AttributeFilter
+	c.logger = input.Logger
+	c.userLimit = input.maxUserAttributes()
 
 	c.agentDests = make(map[string]destinationSet)
 	for name, dest := range agentAttributeDefaultDests {
 		c.agentDests[name] = applyAttributeConfig(c, name, dest)
 	}
+	for _, hdr := range input.CaptureRequestHeaders {
+		if isBlockedRequestHeader(hdr) {
+			continue
+		}
+		name := requestHeaderAttributeName(hdr)
+		if _, ok := c.agentDests[name]; !ok {
+			c.agentDests[name] = applyAttributeConfig(c, name, usualDests)
+		}
+	}
 
 	return c
 }
@@ -241,13 +372,25 @@ type agentAttributeValue struct {
 	otherVal  interface{}
 }
 
+// value returns the attribute value as passed to Config.AttributeFilter.
+func (v agentAttributeValue) value() interface{} {
+	if v.stringVal != "" {
+		return v.stringVal
+	}
+	return v.otherVal
+}
+
 type agentAttributes map[string]agentAttributeValue
 
 func (a *attributes) filterSpanAttributes(s map[string]jsonWriter, d destinationSet) map[string]jsonWriter {
 	if nil != a {
-		for key := range s {
+		for key, w := range s {
 			if a.config.agentDests[key]&d == 0 {
 				delete(s, key)
+				continue
+			}
+			if nil != a.config.filter && applyAttributeFilter(a.config, key, spanAttributeValue(w), d)&d == 0 {
+				delete(s, key)
 			}
 		}
 	}
@@ -261,7 +404,10 @@ func (a *attributes) GetAgentValue(id string, d destinationSet) (string, interfa
 	if nil == a || 0 == a.config.agentDests[id]&d {
 		return "", nil
 	}
-	v, _ := a.Agent[id]
+	v, ok := a.Agent[id]
+	if ok && 0 == applyAttributeFilter(a.config, id, v.value(), d)&d {
+		return "", nil
+	}
 	return v.stringVal, v.otherVal
 }
 
@@ -317,11 +463,26 @@ func (e invalidAttributeKeyErr) Error() string {
 		e.key, attributeKeyLengthLimit)
 }
 
-type userAttributeLimitErr struct{ key string }
+type userAttributeLimitErr struct {
+	key   string
+	limit int
+}
 
 func (e userAttributeLimitErr) Error() string {
 	return fmt.Sprintf("attribute '%s' discarded: limit of %d reached", e.key,
-		attributeUserLimit)
+		e.limit)
+}
+
+// errTooManyTxnAttributes is returned instead of userAttributeLimitErr when
+// the lower limit set by Config.MaxAttributesPerTransaction is reached.
+type errTooManyTxnAttributes struct {
+	key   string
+	limit int
+}
+
+func (e errTooManyTxnAttributes) Error() string {
+	return fmt.Sprintf("attribute '%s' discarded: Config.MaxAttributesPerTransaction limit of %d reached",
+		e.key, e.limit)
 }
 
 type invalidFloatAttrValue struct {
@@ -399,6 +560,7 @@ func addUserAttribute(a *attributes, key string, val interface{}, d destinationS
 		return err
 	}
 	dests := applyAttributeConfig(a.config, key, d)
+	dests = applyAttributeFilter(a.config, key, val, dests)
 	if destNone == dests {
 		return nil
 	}
@@ -406,8 +568,13 @@ func addUserAttribute(a *attributes, key string, val interface{}, d destinationS
 		a.user = make(map[string]userAttribute)
 	}
 
-	if _, exists := a.user[key]; !exists && len(a.user) >= attributeUserLimit {
-		return userAttributeLimitErr{key}
+	if _, exists := a.user[key]; !exists {
+		if nil != a.config && a.config.userLimit < attributeUserLimit && len(a.user) >= a.config.userLimit {
+			return errTooManyTxnAttributes{key: key, limit: a.config.userLimit}
+		}
+		if len(a.user) >= attributeUserLimit {
+			return userAttributeLimitErr{key: key, limit: attributeUserLimit}
+		}
 	}
 
 	// Note: Duplicates are overridden: last attribute in wins.
@@ -460,6 +627,14 @@ func writeAttributeValueJSON(w *jsonFieldsWriter, key string, val interface{}) {
 }
 
 func agentAttributesJSON(a *attributes, buf *bytes.Buffer, d destinationSet, additionalAttributes ...map[string]string) {
+	agentAttributesJSONOmitting(a, buf, d, "", additionalAttributes...)
+}
+
+// agentAttributesJSONOmitting writes the agent attributes in the same way as
+// agentAttributesJSON, leaving out the attribute named omit.  This allows an
+// attribute to be written to only some of the data types sharing a
+// destination, such as traced errors but not error events.
+func agentAttributesJSONOmitting(a *attributes, buf *bytes.Buffer, d destinationSet, omit string, additionalAttributes ...map[string]string) {
 	if a == nil {
 		buf.WriteString("{}")
 		return
@@ -468,12 +643,19 @@ func agentAttributesJSON(a *attributes, buf *bytes.Buffer, d destinationSet, add
 	w := jsonFieldsWriter{buf: buf}
 	buf.WriteByte('{')
 	for id, val := range a.Agent {
-		if a.config.agentDests[id]&d != 0 {
-			if val.stringVal != "" {
-				w.stringField(id, val.stringVal)
-			} else {
-				writeAttributeValueJSON(&w, id, val.otherVal)
-			}
+		if a.config.agentDests[id]&d == 0 || id == omit {
+			continue
+		}
+		// Only box the value into an interface when there is a filter to
+		// consult:  doing so unconditionally allocates for every string
+		// attribute of every event written.
+		if nil != a.config.filter && applyAttributeFilter(a.config, id, val.value(), d)&d == 0 {
+			continue
+		}
+		if val.stringVal != "" {
+			w.stringField(id, val.stringVal)
+		} else {
+			writeAttributeValueJSON(&w, id, val.otherVal)
 		}
 	}
 
@@ -493,6 +675,7 @@ func userAttributesJSON(a *attributes, buf *bytes.Buffer, d destinationSet, extr
 		w := jsonFieldsWriter{buf: buf}
 		for key, val := range extraAttributes {
 			outputDest := applyAttributeConfig(a.config, key, d)
+			outputDest = applyAttributeFilter(a.config, key, val, outputDest)
 			if outputDest&d != 0 {
 				writeAttributeValueJSON(&w, key, val)
 			}
@@ -540,6 +723,161 @@ func requestAgentAttributes(a *attributes, method string, hdrs http.Header, u *u
 	}
 }
 
+// requestHeadersJSONAttribute records the request headers which are otherwise
+// recorded as separate attributes, along with the captured headers, as a
+// single JSON object keyed by lowercased header name.  Since the object is
+// sent to every destination of AttributeRequestHeaders, headers whose own
+// attribute is not allowed at all of those destinations, by default or by
+// the attribute configuration and filter, are left out.  Headers which would
+// make the object exceed the attribute value length limit are also left out,
+// so that the value is never truncated into invalid JSON.
+func requestHeadersJSONAttribute(a *attributes, hdrs http.Header, host string, captured []string) {
+	if nil == hdrs {
+		return
+	}
+	type header struct{ name, attr, value string }
+	headers := []header{
+		{"accept", AttributeRequestAccept, hdrs.Get("Accept")},
+		{"content-type", AttributeRequestContentType, hdrs.Get("Content-Type")},
+		{"user-agent", AttributeRequestUserAgent, hdrs.Get("User-Agent")},
+		{"referer", AttributeRequestReferer, safeURLFromString(hdrs.Get("Referer"))},
+		{"host", AttributeRequestHost, host},
+		{"content-length", AttributeRequestContentLength, hdrs.Get("Content-Length")},
+	}
+	for _, hdr := range captured {
+		if isBlockedRequestHeader(hdr) {
+			continue
+		}
+		headers = append(headers, header{strings.ToLower(hdr), requestHeaderAttributeName(hdr), hdrs.Get(hdr)})
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, attributeValueLengthLimit))
+	entry := bytes.NewBuffer(make([]byte, 0, 64))
+	seen := make(map[string]struct{}, len(headers))
+	buf.WriteByte('{')
+	for _, hdr := range headers {
+		if _, ok := seen[hdr.name]; ok || hdr.value == "" {
+			continue
+		}
+		seen[hdr.name] = struct{}{}
+		dests := applyAttributeFilter(a.config, hdr.attr, hdr.value, a.config.agentDests[hdr.attr])
+		if a.config.agentDests[AttributeRequestHeaders]&^dests != 0 {
+			continue
+		}
+		entry.Reset()
+		if buf.Len() > 1 {
+			entry.WriteByte(',')
+		}
+		jsonx.AppendString(entry, hdr.name)
+		entry.WriteByte(':')
+		jsonx.AppendString(entry, hdr.value)
+		if buf.Len()+entry.Len()+1 > attributeValueLengthLimit {
+			continue
+		}
+		buf.Write(entry.Bytes())
+	}
+	buf.WriteByte('}')
+	if buf.Len() > 2 {
+		a.Agent.Add(AttributeRequestHeaders, buf.String(), nil)
+	}
+}
+
+// tlsVersionNames contains the names of the TLS versions supported by
+// crypto/tls.
+var tlsVersionNames = map[uint16]string{
+	tls.VersionTLS10: "TLS 1.0",
+	tls.VersionTLS11: "TLS 1.1",
+	tls.VersionTLS12: "TLS 1.2",
+	tls.VersionTLS13: "TLS 1.3",
+}
+
+// tlsVersionName returns the name of a TLS version, or its hexadecimal value
+// if it is not known.
+func tlsVersionName(version uint16) string {
+	if name, ok := tlsVersionNames[version]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04X", version)
+}
+
+// requestTLSAttributes gathers agent attributes from the connection state of
+// requests received over TLS.
+func requestTLSAttributes(a *attributes, state *tls.ConnectionState) {
+	if nil == state {
+		return
+	}
+	a.Agent.Add(AttributeRequestTLSVersion, tlsVersionName(state.Version), nil)
+	a.Agent.Add(AttributeRequestTLSCipherSuite, tls.CipherSuiteName(state.CipherSuite), nil)
+}
+
+// requestSchemeAttribute records the scheme of the original request from the
+// X-Forwarded-Proto header, or from the TLS connection state if the header
+// is absent.  Nothing is recorded if the scheme cannot be determined:  a
+// plaintext connection may have been forwarded by a TLS-terminating proxy.
+func requestSchemeAttribute(a *attributes, hdrs http.Header, state *tls.ConnectionState) {
+	if proto := hdrs.Get("X-Forwarded-Proto"); proto != "" {
+		// Proxies may append to the header, in which case the first
+		// value is the one received from the client.
+		if i := strings.IndexByte(proto, ','); i >= 0 {
+			proto = proto[:i]
+		}
+		switch scheme := strings.ToLower(strings.TrimSpace(proto)); scheme {
+		case "http", "https":
+			a.Agent.Add(AttributeRequestScheme, scheme, nil)
+		}
+		return
+	}
+	if nil != state {
+		a.Agent.Add(AttributeRequestScheme, "https", nil)
+	}
+}
+
+// requestClientRegionAttribute records the value of the header named by
+// Config.ClientRegionHeader, such as "CloudFront-Viewer-Country".
+func requestClientRegionAttribute(a *attributes, hdrs http.Header, header string) {
+	if header == "" {
+		return
+	}
+	if region := strings.TrimSpace(hdrs.Get(header)); region != "" {
+		a.Agent.Add(AttributeRequestClientRegion, region, nil)
+	}
+}
+
+// blockedRequestHeaders contains the lowercased names of request headers
+// which are never captured by Config.CaptureRequestHeaders since they
+// commonly contain credentials.
+var blockedRequestHeaders = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"cookie":              {},
+	"x-api-key":           {},
+}
+
+func isBlockedRequestHeader(hdr string) bool {
+	_, ok := blockedRequestHeaders[strings.ToLower(hdr)]
+	return ok
+}
+
+// requestHeaderAttributeName returns the agent attribute name used for a
+// header listed in Config.CaptureRequestHeaders.
+func requestHeaderAttributeName(hdr string) string {
+	return "request.headers." + strings.ToLower(hdr)
+}
+
+// requestCapturedHeaderAttributes gathers agent attributes from the
+// request headers listed in Config.CaptureRequestHeaders.
+func requestCapturedHeaderAttributes(a *attributes, hdrs http.Header, captured []string) {
+	if nil == hdrs {
+		return
+	}
+	for _, hdr := range captured {
+		if isBlockedRequestHeader(hdr) {
+			continue
+		}
+		a.Agent.Add(requestHeaderAttributeName(hdr), hdrs.Get(hdr), nil)
+	}
+}
+
 // responseHeaderAttributes gather agent attributes from the response headers.
 func responseHeaderAttributes(a *attributes, h http.Header) {
 	if nil == h {
@@ -552,6 +890,33 @@ func responseHeaderAttributes(a *attributes, h http.Header) {
 	}
 }
 
+// responseRetryAfterAttribute records the "Retry-After" header of 429 and 503
+// responses as a number of seconds.  The header may contain either a number
+// of seconds or an HTTP date, which is converted relative to now.
+// Unparseable values are ignored.
+func responseRetryAfterAttribute(a *attributes, code int, h http.Header, now time.Time) {
+	if code != http.StatusTooManyRequests && code != http.StatusServiceUnavailable {
+		return
+	}
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if secs >= 0 {
+			a.Agent.Add(AttributeResponseRetryAfter, "", secs)
+		}
+		return
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		secs := int64(t.Sub(now) / time.Second)
+		if secs < 0 {
+			secs = 0
+		}
+		a.Agent.Add(AttributeResponseRetryAfter, "", secs)
+	}
+}
+
 var (
 	// statusCodeLookup avoids a strconv.Itoa call.
 	statusCodeLookup = map[int]string{
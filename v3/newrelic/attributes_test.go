@@ -305,6 +305,25 @@ func TestNumUserAttributesLimit(t *testing.T) {
 	}
 }
 
+func TestNumUserAttributesTxnLimit(t *testing.T) {
+	c := config{Config: defaultConfig()}
+	c.MaxAttributesPerTransaction = 2
+	attrs := newAttributes(createAttributeConfig(c, true))
+
+	for _, key := range []string{"zip", "zap"} {
+		if err := addUserAttribute(attrs, key, 1, destAll); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := addUserAttribute(attrs, "cant_add_me", 123, destAll)
+	if _, ok := err.(errTooManyTxnAttributes); !ok {
+		t.Fatal(err)
+	}
+	// Existing attributes may still be replaced.
+	if err := addUserAttribute(attrs, "zip", 2, destAll); err != nil {
+		t.Fatal(err)
+	}
+}
 func TestExtraAttributesIncluded(t *testing.T) {
 	cfg := createAttributeConfig(config{Config: defaultConfig()}, true)
 	attrs := newAttributes(cfg)
@@ -455,3 +474,36 @@ func TestGetAgentValue(t *testing.T) {
 		t.Error(outstr, outother)
 	}
 }
+
+func TestAttributeConfigDestinations(t *testing.T) {
+	c := config{Config: defaultConfig()}
+	c.ErrorCollector.Attributes.Exclude = []string{"secret*"}
+	c.TransactionEvents.Attributes.Exclude = []string{AttributeRequestURI}
+	cfg := createAttributeConfig(c, true)
+
+	// Browser monitoring attributes are disabled by default.
+	if d := cfg.destinations("zip"); d != destAll&^destBrowser {
+		t.Error(destToString(d))
+	}
+	if d := cfg.destinations("secret.key"); d != destAll&^destBrowser&^destError {
+		t.Error(destToString(d))
+	}
+	if d := cfg.destinations(AttributeRequestURI); d != usualDests&^destTxnEvent {
+		t.Error(destToString(d))
+	}
+	if d := cfg.destinations(AttributeApdexThreshold); d != destTxnEvent {
+		t.Error(destToString(d))
+	}
+}
+
+func TestDestinationNames(t *testing.T) {
+	if names := destinationNames(destNone); nil != names {
+		t.Error(names)
+	}
+	names := destinationNames(destTxnEvent | destError)
+	if len(names) != 2 ||
+		names[0] != AttributeDestinationTransactionEvents ||
+		names[1] != AttributeDestinationErrors {
+		t.Error(names)
+	}
+}
@@ -55,6 +55,11 @@ type Config struct {
 	// https://docs.newrelic.com/docs/agents/manage-apm-agents/configuration/high-security-mode
 	HighSecurity bool
 
+	// AllowPerTxnSecurityOverride allows Transaction.PreserveErrorMessages
+	// to keep the messages of a transaction's errors when HighSecurity is
+	// enabled.  It is disabled by default.
+	AllowPerTxnSecurityOverride bool
+
 	// SecurityPoliciesToken enables security policies if set to a non-empty
 	// string.  Only set this if security policies have been enabled on your
 	// account.  This cannot be used in conjunction with HighSecurity.
@@ -75,6 +80,14 @@ type Config struct {
 		MaxSamplesStored int
 	}
 
+	// CustomMetricPrefix is prepended to the names of metrics recorded
+	// using Application.RecordCustomMetric.  It must be a single non-empty
+	// segment followed by a slash, eg. "Team/".  The default is "Custom/",
+	// which is also used if the prefix is empty.
+	// Note that segments created using Transaction.StartSegment are always
+	// recorded with the "Custom/" prefix.
+	CustomMetricPrefix string
+
 	// TransactionEvents controls the behavior of transaction analytics
 	// events.
 	TransactionEvents struct {
@@ -86,6 +99,24 @@ type Config struct {
 		// MaxSamplesStored allows you to limit the number of Transaction
 		// Events stored/reported in a given 60-second period
 		MaxSamplesStored int
+		// SampleRate is the fraction, from 0.0 to 1.0, of transactions
+		// whose transaction events are recorded.  The decision is made
+		// using the transaction's priority, so it is consistent with
+		// distributed tracing sampling.  Metrics, errors, and traces are
+		// unaffected.  Events from Synthetics transactions are always
+		// recorded.  By default, this is set to 1.0.
+		SampleRate float64
+	}
+
+	// Apdex controls the Apdex scoring of web transactions.
+	Apdex struct {
+		// IgnoreTransactions lists the names of web transactions, such as
+		// "WebTransaction/Go/healthz", which do not contribute to Apdex.
+		// Each '*' in a name matches any sequence of characters, so
+		// "WebTransaction/Go/internal/*" ignores all transactions with
+		// that prefix.  Matching transactions are otherwise recorded as
+		// web transactions as usual.
+		IgnoreTransactions []string
 	}
 
 	// ErrorCollector controls the capture of errors.
@@ -96,6 +127,56 @@ type Config struct {
 		// CaptureEvents controls whether error analytics events are
 		// captured.
 		CaptureEvents bool
+		// CaptureTraces controls whether traced errors are captured.
+		// Disabling this while leaving CaptureEvents enabled retains
+		// error analytics events without the overhead of error traces.
+		CaptureTraces bool
+		// CaptureTracesSampledOnly limits traced errors to transactions
+		// which have been sampled by the distributed tracer, reducing the
+		// harvest payload during error storms.  Error events and error
+		// metrics are still recorded for all transactions.  Since only
+		// the distributed tracer samples transactions, no traced errors
+		// are captured when this is enabled and DistributedTracer is
+		// disabled.  By default, this is set to false.
+		CaptureTracesSampledOnly bool
+		// ExcludeHandledFromApdex controls whether errors recorded using
+		// Transaction.NoticeHandledError are left out of the decision to
+		// place a transaction in the failing Apdex zone.  Handled errors
+		// are always counted in error metrics.  By default, this is set
+		// to false.
+		ExcludeHandledFromApdex bool
+		// DefaultAttributes are user attributes, such as a build SHA,
+		// added to every traced error and error event.  Attributes of the
+		// same name provided with the error itself take precedence.  Like
+		// other user attributes, these are not recorded when HighSecurity
+		// is enabled or when custom parameters are disabled by security
+		// policy.  Invalid attribute values are ignored.
+		DefaultAttributes map[string]interface{}
+		// CaptureIgnoredTransactionErrors controls whether errors noticed
+		// on a transaction that is ignored, using Transaction.Ignore or a
+		// transaction naming rule, are still recorded as traced errors
+		// and error events.  No other data is recorded for ignored
+		// transactions.  By default, this is set to false.
+		CaptureIgnoredTransactionErrors bool
+		// CaptureSourceContext controls whether traced errors include the
+		// source lines surrounding the line at which the error occurred.
+		// The source is read from disk when errors are harvested, so it is
+		// only included when the source files are available where the
+		// application runs.  By default, this is set to false.
+		CaptureSourceContext bool
+		// CaptureRequestBody controls whether traced errors include the
+		// "request.body" attribute: the first 2048 bytes of the request
+		// body read by the application during the transaction.  Only the
+		// bytes the application reads from the body of the *http.Request
+		// given to Transaction.SetWebRequestHTTP are captured, so nothing
+		// is captured if the body was consumed beforehand.  The body is
+		// never added to error events, and is not captured when high
+		// security mode is enabled or when the attributes include or
+		// custom parameters security policies are disabled.  It is
+		// recorded as the AttributeRequestBody agent attribute, which is
+		// subject to the attribute configuration and
+		// Config.AttributeFilter.  By default, this is set to false.
+		CaptureRequestBody bool
 		// IgnoreStatusCodes controls which http response codes are
 		// automatically turned into errors.  By default, response codes
 		// greater than or equal to 400 or less than 100 -- with the exception
@@ -106,13 +187,36 @@ type Config struct {
 		// be silently captured without impacting any of those. Note that setting an error
 		// code as Ignored will prevent it from being collected, even if its expected.
 		ExpectStatusCodes []int
+		// ExpectSeverities lists the severities, compared case
+		// insensitively, of errors noticed with
+		// Transaction.NoticeErrorWithSeverity which should be treated as
+		// expected errors.  Like other expected errors, they are recorded
+		// but do not affect error metrics or the apdex score.
+		ExpectSeverities []string
 		// Attributes controls the attributes included with errors.
 		Attributes AttributeDestinationConfig
-		// RecordPanics controls whether or not a deferred
-		// Transaction.End will attempt to recover panics, record them
-		// as errors, and then re-panic them.  By default, this is
-		// set to false.
+		// RecordPanics controls whether or not panics recovered by a
+		// deferred Transaction.End are recorded as errors.  A deferred
+		// End always recovers a panic, records the
+		// "Supportability/Go/Transaction/Panic" metric, and then
+		// re-panics it.  By default, this is set to false.
 		RecordPanics bool
+		// RecoverPanics controls whether a panic in a function started
+		// with Transaction.Go is swallowed after it is recorded as an
+		// error.  By default, this is set to false and the panic is
+		// re-panicked.
+		RecoverPanics bool
+		// RecordOnlyClasses, when non-empty, limits the errors which are
+		// recorded to those whose class is listed.  Errors of other
+		// classes still count towards the error metrics, but no error
+		// traces or error events are created for them.  This is the
+		// complement of ignoring errors.
+		RecordOnlyClasses []string
+		// MaxEventsStored sets the desired maximum number of error events
+		// stored per harvest.  When the limit is reached, events with a
+		// higher priority replace those with a lower priority.  The
+		// default is 100.
+		MaxEventsStored int
 		// ErrorGroupCallback is a user defined callback function that takes an error as an input
 		// and returns a string that will be applied to an error to put it in an error group.
 		//
@@ -338,6 +442,70 @@ type Config struct {
 	// Events, and Browser timing header.
 	Attributes AttributeDestinationConfig
 
+	// AttributeFilter, if set, is called to decide whether a user or agent
+	// attribute of a transaction, error, span, or segment is sent to a
+	// destination.  It is evaluated after the include and exclude rules
+	// above, so it only sees attributes that would otherwise be recorded.
+	// Returning false drops the attribute from the given destination.  The
+	// filter may be called many times per transaction, for agent attributes
+	// each time the attribute is written, so it must be fast and safe for
+	// concurrent use.  If the filter panics, the panic is recovered and
+	// logged, and the attribute is dropped from the destination.
+	AttributeFilter AttributeFilter `json:"-"`
+
+	// MaxAttributesPerTransaction limits the number of user attributes
+	// which may be added to a transaction using Transaction.AddAttribute.
+	// Once the limit is reached, further attributes are discarded and the
+	// "Supportability/Go/UserAttributes/LimitExceeded" metric is
+	// incremented.  Attributes captured by the agent do not count against
+	// this limit.  Values that are negative or greater than the default of
+	// 64 are treated as 64.
+	MaxAttributesPerTransaction int
+
+	// CaptureRequestHeaders lists additional request headers, such as
+	// "X-Tenant-ID", to record as agent attributes on web transactions.
+	// Each header is recorded as "request.headers." followed by its
+	// lowercased name and is subject to the attribute destination
+	// configuration.  Headers are not captured when HighSecurity is
+	// enabled, and credential headers such as "Authorization" and "Cookie"
+	// are never captured.
+	CaptureRequestHeaders []string
+
+	// CaptureRequestHeadersAsJSON records the request headers of web
+	// transactions as a single AttributeRequestHeaders agent attribute,
+	// containing a JSON object, instead of one attribute per header.  The
+	// object contains the headers usually recorded, such as "Accept" and
+	// "Content-Type", along with those listed in CaptureRequestHeaders.
+	// Headers whose own attribute is not sent to every destination of
+	// AttributeRequestHeaders, such as "User-Agent" by default, are left
+	// out.  Credential headers are never included, and headers which would
+	// make the value longer than 255 bytes are left out.
+	CaptureRequestHeadersAsJSON bool
+
+	// ClientRegionHeader is the name of a request header, such as
+	// "CloudFront-Viewer-Country", containing the client's geographic
+	// region as set by a CDN or load balancer.  When set, the header's
+	// value is recorded on web transactions as the
+	// AttributeRequestClientRegion agent attribute.  Requests without the
+	// header, or with an empty value, do not have the attribute.
+	ClientRegionHeader string
+
+	// WritesAfterEnd controls the handling of writes to the
+	// http.ResponseWriter returned by Transaction.SetWebResponse after the
+	// transaction has ended, which can happen in streaming handlers.  By
+	// default such writes are silently passed through to the underlying
+	// ResponseWriter.
+	WritesAfterEnd struct {
+		// RecordMetric records each write after the transaction has
+		// ended in the "Supportability/Go/ResponseWriter/WriteAfterEnd"
+		// metric.
+		RecordMetric bool
+		// ReturnError rejects writes after the transaction has ended:
+		// Write returns ErrWriteAfterEnd and WriteHeader does nothing,
+		// without using the underlying ResponseWriter.
+		ReturnError bool
+	}
+
 	// RuntimeSampler controls the collection of runtime statistics like
 	// CPU/Memory usage, goroutine count, and GC pauses.
 	RuntimeSampler struct {
@@ -345,6 +513,83 @@ type Config struct {
 		Enabled bool
 	}
 
+	// CaptureGoroutineCountThreshold controls the sampling of
+	// runtime.NumGoroutine at the end of slow transactions.  When a
+	// transaction's duration exceeds this threshold, the goroutine count is
+	// recorded as the AttributeGoroutineCount agent attribute on the
+	// transaction event.  The default of zero disables this feature.
+	CaptureGoroutineCountThreshold time.Duration
+
+	// CaptureMemStatsThreshold controls the sampling of the heap size at
+	// the end of slow transactions.  When a transaction's duration exceeds
+	// this threshold, runtime.MemStats.HeapAlloc is recorded as the
+	// AttributeHeapAlloc agent attribute on the transaction event.  Reading
+	// the memory statistics briefly stops the world, so choose a threshold
+	// which only slow transactions exceed.  The default of zero disables
+	// this feature.
+	CaptureMemStatsThreshold time.Duration
+
+	// RecordTransactionStartTime controls whether the start time of each
+	// transaction is recorded as the AttributeTransactionStartTime agent
+	// attribute.  This allows transactions to be correlated with systems
+	// which only record wall-clock timestamps.
+	RecordTransactionStartTime bool
+
+	// RecordTransactionLockWait controls whether the cumulative time each
+	// transaction spends waiting to acquire its internal lock is recorded
+	// as the AttributeTransactionLockWait agent attribute on the
+	// transaction event.  This helps to detect contention when many
+	// goroutines use the same transaction concurrently.  Measuring the
+	// wait adds a small cost to every transaction method, so it is
+	// disabled by default.
+	RecordTransactionLockWait bool
+
+	// NonBlockingTransactionEnd controls whether Transaction.End drops the
+	// transaction rather than waiting when the application's data
+	// channel is full because harvest processing cannot keep up.  Dropped
+	// transactions are counted in the
+	// "Supportability/Go/Harvest/Overflow" metric.  By default, End
+	// blocks until the transaction is accepted.
+	NonBlockingTransactionEnd bool
+
+	// TransactionNameCallback, if set, is called when a transaction ends
+	// to rename it based on whether it failed.  See TransactionNameCallback.
+	TransactionNameCallback TransactionNameCallback `json:"-"`
+
+	// OnError, if set, is called synchronously each time a transaction
+	// records an error, for example to integrate with external alerting.
+	// The ErrorInfo has the error message scrubbed according to high
+	// security mode and security policies.  OnError is not called for
+	// errors that are not collected because the error collector is
+	// disabled, either locally or by the server, because their class is
+	// ignored or excluded by ErrorCollector.RecordOnlyClasses, or because
+	// the transaction has already recorded the maximum of five errors.
+	// OnError is called while the transaction is locked, so it must not
+	// call methods of the Transaction.  Panics in OnError are recovered and
+	// logged.
+	OnError func(ErrorInfo) `json:"-"`
+
+	// TransactionTypeResolver, if set, is called by
+	// Transaction.SetWebRequestHTTP to decide whether the transaction is a
+	// web transaction, rather than treating every transaction given a
+	// request as one.  It is not called with the transaction locked.  If
+	// the resolver panics, the panic is recovered and logged, and the
+	// transaction is classified as though no resolver was set.  See
+	// TransactionTypeResolver.
+	TransactionTypeResolver TransactionTypeResolver `json:"-"`
+
+	// RecentTransactionBufferSize is the number of finished transaction
+	// summaries retained in memory and returned by
+	// Application.RecentTransactions.  This is intended for exposing recent
+	// activity on a debugging endpoint.  The default of zero disables the
+	// buffer.
+	RecentTransactionBufferSize int
+
+	// ErrorRateWindow is the length of the sliding window over which
+	// Application.ErrorRate is computed.  It is rounded down to whole
+	// seconds.  The default of zero disables tracking of the error rate.
+	ErrorRateWindow time.Duration
+
 	// ServerlessMode contains fields which control behavior when running in
 	// AWS Lambda.
 	//
@@ -561,6 +806,29 @@ type ApplicationLogging struct {
 		// Controls the overall memory consumption when using log forwarding.
 		// SHOULD be sent as part of the harvest_limits on Connect.
 		MaxSamplesStored int
+		// TimestampFormat controls how the timestamp of each forwarded
+		// log record is represented.  Supported values are
+		// LogTimestampFormatEpochMillis (the default), an integer number
+		// of milliseconds since the Unix epoch, and
+		// LogTimestampFormatRFC3339, a string including the local time
+		// zone offset.  Unrecognized values use the default.
+		TimestampFormat string
+		// CaptureSource controls whether the file name and line number of
+		// the code which called Application.RecordLog or
+		// Transaction.RecordLog are captured using runtime.Caller when
+		// LogData.SourceFile is not set.  Capturing the caller has a cost,
+		// so it is disabled by default.
+		CaptureSource bool
+		// MessageScrubber, if set, is applied to the message of every
+		// forwarded log record, for example to remove email addresses
+		// before the message leaves the process.  It is called after
+		// surrounding whitespace is trimmed and before the message is
+		// checked against MaxLogLength.  It is not applied to locally
+		// decorated logs, whose messages are written by the logging
+		// framework rather than the agent.  If the scrubber panics, the
+		// panic is recovered, an error is logged, and the log record is
+		// dropped.
+		MessageScrubber func(message string) string `json:"-"`
 	}
 	Metrics struct {
 		// Toggles whether the agent gathers the the user facing Logging/lines and Logging/lines/{SEVERITY}
@@ -573,6 +841,12 @@ type ApplicationLogging struct {
 	}
 }
 
+// Values for Config.ApplicationLogging.Forwarding.TimestampFormat.
+const (
+	LogTimestampFormatEpochMillis = "epoch_millis"
+	LogTimestampFormatRFC3339     = "rfc3339"
+)
+
 // AttributeDestinationConfig controls the attributes sent to each destination.
 // For more information, see:
 // https://docs.newrelic.com/docs/agents/manage-apm-agents/agent-data/agent-attributes
@@ -607,12 +881,16 @@ func defaultConfig() Config {
 	c.Labels = make(map[string]string)
 	c.CustomInsightsEvents.Enabled = true
 	c.CustomInsightsEvents.MaxSamplesStored = internal.MaxCustomEvents
+	c.CustomMetricPrefix = defaultCustomMetricPrefix
 	c.TransactionEvents.Enabled = true
 	c.TransactionEvents.Attributes.Enabled = true
 	c.TransactionEvents.MaxSamplesStored = internal.MaxTxnEvents
+	c.TransactionEvents.SampleRate = 1.0
+	c.MaxAttributesPerTransaction = attributeUserLimit
 	c.HighSecurity = false
 	c.ErrorCollector.Enabled = true
 	c.ErrorCollector.CaptureEvents = true
+	c.ErrorCollector.CaptureTraces = true
 	c.ErrorCollector.IgnoreStatusCodes = []int{
 		// https://github.com/grpc/grpc/blob/master/doc/statuscodes.md
 		0,                   // gRPC OK
@@ -620,6 +898,7 @@ func defaultConfig() Config {
 		http.StatusNotFound, // 404
 	}
 	c.ErrorCollector.Attributes.Enabled = true
+	c.ErrorCollector.MaxEventsStored = internal.MaxErrorEvents
 	c.Utilization.DetectAWS = true
 	c.Utilization.DetectAzure = true
 	c.Utilization.DetectPCF = true
@@ -641,6 +920,7 @@ func defaultConfig() Config {
 	c.ApplicationLogging.Enabled = true
 	c.ApplicationLogging.Forwarding.Enabled = true
 	c.ApplicationLogging.Forwarding.MaxSamplesStored = internal.MaxLogEvents
+	c.ApplicationLogging.Forwarding.TimestampFormat = LogTimestampFormatEpochMillis
 	c.ApplicationLogging.Metrics.Enabled = true
 	c.ApplicationLogging.LocalDecorating.Enabled = false
 
@@ -694,6 +974,7 @@ var (
 	errAppNameLimit                     = fmt.Errorf("max of %d rollup application names", appNameLimit)
 	errHighSecurityWithSecurityPolicies = errors.New("SecurityPoliciesToken and HighSecurity are incompatible; please ensure HighSecurity is set to false if SecurityPoliciesToken is a non-empty string and a security policy has been set for your account")
 	errInfTracingServerless             = errors.New("ServerlessMode cannot be used with Infinite Tracing")
+	errCustomMetricPrefix               = errors.New(`CustomMetricPrefix must be a single non-empty segment followed by a slash, eg. "Custom/"`)
 )
 
 // validate checks the config for improper fields.  If the config is invalid,
@@ -721,10 +1002,20 @@ func (c Config) validate() error {
 	if c.InfiniteTracing.TraceObserver.Host != "" && c.ServerlessMode.Enabled {
 		return errInfTracingServerless
 	}
+	if c.CustomMetricPrefix != "" && !validCustomMetricPrefix(c.CustomMetricPrefix) {
+		return errCustomMetricPrefix
+	}
 
 	return nil
 }
 
+// validCustomMetricPrefix returns true if the prefix is a single non-empty
+// segment followed by a slash.
+func validCustomMetricPrefix(prefix string) bool {
+	segment := strings.TrimSuffix(prefix, "/")
+	return segment != "" && len(segment) == len(prefix)-1 && !strings.Contains(segment, "/")
+}
+
 func (c Config) validateTraceObserverConfig() (*observerURL, error) {
 	configHost := c.InfiniteTracing.TraceObserver.Host
 	if configHost == "" {
@@ -755,6 +1046,27 @@ func (c Config) maxTxnEvents() int {
 	return configured
 }
 
+// maxErrorEvents returns the configured maximum number of Error Events if it
+// has been configured; otherwise it returns the default max.
+func (c Config) maxErrorEvents() int {
+	configured := c.ErrorCollector.MaxEventsStored
+	if configured < 0 {
+		return internal.MaxErrorEvents
+	}
+	return configured
+}
+
+// maxUserAttributes returns the configured maximum number of user attributes
+// per transaction if it has been configured and is less than the default
+// maximum; otherwise it returns the default max.
+func (c Config) maxUserAttributes() int {
+	configured := c.MaxAttributesPerTransaction
+	if configured < 0 || configured > attributeUserLimit {
+		return attributeUserLimit
+	}
+	return configured
+}
+
 // maxCustomEvents returns the configured maximum number of Custom Events if it has been configured
 // and is less than the default maximum; otherwise it returns the default max.
 func (c Config) maxCustomEvents() int {
@@ -951,7 +1263,7 @@ func configConnectJSONInternal(c Config, pid int, util *utilization.Data, e envi
 		Util:             util,
 		SecurityPolicies: securityPolicies,
 		Metadata:         metadata,
-		EventData:        internal.DefaultEventHarvestConfigWithDT(c.TransactionEvents.MaxSamplesStored, c.ApplicationLogging.Forwarding.MaxSamplesStored, c.CustomInsightsEvents.MaxSamplesStored, c.DistributedTracer.ReservoirLimit, c.DistributedTracer.Enabled),
+		EventData:        internal.DefaultEventHarvestConfigWithDT(c.TransactionEvents.MaxSamplesStored, c.ApplicationLogging.Forwarding.MaxSamplesStored, c.CustomInsightsEvents.MaxSamplesStored, c.maxErrorEvents(), c.DistributedTracer.ReservoirLimit, c.DistributedTracer.Enabled),
 	}})
 }
 
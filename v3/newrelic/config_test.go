@@ -130,12 +130,16 @@ func TestCopyConfigReferenceFieldsPresent(t *testing.T) {
 		"agent_version":"0.2.2",
 		"host":"my-hostname",
 		"settings":{
+			"AllowPerTxnSecurityOverride":false,
+			"Apdex":{"IgnoreTransactions":null},
 			"AppName":"my appname",
 			"ApplicationLogging": {
 				"Enabled": true,
 				"Forwarding": {
+					"CaptureSource": false,
 					"Enabled": true,
-					"MaxSamplesStored": %d
+					"MaxSamplesStored": %d,
+					"TimestampFormat": "epoch_millis"
 				},
 				"LocalDecorating":{
 					"Enabled": false
@@ -149,12 +153,18 @@ func TestCopyConfigReferenceFieldsPresent(t *testing.T) {
 				"Attributes":{"Enabled":false,"Exclude":["10"],"Include":["9"]},
 				"Enabled":true
 			},
+			"CaptureGoroutineCountThreshold":0,
+			"CaptureMemStatsThreshold":0,
+			"CaptureRequestHeaders":null,
+			"CaptureRequestHeadersAsJSON":false,
+			"ClientRegionHeader":"",
 			"CodeLevelMetrics":{"Enabled":true,"IgnoredPrefix":"","IgnoredPrefixes":null,"PathPrefix":"","PathPrefixes":null,"RedactIgnoredPrefixes":true,"RedactPathPrefixes":true,"Scope":"all"},
 			"CrossApplicationTracer":{"Enabled":false},
 			"CustomInsightsEvents":{
 				"Enabled":true,
 				"MaxSamplesStored":%d
 			},
+			"CustomMetricPrefix":"Custom/",
 			"DatastoreTracer":{
 				"DatabaseNameReporting":{"Enabled":true},
 				"InstanceReporting":{"Enabled":true},
@@ -171,11 +181,23 @@ func TestCopyConfigReferenceFieldsPresent(t *testing.T) {
 			"ErrorCollector":{
 				"Attributes":{"Enabled":true,"Exclude":["6"],"Include":["5"]},
 				"CaptureEvents":true,
+				"CaptureIgnoredTransactionErrors":false,
+				"CaptureRequestBody":false,
+				"CaptureSourceContext":false,
+				"CaptureTraces":true,
+				"CaptureTracesSampledOnly":false,
+				"DefaultAttributes":null,
 				"Enabled":true,
+				"ExcludeHandledFromApdex":false,
+				"ExpectSeverities":null,
 				"ExpectStatusCodes":[500],
 				"IgnoreStatusCodes":[0,5,404,405],
-				"RecordPanics":false
+				"MaxEventsStored":100,
+				"RecordOnlyClasses":null,
+				"RecordPanics":false,
+				"RecoverPanics":false
 			},
+			"ErrorRateWindow":0,
 			"Heroku":{
 				"DynoNamePrefixesToShorten":["scheduler","run"],
 				"UseDynoNames":true
@@ -192,7 +214,12 @@ func TestCopyConfigReferenceFieldsPresent(t *testing.T) {
 			},
 			"Labels":{"zip":"zap"},
 			"Logger":"*logger.logFile",
+			"MaxAttributesPerTransaction":64,
 			"ModuleDependencyMetrics":{"Enabled":true,"IgnoredPrefixes":null,"RedactIgnoredPrefixes":true},
+			"NonBlockingTransactionEnd":false,
+			"RecentTransactionBufferSize":0,
+			"RecordTransactionLockWait":false,
+			"RecordTransactionStartTime":false,
 			"RuntimeSampler":{"Enabled":true},
 			"SecurityPoliciesToken":"",
 			"ServerlessMode":{
@@ -211,7 +238,8 @@ func TestCopyConfigReferenceFieldsPresent(t *testing.T) {
 			"TransactionEvents":{
 				"Attributes":{"Enabled":true,"Exclude":["4"],"Include":["3"]},
 				"Enabled":true,
-				"MaxSamplesStored": %d
+				"MaxSamplesStored": %d,
+				"SampleRate":1
 			},
 			"TransactionTracer":{
 				"Attributes":{"Enabled":true,"Exclude":["8"],"Include":["7"]},
@@ -238,6 +266,7 @@ func TestCopyConfigReferenceFieldsPresent(t *testing.T) {
 				"LogicalProcessors":0,
 				"TotalRAMMIB":0
 			},
+			"WritesAfterEnd":{"RecordMetric":false,"ReturnError":false},
 			"browser_monitoring.loader":"rum"
 		},
 		"app_name":["my appname"],
@@ -326,12 +355,16 @@ func TestCopyConfigReferenceFieldsAbsent(t *testing.T) {
 		"agent_version":"0.2.2",
 		"host":"my-hostname",
 		"settings":{
+			"AllowPerTxnSecurityOverride":false,
+			"Apdex":{"IgnoreTransactions":null},
 			"AppName":"my appname",
 			"ApplicationLogging": {
 				"Enabled": true,
 				"Forwarding": {
+					"CaptureSource": false,
 					"Enabled": true,
-					"MaxSamplesStored": %d
+					"MaxSamplesStored": %d,
+					"TimestampFormat": "epoch_millis"
 				},
 				"LocalDecorating":{
 					"Enabled": false
@@ -349,12 +382,18 @@ func TestCopyConfigReferenceFieldsAbsent(t *testing.T) {
 				},
 				"Enabled":true
 			},
+			"CaptureGoroutineCountThreshold":0,
+			"CaptureMemStatsThreshold":0,
+			"CaptureRequestHeaders":null,
+			"CaptureRequestHeadersAsJSON":false,
+			"ClientRegionHeader":"",
 			"CodeLevelMetrics":{"Enabled":true,"IgnoredPrefix":"","IgnoredPrefixes":null,"PathPrefix":"","PathPrefixes":null,"RedactIgnoredPrefixes":true,"RedactPathPrefixes":true,"Scope":"all"},
 			"CrossApplicationTracer":{"Enabled":false},
 			"CustomInsightsEvents":{
 				"Enabled":true,
 				"MaxSamplesStored":%d
 			},
+			"CustomMetricPrefix":"Custom/",
 			"DatastoreTracer":{
 				"DatabaseNameReporting":{"Enabled":true},
 				"InstanceReporting":{"Enabled":true},
@@ -371,11 +410,23 @@ func TestCopyConfigReferenceFieldsAbsent(t *testing.T) {
 			"ErrorCollector":{
 				"Attributes":{"Enabled":true,"Exclude":null,"Include":null},
 				"CaptureEvents":true,
+				"CaptureIgnoredTransactionErrors":false,
+				"CaptureRequestBody":false,
+				"CaptureSourceContext":false,
+				"CaptureTraces":true,
+				"CaptureTracesSampledOnly":false,
+				"DefaultAttributes":null,
 				"Enabled":true,
+				"ExcludeHandledFromApdex":false,
+				"ExpectSeverities":null,
 				"ExpectStatusCodes":null,
 				"IgnoreStatusCodes":null,
-				"RecordPanics":false
+				"MaxEventsStored":100,
+				"RecordOnlyClasses":null,
+				"RecordPanics":false,
+				"RecoverPanics":false
 			},
+			"ErrorRateWindow":0,
 			"Heroku":{
 				"DynoNamePrefixesToShorten":["scheduler","run"],
 				"UseDynoNames":true
@@ -392,7 +443,12 @@ func TestCopyConfigReferenceFieldsAbsent(t *testing.T) {
 			},
 			"Labels":null,
 			"Logger":null,
+			"MaxAttributesPerTransaction":64,
 			"ModuleDependencyMetrics":{"Enabled":true,"IgnoredPrefixes":null,"RedactIgnoredPrefixes":true},
+			"NonBlockingTransactionEnd":false,
+			"RecentTransactionBufferSize":0,
+			"RecordTransactionLockWait":false,
+			"RecordTransactionStartTime":false,
 			"RuntimeSampler":{"Enabled":true},
 			"SecurityPoliciesToken":"",
 			"ServerlessMode":{
@@ -409,7 +465,8 @@ func TestCopyConfigReferenceFieldsAbsent(t *testing.T) {
 			"TransactionEvents":{
 				"Attributes":{"Enabled":true,"Exclude":null,"Include":null},
 				"Enabled":true,
-				"MaxSamplesStored": %d
+				"MaxSamplesStored": %d,
+				"SampleRate":1
 			},
 			"TransactionTracer":{
 				"Attributes":{"Enabled":true,"Exclude":null,"Include":null},
@@ -436,6 +493,7 @@ func TestCopyConfigReferenceFieldsAbsent(t *testing.T) {
 				"LogicalProcessors":0,
 				"TotalRAMMIB":0
 			},
+			"WritesAfterEnd":{"RecordMetric":false,"ReturnError":false},
 			"browser_monitoring.loader":"rum"
 		},
 		"app_name":["my appname"],
@@ -585,6 +643,28 @@ func TestValidateWithPoliciesToken(t *testing.T) {
 	}
 }
 
+func TestValidateCustomMetricPrefix(t *testing.T) {
+	for prefix, valid := range map[string]bool{
+		"":          true,
+		"Custom/":   true,
+		"Team/":     true,
+		"Team":      false,
+		"/":         false,
+		"Team//":    false,
+		"Team/Sub/": false,
+		"/Team/":    false,
+	} {
+		c := Config{CustomMetricPrefix: prefix}
+		err := c.validate()
+		if valid && err != nil {
+			t.Error(prefix, err)
+		}
+		if !valid && err != errCustomMetricPrefix {
+			t.Error(prefix, err)
+		}
+	}
+}
+
 func TestGatherMetadata(t *testing.T) {
 	metadata := gatherMetadata(nil)
 	if !reflect.DeepEqual(metadata, map[string]string{}) {
@@ -699,6 +779,31 @@ func TestPreconnectHostCrossAgent(t *testing.T) {
 	}
 }
 
+func TestConfigMaxErrorEvents(t *testing.T) {
+	cfg := defaultConfig()
+	if n := cfg.maxErrorEvents(); n != internal.MaxErrorEvents {
+		t.Error(n)
+	}
+
+	cfg = defaultConfig()
+	cfg.ErrorCollector.MaxEventsStored = 434
+	if n := cfg.maxErrorEvents(); n != 434 {
+		t.Error(n)
+	}
+
+	cfg = defaultConfig()
+	cfg.ErrorCollector.MaxEventsStored = 0
+	if n := cfg.maxErrorEvents(); n != 0 {
+		t.Error(n)
+	}
+
+	cfg = defaultConfig()
+	cfg.ErrorCollector.MaxEventsStored = -1
+	if n := cfg.maxErrorEvents(); n != internal.MaxErrorEvents {
+		t.Error(n)
+	}
+}
+
 func TestConfigMaxTxnEvents(t *testing.T) {
 	cfg := defaultConfig()
 	if n := cfg.maxTxnEvents(); n != internal.MaxTxnEvents {
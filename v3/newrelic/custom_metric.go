@@ -6,10 +6,12 @@ package newrelic
 // customMetric is a custom metric.
 type customMetric struct {
 	RawInputName string
-	Value        float64
+	// Prefix is the value of Config.CustomMetricPrefix.
+	Prefix string
+	Value  float64
 }
 
 // MergeIntoHarvest implements Harvestable.
 func (m customMetric) MergeIntoHarvest(h *harvest) {
-	h.Metrics.addValue(customMetricName(m.RawInputName), "", m.Value, unforced)
+	h.Metrics.addValue(customMetricName(m.Prefix, m.RawInputName), "", m.Value, unforced)
 }
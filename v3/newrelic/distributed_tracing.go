@@ -236,14 +236,17 @@ const (
 	traceStateVersion = "0"
 )
 
+// w3cTraceFlags returns the W3C trace-flags for the sampling decision.
+func w3cTraceFlags(sampled bool) string {
+	if sampled {
+		return "01"
+	}
+	return "00"
+}
+
 // W3CTraceParent returns the W3C TraceParent header for this payload
 func (p payload) W3CTraceParent() string {
-	var flags string
-	if p.isSampled() {
-		flags = "01"
-	} else {
-		flags = "00"
-	}
+	flags := w3cTraceFlags(p.isSampled())
 	traceID := strings.ToLower(p.TracedID)
 	if idLen := len(traceID); idLen < internal.TraceIDHexStringLen {
 		traceID = strings.Repeat("0", internal.TraceIDHexStringLen-idLen) + traceID
@@ -699,3 +699,12 @@ func Test_processTraceState_invalidEntry(t *testing.T) {
 		t.Errorf("expected invalidNRTraceState error but got %v", err)
 	}
 }
+
+func TestW3CTraceFlags(t *testing.T) {
+	if f := w3cTraceFlags(true); f != "01" {
+		t.Error(f)
+	}
+	if f := w3cTraceFlags(false); f != "00" {
+		t.Error(f)
+	}
+}
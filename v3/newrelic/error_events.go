@@ -31,9 +31,21 @@ func (e *errorEvent) WriteJSON(buf *bytes.Buffer) {
 	if e.SpanID != "" {
 		w.stringField("spanId", e.SpanID)
 	}
+	if e.ID != "" {
+		w.stringField(errorIDAttr, e.ID)
+	}
+	if e.Severity != "" {
+		w.stringField(errorSeverityAttr, e.Severity)
+	}
 	if e.Expect {
 		w.boolField(expectErrorAttr, true)
 	}
+	if e.Handled {
+		w.boolField(handledErrorAttr, true)
+	}
+	if e.RecoveredPanic {
+		w.stringField(errorSourceAttr, errorSourceRecoveredPanic)
+	}
 
 	sharedTransactionIntrinsics(&e.txnEvent, &w)
 	sharedBetterCATIntrinsics(&e.txnEvent, &w)
@@ -43,10 +55,11 @@ func (e *errorEvent) WriteJSON(buf *bytes.Buffer) {
 	userAttributesJSON(e.Attrs, buf, destError, e.errorData.ExtraAttributes)
 	buf.WriteByte(',')
 
+	// The request body is only recorded on traced errors.
 	if e.ErrorGroup != "" {
-		agentAttributesJSON(e.Attrs, buf, destError, map[string]string{AttributeErrorGroupName: e.ErrorGroup})
+		agentAttributesJSONOmitting(e.Attrs, buf, destError, AttributeRequestBody, map[string]string{AttributeErrorGroupName: e.ErrorGroup})
 	} else {
-		agentAttributesJSON(e.Attrs, buf, destError)
+		agentAttributesJSONOmitting(e.Attrs, buf, destError, AttributeRequestBody)
 	}
 
 	buf.WriteByte(']')
@@ -0,0 +1,76 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"sync"
+	"time"
+)
+
+// errorRateBucket counts the transactions which finished during one second.
+type errorRateBucket struct {
+	second int64
+	txns   int
+	errors int
+}
+
+// errorRate tracks the fraction of recently finished transactions which
+// noticed errors.  It keeps one bucket per second of the window, so the
+// window slides in one second steps.
+type errorRate struct {
+	sync.Mutex
+	buckets []errorRateBucket
+}
+
+// newErrorRate returns nil if the window is shorter than one second.
+func newErrorRate(window time.Duration) *errorRate {
+	seconds := int(window / time.Second)
+	if seconds <= 0 {
+		return nil
+	}
+	return &errorRate{
+		buckets: make([]errorRateBucket, seconds),
+	}
+}
+
+func (r *errorRate) add(now time.Time, hasErrors bool) {
+	if nil == r {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+
+	second := now.Unix()
+	b := &r.buckets[int(second%int64(len(r.buckets)))]
+	if b.second != second {
+		*b = errorRateBucket{second: second}
+	}
+	b.txns++
+	if hasErrors {
+		b.errors++
+	}
+}
+
+// rate returns the fraction of transactions finished within the window
+// ending at now which noticed errors, or zero if there were none.
+func (r *errorRate) rate(now time.Time) float64 {
+	if nil == r {
+		return 0
+	}
+	r.Lock()
+	defer r.Unlock()
+
+	oldest := now.Unix() - int64(len(r.buckets))
+	var txns, errors int
+	for _, b := range r.buckets {
+		if b.second > oldest {
+			txns += b.txns
+			errors += b.errors
+		}
+	}
+	if 0 == txns {
+		return 0
+	}
+	return float64(errors) / float64(txns)
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestErrorRateDisabled(t *testing.T) {
+	r := newErrorRate(500 * time.Millisecond)
+	if r != nil {
+		t.Fatal("expected nil error rate for window under one second")
+	}
+	now := time.Now()
+	r.add(now, true)
+	if rate := r.rate(now); rate != 0 {
+		t.Error(rate)
+	}
+}
+
+func TestErrorRateWindowSlides(t *testing.T) {
+	r := newErrorRate(10 * time.Second)
+	start := time.Unix(1700000000, 0)
+	if rate := r.rate(start); rate != 0 {
+		t.Error(rate)
+	}
+	r.add(start, true)
+	r.add(start, false)
+	r.add(start.Add(5*time.Second), false)
+	r.add(start.Add(5*time.Second), false)
+	if rate := r.rate(start.Add(5 * time.Second)); rate != 0.25 {
+		t.Error(rate)
+	}
+	// The first bucket has left the window.
+	if rate := r.rate(start.Add(10 * time.Second)); rate != 0 {
+		t.Error(rate)
+	}
+	// The first bucket is reused for a later second.
+	r.add(start.Add(10*time.Second), true)
+	if rate := r.rate(start.Add(10 * time.Second)); rate != 1.0/3.0 {
+		t.Error(rate)
+	}
+	if rate := r.rate(start.Add(30 * time.Second)); rate != 0 {
+		t.Error(rate)
+	}
+}
+
+func TestApplicationErrorRate(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.ErrorRateWindow = time.Minute
+	}, t)
+	txn := app.StartTransaction("one")
+	txn.NoticeError(myError{})
+	txn.End()
+	txn = app.StartTransaction("two")
+	txn.NoticeExpectedError(myError{})
+	txn.End()
+	txn = app.StartTransaction("three")
+	txn.End()
+	txn = app.StartTransaction("four")
+	txn.End()
+
+	if rate := app.ErrorRate(); rate != 0.25 {
+		t.Error(rate)
+	}
+}
+
+func TestApplicationErrorRateDisabled(t *testing.T) {
+	app := testApp(nil, nil, t)
+	txn := app.StartTransaction("one")
+	txn.NoticeError(myError{})
+	txn.End()
+	if rate := app.ErrorRate(); rate != 0 {
+		t.Error(rate)
+	}
+	var nilApp *Application
+	if rate := nilApp.ErrorRate(); rate != 0 {
+		t.Error(rate)
+	}
+}
@@ -31,9 +31,10 @@ func panicValueMsg(v interface{}) string {
 // txnErrorFromPanic creates a new TxnError from a panic.
 func txnErrorFromPanic(now time.Time, v interface{}) errorData {
 	return errorData{
-		When:  now,
-		Msg:   panicValueMsg(v),
-		Klass: panicErrorKlass,
+		When:           now,
+		Msg:            panicValueMsg(v),
+		Klass:          panicErrorKlass,
+		RecoveredPanic: true,
 	}
 }
 
@@ -63,7 +64,38 @@ type errorData struct {
 	Msg             string
 	Klass           string
 	SpanID          string
-	Expect          bool
+	// ID is the identifier generated by Transaction.NoticeErrorWithID.
+	ID string
+	// Severity is the severity given to Transaction.NoticeErrorWithSeverity.
+	Severity string
+	Expect   bool
+	Handled  bool
+	// RecoveredPanic is true when the error was created from a panic
+	// recovered by Transaction.End rather than reported by the user.
+	RecoveredPanic bool
+	// InfoOnly is true when the error was recorded by
+	// Transaction.RecordErrorInfoOnly and must not affect metrics or apdex.
+	InfoOnly      bool
+	SourceContext *sourceContext
+}
+
+// addDefaultAttributes adds the valid default attributes to the error's extra
+// attributes.  Extra attributes already present and the transaction's user
+// attributes take precedence.
+func (e *errorData) addDefaultAttributes(defaults map[string]interface{}, attrs *attributes) {
+	extra := make(map[string]interface{}, len(defaults)+len(e.ExtraAttributes))
+	for key, val := range defaults {
+		if _, ok := attrs.user[key]; ok {
+			continue
+		}
+		if val, err := validateUserAttribute(key, val); nil == err {
+			extra[key] = val
+		}
+	}
+	for key, val := range e.ExtraAttributes {
+		extra[key] = val
+	}
+	e.ExtraAttributes = extra
 }
 
 // txnError combines error data with information about a transaction.  txnError is used for
@@ -87,11 +119,14 @@ func newTxnErrors(max int) txnErrors {
 	return make([]*errorData, 0, max)
 }
 
-// Add adds a TxnError.
-func (errors *txnErrors) Add(e errorData) {
+// Add adds a TxnError, returning false if the set is full and the error is
+// dropped.
+func (errors *txnErrors) Add(e errorData) bool {
 	if len(*errors) < cap(*errors) {
 		*errors = append(*errors, &e)
+		return true
 	}
+	return false
 }
 
 func (h *tracedError) WriteJSON(buf *bytes.Buffer) {
@@ -116,13 +151,19 @@ func (h *tracedError) WriteJSON(buf *bytes.Buffer) {
 	buf.WriteByte(',')
 	buf.WriteString(`"intrinsics"`)
 	buf.WriteByte(':')
-	intrinsicsJSON(&h.txnEvent, buf, h.errorData.Expect)
+	intrinsicsJSON(&h.txnEvent, buf, h.errorData.Expect, h.errorData.ID)
 	if nil != h.Stack {
 		buf.WriteByte(',')
 		buf.WriteString(`"stack_trace"`)
 		buf.WriteByte(':')
 		h.Stack.WriteJSON(buf)
 	}
+	if nil != h.SourceContext {
+		buf.WriteByte(',')
+		buf.WriteString(`"source_context"`)
+		buf.WriteByte(':')
+		h.SourceContext.WriteJSON(buf)
+	}
 	buf.WriteByte('}')
 	buf.WriteByte(',')
 	jsonx.AppendString(buf, h.txnEvent.TxnID)
@@ -186,11 +227,15 @@ func (errors harvestErrors) EndpointMethod() string {
 }
 
 // applyErrorGroup applies the error group callback function to an errorData object. It will either consume the txn object
-// or the txnEvent in that order. If both are nil, nothing will happen.
+// or the txnEvent in that order. If both are nil, nothing will happen. Errors given an explicit group using
+// Transaction.NoticeErrorWithGroupAndAttributes keep that group.
 func (errData *errorData) applyErrorGroup(txnEvent *txnEvent) {
 	if txnEvent == nil || txnEvent.errGroupCallback == nil {
 		return
 	}
+	if errData.ErrorGroup != "" {
+		return
+	}
 
 	errorInfo := ErrorInfo{
 		txnAttributes:   txnEvent.Attrs,
@@ -238,7 +283,7 @@ func scrubbedErrorMessage(msg string, txn *txn) string {
 		return msg
 	}
 
-	if txn.Config.HighSecurity {
+	if txn.redactsErrorMessages() {
 		return highSecurityErrorMsg
 	}
 
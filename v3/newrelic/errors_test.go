@@ -69,6 +69,47 @@ func TestErrorNoCAT(t *testing.T) {
 	testExpectedJSON(t, expect, string(js))
 }
 
+func TestErrorTraceMarshalWithID(t *testing.T) {
+	he := &tracedError{
+		errorData: errorData{
+			When:  time.Date(2014, time.November, 28, 1, 1, 0, 0, time.UTC),
+			Stack: emptyStackTrace,
+			Msg:   "my_msg",
+			Klass: "my_class",
+			ID:    "my-error-id",
+		},
+		txnEvent: txnEvent{
+			FinalName: "my_txn_name",
+			Attrs:     nil,
+			TxnID:     "txn-guid-id",
+			TotalTime: 2 * time.Second,
+		},
+	}
+	js, err := json.Marshal(he)
+	if nil != err {
+		t.Error(err)
+	}
+
+	expect := `
+	[
+		1.41713646e+12,
+		"my_txn_name",
+		"my_msg",
+		"my_class",
+		{
+			"agentAttributes":{},
+			"userAttributes":{},
+			"intrinsics":{
+				"totalTime":2,
+				"error.id":"my-error-id"
+			},
+			"stack_trace":[]
+		},
+		"txn-guid-id"
+	]`
+	testExpectedJSON(t, expect, string(js))
+}
+
 func TestErrorTraceMarshal(t *testing.T) {
 	he := &tracedError{
 		errorData: errorData{
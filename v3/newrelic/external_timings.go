@@ -0,0 +1,131 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// ExternalSegmentOption configures an ExternalSegment started using
+// StartExternalSegment or NewRoundTripper.
+type ExternalSegmentOption func(*ExternalSegment)
+
+// WithConnectionTimings breaks down the time of an outbound HTTP request into
+// its DNS lookup, connection, TLS handshake, and time to first byte.  An
+// httptrace.ClientTrace is added to the request's context, and the timings
+// are recorded on the external segment's span event and transaction trace
+// node as the SpanAttributeHTTPDNSDuration, SpanAttributeHTTPConnectDuration,
+// SpanAttributeHTTPTLSDuration, and SpanAttributeHTTPTimeToFirstByte
+// attributes.  Requests which reuse a connection record zero DNS and connect
+// durations, and set SpanAttributeHTTPConnectionReused.
+//
+// Since the request's context is replaced, the request passed to
+// StartExternalSegment must be used for the call after the segment is
+// started.
+func WithConnectionTimings() ExternalSegmentOption {
+	return func(s *ExternalSegment) {
+		if nil == s.Request {
+			return
+		}
+		s.timings = &externalTimings{}
+		ctx := httptrace.WithClientTrace(s.Request.Context(), s.timings.clientTrace())
+		*s.Request = *s.Request.WithContext(ctx)
+	}
+}
+
+// externalTimings records the timings of an outbound request from the hooks
+// of an httptrace.ClientTrace.  The hooks may be called from transport
+// goroutines, so the fields are protected by the mutex.  Only the first
+// occurrence of each hook is recorded, so that the timings of the first
+// request are kept if the client follows redirects.
+type externalTimings struct {
+	sync.Mutex
+	getConn      time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+	gotConn      bool
+	reused       bool
+}
+
+// setOnce records the current time in t unless it has already been set.
+func (et *externalTimings) setOnce(t *time.Time) {
+	et.Lock()
+	defer et.Unlock()
+	if t.IsZero() {
+		*t = time.Now()
+	}
+}
+
+func (et *externalTimings) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn:           func(string) { et.setOnce(&et.getConn) },
+		DNSStart:          func(httptrace.DNSStartInfo) { et.setOnce(&et.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { et.setOnce(&et.dnsDone) },
+		ConnectStart:      func(string, string) { et.setOnce(&et.connectStart) },
+		TLSHandshakeStart: func() { et.setOnce(&et.tlsStart) },
+		ConnectDone: func(_, _ string, err error) {
+			// Several addresses may be dialed in parallel, in which
+			// case the first successful connection is used.
+			if nil == err {
+				et.setOnce(&et.connectDone)
+			}
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if nil == err {
+				et.setOnce(&et.tlsDone)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			et.Lock()
+			defer et.Unlock()
+			if !et.gotConn {
+				et.gotConn = true
+				et.reused = info.Reused
+			}
+		},
+		GotFirstResponseByte: func() { et.setOnce(&et.firstByte) },
+	}
+}
+
+// phaseDuration adds the duration between start and done, in seconds, unless
+// the phase did not complete.
+func phaseDuration(m *spanAttributeMap, key string, start, done time.Time) {
+	if start.IsZero() || done.IsZero() {
+		return
+	}
+	m.addFloat(key, done.Sub(start).Seconds())
+}
+
+// addAttributes adds the recorded timings to the segment attributes.  Nothing
+// is added if the request never obtained a connection.
+func (et *externalTimings) addAttributes(m *spanAttributeMap) {
+	if nil == et {
+		return
+	}
+	et.Lock()
+	defer et.Unlock()
+
+	if !et.gotConn {
+		return
+	}
+	m.addBool(SpanAttributeHTTPConnectionReused, et.reused)
+	if et.reused {
+		// A reused connection requires no DNS lookup or dial.
+		m.addFloat(SpanAttributeHTTPDNSDuration, 0)
+		m.addFloat(SpanAttributeHTTPConnectDuration, 0)
+	} else {
+		phaseDuration(m, SpanAttributeHTTPDNSDuration, et.dnsStart, et.dnsDone)
+		phaseDuration(m, SpanAttributeHTTPConnectDuration, et.connectStart, et.connectDone)
+		phaseDuration(m, SpanAttributeHTTPTLSDuration, et.tlsStart, et.tlsDone)
+	}
+	phaseDuration(m, SpanAttributeHTTPTimeToFirstByte, et.getConn, et.firstByte)
+}
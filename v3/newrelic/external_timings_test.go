@@ -0,0 +1,170 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestExternalTimingsAttributes(t *testing.T) {
+	var nilTimings *externalTimings
+	var m spanAttributeMap
+	nilTimings.addAttributes(&m)
+	if len(m) != 0 {
+		t.Error(m)
+	}
+
+	// No attributes are added if the request never got a connection.
+	et := &externalTimings{}
+	et.addAttributes(&m)
+	if len(m) != 0 {
+		t.Error(m)
+	}
+
+	start := time.Now()
+	et = &externalTimings{
+		getConn:      start,
+		dnsStart:     start,
+		dnsDone:      start.Add(1 * time.Second),
+		connectStart: start.Add(1 * time.Second),
+		connectDone:  start.Add(3 * time.Second),
+		tlsStart:     start.Add(3 * time.Second),
+		tlsDone:      start.Add(6 * time.Second),
+		firstByte:    start.Add(10 * time.Second),
+		gotConn:      true,
+	}
+	m = nil
+	et.addAttributes(&m)
+	expect := map[string]string{
+		SpanAttributeHTTPConnectionReused: "false",
+		SpanAttributeHTTPDNSDuration:      "1",
+		SpanAttributeHTTPConnectDuration:  "2",
+		SpanAttributeHTTPTLSDuration:      "3",
+		SpanAttributeHTTPTimeToFirstByte:  "10",
+	}
+	expectSpanAttributes(t, m, expect)
+
+	// Phases of a reused connection are zero, even if the hooks of an
+	// earlier attempt were recorded.
+	et.reused = true
+	m = nil
+	et.addAttributes(&m)
+	expect = map[string]string{
+		SpanAttributeHTTPConnectionReused: "true",
+		SpanAttributeHTTPDNSDuration:      "0",
+		SpanAttributeHTTPConnectDuration:  "0",
+		SpanAttributeHTTPTimeToFirstByte:  "10",
+	}
+	expectSpanAttributes(t, m, expect)
+}
+
+func expectSpanAttributes(t *testing.T, m spanAttributeMap, expect map[string]string) {
+	t.Helper()
+	if len(m) != len(expect) {
+		t.Errorf("got %d attributes, want %d", len(m), len(expect))
+	}
+	for key, want := range expect {
+		w, ok := m[key]
+		if !ok {
+			t.Error("missing attribute", key)
+			continue
+		}
+		buf := &bytes.Buffer{}
+		w.WriteJSON(buf)
+		if got := buf.String(); got != want {
+			t.Errorf("attribute %s: got %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestWithConnectionTimingsNilRequest(t *testing.T) {
+	app := testApp(nil, nil, t)
+	txn := app.StartTransaction("hello")
+	s := StartExternalSegment(txn, nil, WithConnectionTimings(), nil)
+	if nil != s.timings {
+		t.Error("timings should not be recorded without a request")
+	}
+	s.End()
+	txn.End()
+}
+
+func TestWithConnectionTimingsRoundTripper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+	txn := app.StartTransaction("hello")
+	client := &http.Client{
+		Transport: NewRoundTripper(srv.Client().Transport, WithConnectionTimings()),
+	}
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", srv.URL, nil)
+		if nil != err {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(RequestWithTransactionContext(req, txn))
+		if nil != err {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	app.expectNoLoggedErrors(t)
+	txn.End()
+
+	externalIntrinsics := map[string]interface{}{
+		"parentId":  internal.MatchAnything,
+		"name":      internal.MatchAnything,
+		"category":  "http",
+		"component": "http",
+		"span.kind": "client",
+	}
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics:     externalIntrinsics,
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"http.url":                        srv.URL,
+				"http.method":                     "GET",
+				"http.statusCode":                 200,
+				SpanAttributeHTTPConnectionReused: false,
+				SpanAttributeHTTPConnectDuration:  internal.MatchAnything,
+				SpanAttributeHTTPTimeToFirstByte:  internal.MatchAnything,
+			},
+		},
+		{
+			Intrinsics:     externalIntrinsics,
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"http.url":                        srv.URL,
+				"http.method":                     "GET",
+				"http.statusCode":                 200,
+				SpanAttributeHTTPConnectionReused: true,
+				SpanAttributeHTTPDNSDuration:      0,
+				SpanAttributeHTTPConnectDuration:  0,
+				SpanAttributeHTTPTimeToFirstByte:  internal.MatchAnything,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/hello",
+				"transaction.name": "OtherTransaction/Go/hello",
+				"sampled":          true,
+				"category":         "generic",
+				"nr.entryPoint":    true,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+		},
+	})
+}
@@ -4,6 +4,8 @@
 package newrelic
 
 import (
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/newrelic/go-agent/v3/internal"
@@ -81,12 +83,23 @@ const (
 // Ready returns a new harvest which contains the data types ready for harvest,
 // or nil if no data is ready for harvest.
 func (h *harvest) Ready(now time.Time) *harvest {
-	ready := &harvest{}
-
 	types := h.timer.ready(now)
 	if 0 == types {
 		return nil
 	}
+	return h.readyTypes(types, now)
+}
+
+// ReadyLogEvents returns a new harvest which contains only the log events,
+// regardless of whether or not they are due to be harvested.
+func (h *harvest) ReadyLogEvents(now time.Time) *harvest {
+	return h.readyTypes(harvestLogEvents, now)
+}
+
+// readyTypes returns a new harvest which contains the data types given,
+// replacing them with empty containers.
+func (h *harvest) readyTypes(types harvestTypes, now time.Time) *harvest {
+	ready := &harvest{}
 
 	if 0 != types&harvestCustomEvents {
 		h.Metrics.addCount(customEventsSeen, h.CustomEvents.NumSeen(), forced)
@@ -263,6 +276,41 @@ type payloadCreator interface {
 	EndpointMethod() string
 }
 
+// responseCodeClass returns the class of an HTTP response code, such as
+// "2xx", or the empty string if the code is not an HTTP response code.
+func responseCodeClass(code int) string {
+	switch {
+	case code >= 100 && code < 200:
+		return "1xx"
+	case code >= 200 && code < 300:
+		return "2xx"
+	case code >= 300 && code < 400:
+		return "3xx"
+	case code >= 400 && code < 500:
+		return "4xx"
+	case code >= 500 && code < 600:
+		return "5xx"
+	}
+	return ""
+}
+
+// requestMethodMetricSuffix returns the request method for use in an
+// "HttpMethod/" metric name, or the empty string if no method was recorded.
+// Nonstandard methods are reported as "Other" to bound the number of metric
+// names.
+func requestMethodMetricSuffix(method string) string {
+	method = strings.ToUpper(method)
+	switch method {
+	case "":
+		return ""
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodConnect,
+		http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "Other"
+}
+
 // createTxnMetrics creates metrics for a transaction.
 func createTxnMetrics(args *txnData, metrics *metricTable) {
 	withoutFirstSegment := removeFirstSegment(args.FinalName)
@@ -274,6 +322,12 @@ func createTxnMetrics(args *txnData, metrics *metricTable) {
 		durationRollup = webRollup
 		totalTimeRollup = totalTimeWeb
 		metrics.addDuration(dispatcherMetric, "", args.Duration, 0, forced)
+		if class := responseCodeClass(args.responseCode); class != "" {
+			metrics.addSingleCount(httpResponseClassPrefix+class, forced)
+		}
+		if method := requestMethodMetricSuffix(args.requestMethod); method != "" {
+			metrics.addDuration(httpMethodPrefix+method, "", args.Duration, 0, forced)
+		}
 	} else {
 		durationRollup = backgroundRollup
 		totalTimeRollup = totalTimeBackground
@@ -309,7 +363,7 @@ func createTxnMetrics(args *txnData, metrics *metricTable) {
 		}
 
 		// CAT Error Metrics
-		if args.HasErrors() {
+		if args.hasMetricErrors() {
 			m = errorsByCallerMetric(caller)
 			metrics.addSingleCount(m.all, unforced)
 			metrics.addSingleCount(m.webOrOther(args.IsWeb), unforced)
@@ -337,9 +391,23 @@ func createTxnMetrics(args *txnData, metrics *metricTable) {
 		metrics.addSingleCount(expectedErrorsRollupMetric.all, forced)
 	}
 
+	if args.userAttrsDropped > 0 {
+		metrics.addCount(supportUserAttrsDropped, float64(args.userAttrsDropped), forced)
+	}
+
+	if args.panicked {
+		metrics.addSingleCount(supportTxnPanic, forced)
+	}
+
+	if args.busyTime > 0 {
+		metrics.addDuration(busyTimePrefix+args.FinalName, "", args.busyTime, args.busyTime, unforced)
+	}
+
 	// Queueing Metrics
 	if args.Queuing > 0 {
 		metrics.addDuration(queueMetric, "", args.Queuing, args.Queuing, forced)
+		total := args.Queuing + args.Duration
+		metrics.addDuration(totalResponseTimeMetric, "", total, total, forced)
 	}
 }
 
@@ -358,6 +426,7 @@ var (
 			true,
 			false,
 			internal.MaxLogEvents,
+			false,
 		},
 	}
 )
@@ -321,6 +321,15 @@ func TestHarvestLogEventsReady(t *testing.T) {
 		"User 'xyz' logged in",
 		"123456789ADF",
 		"ADF09876565",
+		"",
+		"",
+		0,
+		"",
+		0,
+		"",
+		"",
+		"",
+		"",
 	}
 
 	h.LogEvents.Add(&logEvent)
@@ -359,6 +368,57 @@ func TestHarvestLogEventsReady(t *testing.T) {
 	})
 }
 
+func TestHarvestReadyLogEvents(t *testing.T) {
+	now := time.Now()
+	h := newHarvest(now, harvestConfig{
+		ReportPeriods: map[harvestTypes]time.Duration{
+			harvestTypesAll: fixedHarvestPeriod,
+		},
+		MaxTxnEvents:  3,
+		LoggingConfig: loggingConfigEnabled(3),
+	})
+	h.LogEvents.Add(&logEvent{
+		0.5,
+		123456,
+		"INFO",
+		"User 'xyz' logged in",
+		"123456789ADF",
+		"ADF09876565",
+		"",
+		"",
+		0,
+		"",
+		0,
+		"",
+		"",
+		"",
+		"",
+	})
+	h.TxnEvents.AddTxnEvent(&txnEvent{
+		FinalName: "finalName",
+		Start:     time.Now(),
+		Duration:  1 * time.Second,
+		TotalTime: 2 * time.Second,
+	}, 0)
+
+	ready := h.ReadyLogEvents(now)
+	if ready.LogEvents == nil || ready.LogEvents.NumSaved() != 1 {
+		t.Fatal("log events not harvested")
+	}
+	if ready.TxnEvents != nil || ready.Metrics != nil {
+		t.Error("unexpected data types harvested", ready)
+	}
+	if h.LogEvents.NumSaved() != 0 {
+		t.Error("log events not correctly reset")
+	}
+	if h.TxnEvents.NumSaved() != 1 {
+		t.Error("txn events should remain in the harvest")
+	}
+	if payloads := ready.Payloads(true); len(payloads) != 1 || payloads[0].EndpointMethod() != "log_event_data" {
+		t.Error(payloads)
+	}
+}
+
 func TestHarvestTxnEventsReady(t *testing.T) {
 	now := time.Now()
 	fixedHarvestTypes := harvestMetricsTraces & harvestCustomEvents & harvestSpanEvents & harvestErrorEvents
@@ -582,6 +642,15 @@ func TestMergeFailedHarvest(t *testing.T) {
 		"User 'xyz' logged in",
 		"123456789ADF",
 		"ADF09876565",
+		"",
+		"",
+		0,
+		"",
+		0,
+		"",
+		"",
+		"",
+		"",
 	}
 
 	h.LogEvents.Add(&logEvent)
@@ -882,6 +951,48 @@ func TestCreateTxnMetrics(t *testing.T) {
 
 }
 
+func TestCreateTxnMetricsQueuing(t *testing.T) {
+	webName := "WebTransaction/zip/zap"
+	args := &txnData{}
+	args.FinalName = webName
+	args.IsWeb = true
+	args.Duration = 3 * time.Second
+	args.TotalTime = 3 * time.Second
+	args.Queuing = 2 * time.Second
+	args.Zone = apdexNone
+	metrics := newMetricTable(100, time.Now())
+	createTxnMetrics(args, metrics)
+	expectMetrics(t, metrics, []internal.WantMetric{
+		{Name: webName, Scope: "", Forced: true, Data: []float64{1, 3, 0, 3, 3, 9}},
+		{Name: webRollup, Scope: "", Forced: true, Data: []float64{1, 3, 0, 3, 3, 9}},
+		{Name: dispatcherMetric, Scope: "", Forced: true, Data: []float64{1, 3, 0, 3, 3, 9}},
+		{Name: "WebTransactionTotalTime", Scope: "", Forced: true, Data: []float64{1, 3, 3, 3, 3, 9}},
+		{Name: "WebTransactionTotalTime/zip/zap", Scope: "", Forced: false, Data: []float64{1, 3, 3, 3, 3, 9}},
+		{Name: "WebFrontend/QueueTime", Scope: "", Forced: true, Data: []float64{1, 2, 2, 2, 2, 4}},
+		{Name: "WebFrontend/TotalResponseTime", Scope: "", Forced: true, Data: []float64{1, 5, 5, 5, 5, 25}},
+	})
+}
+
+func TestCreateTxnMetricsBusyTime(t *testing.T) {
+	name := "OtherTransaction/Go/job"
+	args := &txnData{}
+	args.FinalName = name
+	args.IsWeb = false
+	args.Duration = 3 * time.Second
+	args.TotalTime = 3 * time.Second
+	args.busyTime = 2 * time.Second
+	args.Zone = apdexNone
+	metrics := newMetricTable(100, time.Now())
+	createTxnMetrics(args, metrics)
+	expectMetrics(t, metrics, []internal.WantMetric{
+		{Name: name, Scope: "", Forced: true, Data: []float64{1, 3, 0, 3, 3, 9}},
+		{Name: backgroundRollup, Scope: "", Forced: true, Data: []float64{1, 3, 0, 3, 3, 9}},
+		{Name: "OtherTransactionTotalTime", Scope: "", Forced: true, Data: []float64{1, 3, 3, 3, 3, 9}},
+		{Name: "OtherTransactionTotalTime/Go/job", Scope: "", Forced: false, Data: []float64{1, 3, 3, 3, 3, 9}},
+		{Name: "BusyTime/OtherTransaction/Go/job", Scope: "", Forced: false, Data: []float64{1, 2, 2, 2, 2, 4}},
+	})
+}
+
 func TestHarvestSplitTxnEvents(t *testing.T) {
 	now := time.Now()
 	h := newHarvest(now, testHarvestCfgr)
@@ -29,6 +29,10 @@ import (
 //
 // The WrapHandle function is safe to call if app is nil.
 //
+// The Transaction is ended when the handler returns.  If the handler panics
+// and Config.ErrorCollector.RecordPanics is enabled, the panic is recorded as
+// an error before it is re-panicked, just as with Transaction.End.
+//
 // WrapHandle accepts zero or more TraceOption functions to allow additional options to be
 // manually added to the transaction trace generated, in the same fashion as StartTransaction
 // does. For example, this can be used to control code level metrics generated for this transaction.
@@ -165,15 +169,18 @@ func WrapListen(endpoint string) string {
 // an external segment before delegating to the original http.RoundTripper
 // provided (or http.DefaultTransport if none is provided).  The
 // http.RoundTripper will look for a Transaction in the request's context
-// (using FromContext).
-func NewRoundTripper(original http.RoundTripper) http.RoundTripper {
+// (using FromContext).  The options are applied to each external segment,
+// for example:
+//
+//	client.Transport = newrelic.NewRoundTripper(nil, newrelic.WithConnectionTimings())
+func NewRoundTripper(original http.RoundTripper, options ...ExternalSegmentOption) http.RoundTripper {
 	if nil == original {
 		original = http.DefaultTransport
 	}
 	return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
 		// The specification of http.RoundTripper requires that the request is never modified.
 		request = cloneRequest(request)
-		segment := StartExternalSegment(nil, request)
+		segment := StartExternalSegment(nil, request, options...)
 
 		response, err := original.RoundTrip(request)
 
@@ -46,7 +46,8 @@ func TestWrapHandleFunc(t *testing.T) {
 			"http.statusCode":  "200",
 		}),
 	}})
-	app.ExpectMetrics(t, []internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", []internal.WantMetric{
+		{Name: "HttpResponseClass/2xx", Scope: "", Forced: true, Data: singleCount},
 		{Name: "WebTransaction/Go/GET /hello", Scope: "", Forced: true, Data: nil},
 		{Name: "WebTransaction", Scope: "", Forced: true, Data: nil},
 		{Name: "WebTransactionTotalTime/Go/GET /hello", Scope: "", Forced: false, Data: nil},
@@ -57,7 +58,7 @@ func TestWrapHandleFunc(t *testing.T) {
 		{Name: "Errors/all", Scope: "", Forced: true, Data: singleCount},
 		{Name: "Errors/allWeb", Scope: "", Forced: true, Data: singleCount},
 		{Name: "Errors/WebTransaction/Go/GET /hello", Scope: "", Forced: true, Data: singleCount},
-	})
+	}))
 }
 
 func TestWrapHandle(t *testing.T) {
@@ -88,7 +89,8 @@ func TestWrapHandle(t *testing.T) {
 			"http.statusCode":  "200",
 		}),
 	}})
-	app.ExpectMetrics(t, []internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", []internal.WantMetric{
+		{Name: "HttpResponseClass/2xx", Scope: "", Forced: true, Data: singleCount},
 		{Name: "WebTransaction/Go/GET /hello", Scope: "", Forced: true, Data: nil},
 		{Name: "WebTransaction", Scope: "", Forced: true, Data: nil},
 		{Name: "WebTransactionTotalTime/Go/GET /hello", Scope: "", Forced: false, Data: nil},
@@ -99,7 +101,39 @@ func TestWrapHandle(t *testing.T) {
 		{Name: "Errors/all", Scope: "", Forced: true, Data: singleCount},
 		{Name: "Errors/allWeb", Scope: "", Forced: true, Data: singleCount},
 		{Name: "Errors/WebTransaction/Go/GET /hello", Scope: "", Forced: true, Data: singleCount},
-	})
+	}))
+}
+
+func TestWrapHandlePanic(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		enableRecordPanics(cfg)
+		cfg.DistributedTracer.Enabled = false
+	}, t)
+	mux := http.NewServeMux()
+	mux.Handle(WrapHandle(app.Application, helloPath, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic(myError{})
+	})))
+	r := func() (r interface{}) {
+		defer func() { r = recover() }()
+		mux.ServeHTTP(newCompatibleResponseRecorder(), helloRequest)
+		return nil
+	}()
+	if r != (myError{}) {
+		t.Error("panic not propagated", r)
+	}
+
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/GET /hello",
+		Msg:     "my msg",
+		Klass:   panicErrorKlass,
+	}})
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/GET /hello",
+			"error":            true,
+			"nr.apdexPerfZone": "F",
+		},
+	}})
 }
 
 func TestWrapHandleNilApp(t *testing.T) {
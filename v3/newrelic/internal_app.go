@@ -5,6 +5,7 @@ package newrelic
 
 import (
 	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -13,6 +14,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/newrelic/go-agent/v3/internal"
@@ -52,6 +54,13 @@ type app struct {
 	dataChan           chan appData
 	collectorErrorChan chan rpmResponse
 	connectChan        chan *appRun
+	// flushLogsChan is used by FlushLogs to request an immediate harvest
+	// of log events.  The channel sent is closed once the harvest is
+	// complete.
+	flushLogsChan chan chan struct{}
+	// pendingMetricsChan is used by PendingMetricNames to request a
+	// snapshot of the metric names in the current harvest.
+	pendingMetricsChan chan chan []string
 
 	// This mutex protects both `run` and `err`, both of which should only
 	// be accessed using getState and setState.
@@ -64,9 +73,32 @@ type app struct {
 	err error
 
 	serverless *serverlessHarvest
+
+	// recentTxns is nil unless Config.RecentTransactionBufferSize is
+	// positive.
+	recentTxns *recentTransactions
+
+	// errRate is nil unless Config.ErrorRateWindow is at least one second.
+	errRate *errorRate
+
+	// harvestOverflows counts the data dropped by tryConsume since the
+	// last harvest.  It must be accessed atomically.
+	harvestOverflows int64
+}
+
+// mergeHarvestOverflows records the data dropped by tryConsume since the last
+// harvest of metrics.
+func (app *app) mergeHarvestOverflows(h *harvest) {
+	if nil == h.Metrics {
+		return
+	}
+	if n := atomic.SwapInt64(&app.harvestOverflows, 0); n > 0 {
+		h.Metrics.addCount(supportHarvestOverflow, float64(n), forced)
+	}
 }
 
 func (app *app) doHarvest(h *harvest, harvestStart time.Time, run *appRun) {
+	app.mergeHarvestOverflows(h)
 	h.CreateFinalMetrics(run, app.getObserver())
 
 	payloads := h.Payloads(app.config.DistributedTracer.Enabled)
@@ -247,6 +279,29 @@ func (app *app) process() {
 			if nil != run && run.Reply.RunID == d.id {
 				d.data.MergeIntoHarvest(h)
 			}
+		case done := <-app.flushLogsChan:
+			if nil == run {
+				close(done)
+				break
+			}
+			// Merge the data already queued so that the logs of
+			// recently ended transactions are included.
+			for i := len(app.dataChan); i > 0; i-- {
+				if d := <-app.dataChan; run.Reply.RunID == d.id {
+					d.data.MergeIntoHarvest(h)
+				}
+			}
+			now := time.Now()
+			go func(ready *harvest, run *appRun) {
+				app.doHarvest(ready, now, run)
+				close(done)
+			}(h.ReadyLogEvents(now), run)
+		case reply := <-app.pendingMetricsChan:
+			if nil == run {
+				reply <- nil
+				break
+			}
+			reply <- h.Metrics.names()
 		case timeout := <-app.initiateShutdown:
 			close(app.shutdownStarted)
 
@@ -357,6 +412,99 @@ func (app *app) Shutdown(timeout time.Duration) {
 	})
 }
 
+// GracefulShutdown shuts down the application, performing a final harvest of
+// all data types, and blocks until it is complete or the context is done.
+// The context's deadline, if any, bounds the time given to the trace
+// observer.
+func (app *app) GracefulShutdown(ctx context.Context) error {
+	if nil == app {
+		return nil
+	}
+	if !app.config.Enabled {
+		return nil
+	}
+	if app.config.ServerlessMode.Enabled {
+		return nil
+	}
+
+	timeout := defaultGracefulShutdownTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	select {
+	case app.initiateShutdown <- timeout:
+	default:
+	}
+
+	select {
+	case <-app.shutdownComplete:
+	case <-ctx.Done():
+		app.Warn("application graceful shutdown incomplete", map[string]interface{}{
+			"app":   app.config.AppName,
+			"error": ctx.Err().Error(),
+		})
+		return ctx.Err()
+	}
+
+	app.Info("application shutdown", map[string]interface{}{
+		"app": app.config.AppName,
+	})
+	return nil
+}
+
+// FlushLogs harvests the log events collected so far, blocking until they
+// have been sent.
+func (app *app) FlushLogs() {
+	if nil == app {
+		return
+	}
+	if !app.config.Enabled {
+		return
+	}
+	if app.config.ServerlessMode.Enabled {
+		return
+	}
+
+	done := make(chan struct{})
+	select {
+	case app.flushLogsChan <- done:
+	case <-app.shutdownStarted:
+		return
+	}
+	select {
+	case <-done:
+	case <-app.shutdownStarted:
+	}
+}
+
+// PendingMetricNames returns the names of the metrics waiting to be sent in
+// the next harvest.  The snapshot is taken by the processor goroutine, which
+// owns the harvest, so this call blocks until that goroutine is available.
+func (app *app) PendingMetricNames() []string {
+	if nil == app {
+		return nil
+	}
+	if nil != app.testHarvest {
+		return app.testHarvest.Metrics.names()
+	}
+	if !app.config.Enabled || app.config.ServerlessMode.Enabled {
+		return nil
+	}
+
+	reply := make(chan []string, 1)
+	select {
+	case app.pendingMetricsChan <- reply:
+	case <-app.shutdownStarted:
+		return nil
+	}
+	select {
+	case names := <-reply:
+		return names
+	case <-app.shutdownStarted:
+		return nil
+	}
+}
+
 func runSampler(app *app, period time.Duration) {
 	previous := getSystemSample(time.Now(), app)
 	t := time.NewTicker(period)
@@ -420,6 +568,8 @@ func newApp(c config) *app {
 		Logger:         c.Logger,
 		config:         c,
 		placeholderRun: newPlaceholderAppRun(c),
+		recentTxns:     newRecentTransactions(c.RecentTransactionBufferSize),
+		errRate:        newErrorRate(c.ErrorRateWindow),
 
 		// This channel must be buffered since Shutdown makes a
 		// non-blocking send attempt.
@@ -430,6 +580,8 @@ func newApp(c config) *app {
 		connectChan:        make(chan *appRun, 1),
 		collectorErrorChan: make(chan rpmResponse, 1),
 		dataChan:           make(chan appData, appDataChanSize),
+		flushLogsChan:      make(chan chan struct{}),
+		pendingMetricsChan: make(chan chan []string),
 		rpmControls: rpmControls{
 			License: c.License,
 			Client: &http.Client{
@@ -534,6 +686,22 @@ func (app *app) StartTransaction(name string, opts ...TraceOption) *Transaction
 	return newTransaction(newTxn(app, run, name, opts...))
 }
 
+// StartTransactionWithOptions implements newrelic.Application's
+// StartTransactionWithOptions.
+func (app *app) StartTransactionWithOptions(name string, opts TransactionOptions) *Transaction {
+	if nil == app {
+		return nil
+	}
+	run, _ := app.getState()
+	thd := newTxn(app, run, name)
+	thd.txn.applyOptions(opts)
+	txn := newTransaction(thd)
+	for key, val := range opts.DefaultAttributes {
+		txn.AddAttribute(key, val)
+	}
+	return txn
+}
+
 var (
 	errHighSecurityEnabled        = errors.New("high security enabled")
 	errCustomEventsDisabled       = errors.New("custom events disabled")
@@ -599,6 +767,7 @@ func (app *app) RecordCustomMetric(name string, value float64) error {
 	run, _ := app.getState()
 	app.Consume(run.Reply.RunID, customMetric{
 		RawInputName: name,
+		Prefix:       app.config.CustomMetricPrefix,
 		Value:        value,
 	})
 	return nil
@@ -614,16 +783,61 @@ func (app *app) RecordLog(log *LogData) error {
 		return errAppLoggingDisabled
 	}
 
-	event, err := log.toLogEvent()
+	event, err := log.toLogEvent(app.config.ApplicationLogging.Forwarding.MessageScrubber)
 	if err != nil {
 		return err
 	}
 
 	run, _ := app.getState()
+	if event.serviceName == "" {
+		event.serviceName = run.firstAppName
+	}
 	app.Consume(run.Reply.RunID, &event)
 	return nil
 }
 
+// logEventBatch is a set of log events merged into the harvest together.
+type logEventBatch []logEvent
+
+func (batch logEventBatch) MergeIntoHarvest(h *harvest) {
+	for i := range batch {
+		h.LogEvents.Add(&batch[i])
+	}
+}
+
+// MergeLogEvents validates the logs and consumes those accepted as a single
+// batch.  The first validation error is returned alongside the counts.
+func (app *app) MergeLogEvents(logs []LogData, opts MergeLogEventsOptions) (accepted, dropped int, err error) {
+	if !app.config.ApplicationLogging.Enabled {
+		return 0, len(logs), errAppLoggingDisabled
+	}
+
+	run, _ := app.getState()
+	serviceName := opts.ServiceName
+	if serviceName == "" {
+		serviceName = run.firstAppName
+	}
+	scrubber := app.config.ApplicationLogging.Forwarding.MessageScrubber
+	batch := make(logEventBatch, 0, len(logs))
+	for i := range logs {
+		event, e := logs[i].toLogEvent(scrubber)
+		if e != nil {
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+		if event.serviceName == "" {
+			event.serviceName = serviceName
+		}
+		batch = append(batch, event)
+	}
+	if len(batch) > 0 {
+		app.Consume(run.Reply.RunID, batch)
+	}
+	return len(batch), len(logs) - len(batch), err
+}
+
 var (
 	_ internal.ServerlessWriter = &app{}
 )
@@ -651,6 +865,41 @@ func (app *app) Consume(id internal.AgentRunID, data harvestable) {
 	}
 }
 
+// tryConsume is like Consume, but drops the data rather than blocking if the
+// data channel is full.  It returns false if the data was dropped.
+func (app *app) tryConsume(id internal.AgentRunID, data harvestable) bool {
+
+	app.serverless.Consume(data)
+
+	if nil != app.testHarvest {
+		data.MergeIntoHarvest(app.testHarvest)
+		return true
+	}
+
+	if id == "" {
+		return true
+	}
+
+	select {
+	case app.dataChan <- appData{id, data}:
+	case <-app.shutdownStarted:
+	default:
+		atomic.AddInt64(&app.harvestOverflows, 1)
+		return false
+	}
+	return true
+}
+
+// consumeTxn consumes the data of an ended transaction, without blocking if
+// Config.NonBlockingTransactionEnd is enabled.
+func (app *app) consumeTxn(id internal.AgentRunID, data harvestable) {
+	if app.config.NonBlockingTransactionEnd {
+		app.tryConsume(id, data)
+		return
+	}
+	app.Consume(id, data)
+}
+
 func (app *app) ExpectCustomEvents(t internal.Validator, want []internal.WantEvent) {
 	expectCustomEvents(extendValidator(t, "custom events"), app.testHarvest.CustomEvents, want)
 }
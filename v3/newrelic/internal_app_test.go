@@ -4,8 +4,16 @@
 package newrelic
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -39,6 +47,13 @@ func TestNilApplication(t *testing.T) {
 	if err := app.WaitForConnection(2 * time.Second); nil != err {
 		t.Error(err)
 	}
+	if id := app.RunID(); id != "" {
+		t.Error(id)
+	}
+	app.FlushLogs()
+	if err := app.GracefulShutdown(context.Background()); nil != err {
+		t.Error(err)
+	}
 	app.Shutdown(2 * time.Second)
 }
 
@@ -52,9 +67,35 @@ func TestEmptyApplication(t *testing.T) {
 	if err := app.WaitForConnection(2 * time.Second); nil != err {
 		t.Error(err)
 	}
+	if id := app.RunID(); id != "" {
+		t.Error(id)
+	}
+	app.FlushLogs()
+	if err := app.GracefulShutdown(context.Background()); nil != err {
+		t.Error(err)
+	}
 	app.Shutdown(2 * time.Second)
 }
 
+func TestApplicationRunID(t *testing.T) {
+	app := testApp(nil, nil, t)
+	if id := app.RunID(); id != "" {
+		t.Error(id)
+	}
+
+	reply := internal.ConnectReplyDefaults()
+	reply.RunID = "my-run-id"
+	app.app.setState(newAppRun(app.app.config, reply), nil)
+	if id := app.RunID(); id != "my-run-id" {
+		t.Error(id)
+	}
+
+	app.app.setState(nil, nil)
+	if id := app.RunID(); id != "" {
+		t.Error(id)
+	}
+}
+
 func TestConfigOptionError(t *testing.T) {
 	err := errors.New("myError")
 	app, got := NewApplication(
@@ -149,3 +190,597 @@ func TestRecordLog(t *testing.T) {
 		},
 	})
 }
+
+func TestRecordLogServiceName(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		configTestAppLogFn,
+	)
+
+	testApp.Application.RecordLog(LogData{
+		Message: "Default Service",
+	})
+	testApp.Application.RecordLog(LogData{
+		Message:     "Named Service",
+		ServiceName: "my-service",
+	})
+	txn := testApp.Application.StartTransaction("hello")
+	txn.RecordLog(LogData{
+		Message: "Transaction Log",
+	})
+	txn.End()
+
+	expect := map[string]string{
+		"Default Service": sampleAppName,
+		"Named Service":   "my-service",
+		"Transaction Log": sampleAppName,
+	}
+	logs := testApp.Application.app.testHarvest.LogEvents.logs
+	if len(logs) != len(expect) {
+		t.Fatal(len(logs))
+	}
+	for _, log := range logs {
+		if want := expect[log.message]; log.serviceName != want {
+			t.Errorf("unexpected service name for %q: got %q, want %q", log.message, log.serviceName, want)
+		}
+	}
+}
+
+func TestRecordLogCaptureSource(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		func(cfg *Config) {
+			configTestAppLogFn(cfg)
+			cfg.ApplicationLogging.Forwarding.CaptureSource = true
+		},
+	)
+
+	_, file, line, _ := runtime.Caller(0)
+	testApp.Application.RecordLog(LogData{
+		Message: "Captured",
+	})
+	testApp.Application.RecordLog(LogData{
+		Message:    "Given",
+		SourceFile: "main.go",
+		SourceLine: 7,
+	})
+	txn := testApp.Application.StartTransaction("hello")
+	txn.RecordLog(LogData{
+		Message: "Transaction Log",
+	})
+	txn.End()
+
+	expect := map[string]struct {
+		file string
+		line int
+	}{
+		"Captured":        {file, line + 1},
+		"Given":           {"main.go", 7},
+		"Transaction Log": {file, line + 10},
+	}
+	logs := testApp.Application.app.testHarvest.LogEvents.logs
+	if len(logs) != len(expect) {
+		t.Fatal(len(logs))
+	}
+	for _, log := range logs {
+		want := expect[log.message]
+		if log.sourceFile != want.file || log.sourceLine != want.line {
+			t.Errorf("unexpected source for %q: got %s:%d, want %s:%d", log.message, log.sourceFile, log.sourceLine, want.file, want.line)
+		}
+	}
+}
+
+func TestRecordLogSourceNotCaptured(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		configTestAppLogFn,
+	)
+	testApp.Application.RecordLog(LogData{
+		Message: "Not Captured",
+	})
+	for _, log := range testApp.Application.app.testHarvest.LogEvents.logs {
+		if log.sourceFile != "" || log.sourceLine != 0 {
+			t.Errorf("unexpected source: %s:%d", log.sourceFile, log.sourceLine)
+		}
+	}
+}
+
+func TestRecordLogTraceFlags(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		configTestAppLogFn,
+	)
+
+	testApp.Application.RecordLog(LogData{
+		Message: "Application Log",
+	})
+	txn := testApp.Application.StartTransaction("hello")
+	txn.RecordLog(LogData{
+		Message: "Transaction Log",
+	})
+	txn.End()
+
+	expect := map[string]string{
+		"Application Log": "",
+		"Transaction Log": "01",
+	}
+	logs := testApp.Application.app.testHarvest.LogEvents.logs
+	if len(logs) != len(expect) {
+		t.Fatal(len(logs))
+	}
+	for _, log := range logs {
+		if want := expect[log.message]; log.traceFlags != want {
+			t.Errorf("unexpected trace flags for %q: got %q, want %q", log.message, log.traceFlags, want)
+		}
+	}
+}
+
+func TestRecordLogMessageScrubber(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		func(cfg *Config) {
+			configTestAppLogFn(cfg)
+			cfg.ApplicationLogging.Forwarding.MessageScrubber = func(msg string) string {
+				return strings.ReplaceAll(msg, "secret", "******")
+			}
+		},
+	)
+
+	testApp.Application.RecordLog(LogData{
+		Severity:  "Debug",
+		Message:   "Application secret",
+		Timestamp: 123,
+	})
+	txn := testApp.Application.StartTransaction("hello")
+	txn.RecordLog(LogData{
+		Severity:  "Debug",
+		Message:   "Transaction secret",
+		Timestamp: 456,
+	})
+	txn.End()
+	testApp.Application.MergeLogEvents([]LogData{{
+		Severity:  "Debug",
+		Message:   "Batch secret",
+		Timestamp: 789,
+	}}, MergeLogEventsOptions{})
+
+	testApp.ExpectLogEvents(t, []internal.WantLog{
+		{Severity: "Debug", Message: "Application ******", Timestamp: 123},
+		{Severity: "Debug", Message: "Transaction ******", Timestamp: 456, SpanID: internal.MatchAnyString, TraceID: internal.MatchAnyString},
+		{Severity: "Debug", Message: "Batch ******", Timestamp: 789},
+	})
+}
+
+func TestRecordLogMessageScrubberPanic(t *testing.T) {
+	scrubber := func(cfg *Config) {
+		cfg.ApplicationLogging.Forwarding.MessageScrubber = func(msg string) string {
+			if strings.Contains(msg, "secret") {
+				panic("oops")
+			}
+			return msg
+		}
+	}
+
+	app := testApp(nil, func(cfg *Config) {
+		configTestAppLogFn(cfg)
+		scrubber(cfg)
+	}, t)
+	app.Application.RecordLog(LogData{
+		Severity: "Debug",
+		Message:  "Application secret",
+	})
+	app.expectSingleLoggedError(t, "unable to record log", map[string]interface{}{
+		"reason": "panic in log message scrubber: oops",
+	})
+
+	testApp := newTestApp(sampleEverythingReplyFn, configTestAppLogFn, scrubber)
+	testApp.Application.RecordLog(LogData{
+		Severity:  "Debug",
+		Message:   "Application secret",
+		Timestamp: 123,
+	})
+	txn := testApp.Application.StartTransaction("hello")
+	txn.RecordLog(LogData{
+		Severity:  "Debug",
+		Message:   "Transaction secret",
+		Timestamp: 456,
+	})
+	txn.End()
+	accepted, dropped := testApp.Application.MergeLogEvents([]LogData{{
+		Severity:  "Debug",
+		Message:   "Batch secret",
+		Timestamp: 789,
+	}, {
+		Severity:  "Debug",
+		Message:   "Batch message",
+		Timestamp: 789,
+	}}, MergeLogEventsOptions{})
+	if accepted != 1 || dropped != 1 {
+		t.Error(accepted, dropped)
+	}
+
+	testApp.ExpectLogEvents(t, []internal.WantLog{
+		{Severity: "Debug", Message: "Batch message", Timestamp: 789},
+	})
+}
+
+func TestRecordLogSeverityCounts(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		func(cfg *Config) {
+			configTestAppLogFn(cfg)
+			cfg.DistributedTracer.Enabled = false
+		},
+	)
+
+	txn := testApp.Application.StartTransaction("hello")
+	for _, severity := range []string{"error", "ERROR", "warn", "info", "notice", ""} {
+		txn.RecordLog(LogData{
+			Severity: severity,
+			Message:  "Transaction Log",
+		})
+	}
+	txn.RecordLog(LogData{
+		Severity:      "critical",
+		SeverityLevel: 18,
+		Message:       "Transaction Log",
+	})
+	txn.End()
+
+	testApp.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeLogCountError:   3,
+			AttributeLogCountWarn:    1,
+			AttributeLogCountInfo:    1,
+			AttributeLogCountUnknown: 2,
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestRecordLogStackTraceErrorCorrelation(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		func(cfg *Config) {
+			configTestAppLogFn(cfg)
+			cfg.DistributedTracer.Enabled = false
+		},
+	)
+
+	txn := testApp.Application.StartTransaction("hello")
+	txn.RecordLog(LogData{
+		Severity:   "error",
+		Message:    "before error",
+		StackTrace: "main.main()",
+	})
+	id := txn.NoticeErrorWithID(errors.New("oops"))
+	txn.RecordLog(LogData{
+		Severity:   "error",
+		Message:    "correlated",
+		StackTrace: "main.main()",
+	})
+	txn.RecordLog(LogData{
+		Severity: "error",
+		Message:  "no stack",
+	})
+	txn.RecordLog(LogData{
+		Severity:   "info",
+		Message:    "not an error",
+		StackTrace: "main.main()",
+	})
+
+	logs := make(map[string]logEvent)
+	for _, log := range txn.thread.txn.logs {
+		logs[log.message] = log
+	}
+	txn.End()
+
+	if log := logs["correlated"]; log.errorClass != "*errors.errorString" || log.errorID != id || log.stackTrace != "main.main()" {
+		t.Error(log.errorClass, log.errorID, log.stackTrace)
+	}
+	for _, msg := range []string{"before error", "no stack", "not an error"} {
+		if log := logs[msg]; log.errorClass != "" || log.errorID != "" {
+			t.Error(msg, log.errorClass, log.errorID)
+		}
+	}
+}
+
+func TestRecordLogForwardingDisabled(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		func(cfg *Config) {
+			configTestAppLogFn(cfg)
+			cfg.ApplicationLogging.Forwarding.Enabled = false
+		},
+	)
+
+	testApp.Application.RecordLog(LogData{
+		Severity: "Debug",
+		Message:  "Test Message",
+	})
+
+	testApp.ExpectLogEvents(t, []internal.WantLog{})
+}
+
+func TestMergeLogEvents(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		configTestAppLogFn,
+	)
+
+	accepted, dropped := testApp.Application.MergeLogEvents([]LogData{
+		{Severity: "Debug", Message: "First", Timestamp: 123},
+		{Message: strings.Repeat("a", MaxLogLength+1)},
+		{Severity: "Info", Message: "Second", Timestamp: 456, ServiceName: "my-service"},
+	}, MergeLogEventsOptions{ServiceName: "batch-service"})
+	if accepted != 2 || dropped != 1 {
+		t.Error(accepted, dropped)
+	}
+
+	testApp.ExpectLogEvents(t, []internal.WantLog{
+		{Severity: "Debug", Message: "First", Timestamp: 123},
+		{Severity: "Info", Message: "Second", Timestamp: 456},
+	})
+	expect := map[string]string{
+		"First":  "batch-service",
+		"Second": "my-service",
+	}
+	for _, log := range testApp.Application.app.testHarvest.LogEvents.logs {
+		if want := expect[log.message]; log.serviceName != want {
+			t.Errorf("unexpected service name for %q: got %q, want %q", log.message, log.serviceName, want)
+		}
+	}
+}
+
+func TestMergeLogEventsLoggingDisabled(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		func(cfg *Config) {
+			configTestAppLogFn(cfg)
+			cfg.ApplicationLogging.Enabled = false
+		},
+	)
+
+	accepted, dropped := testApp.Application.MergeLogEvents([]LogData{
+		{Message: "First"},
+		{Message: "Second"},
+	}, MergeLogEventsOptions{})
+	if accepted != 0 || dropped != 2 {
+		t.Error(accepted, dropped)
+	}
+	testApp.ExpectLogEvents(t, []internal.WantLog{})
+}
+
+func TestMergeLogEventsNilApplication(t *testing.T) {
+	var app *Application
+	accepted, dropped := app.MergeLogEvents([]LogData{{Message: "First"}}, MergeLogEventsOptions{})
+	if accepted != 0 || dropped != 1 {
+		t.Error(accepted, dropped)
+	}
+}
+
+func TestTryConsumeOverflow(t *testing.T) {
+	app := &app{
+		dataChan:        make(chan appData, 1),
+		shutdownStarted: make(chan struct{}),
+	}
+	if !app.tryConsume("run", writeAfterEnd{}) {
+		t.Error("data dropped with space in the channel")
+	}
+	if app.tryConsume("run", writeAfterEnd{}) {
+		t.Error("data not dropped with a full channel")
+	}
+	if app.tryConsume("run", writeAfterEnd{}) {
+		t.Error("data not dropped with a full channel")
+	}
+
+	h := newHarvest(time.Now(), testHarvestCfgr)
+	app.mergeHarvestOverflows(h)
+	expectMetrics(t, h.Metrics, []internal.WantMetric{
+		{Name: supportHarvestOverflow, Scope: "", Forced: true, Data: []float64{2, 0, 0, 0, 0, 0}},
+	})
+
+	h = newHarvest(time.Now(), testHarvestCfgr)
+	app.mergeHarvestOverflows(h)
+	expectMetrics(t, h.Metrics, []internal.WantMetric{})
+}
+
+func TestNonBlockingTransactionEnd(t *testing.T) {
+	app := &app{
+		config: config{
+			Config: Config{NonBlockingTransactionEnd: true},
+		},
+		dataChan:        make(chan appData),
+		shutdownStarted: make(chan struct{}),
+	}
+	done := make(chan struct{})
+	go func() {
+		app.consumeTxn("run", writeAfterEnd{})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("consumeTxn blocked")
+	}
+	if n := atomic.LoadInt64(&app.harvestOverflows); n != 1 {
+		t.Error(n)
+	}
+}
+
+func TestPendingMetricNames(t *testing.T) {
+	app := testApp(nil, nil, t)
+	app.RecordCustomMetric("myMetric", 123.45)
+	txn := app.StartTransaction("hello")
+	txn.End()
+
+	names := app.PendingMetricNames()
+	if !sort.StringsAreSorted(names) {
+		t.Error("names not sorted", names)
+	}
+	found := make(map[string]bool)
+	for _, name := range names {
+		found[name] = true
+	}
+	for _, name := range []string{"Custom/myMetric", "OtherTransaction/Go/hello", "OtherTransaction/all"} {
+		if !found[name] {
+			t.Error("missing metric name", name, names)
+		}
+	}
+}
+
+func TestPendingMetricNamesNilApplication(t *testing.T) {
+	var app *Application
+	if names := app.PendingMetricNames(); names != nil {
+		t.Error(names)
+	}
+	if names := (&Application{}).PendingMetricNames(); names != nil {
+		t.Error(names)
+	}
+}
+
+func TestPendingMetricNamesFromProcessor(t *testing.T) {
+	app := &app{
+		config: config{
+			Config: Config{Enabled: true},
+		},
+		pendingMetricsChan: make(chan chan []string),
+		shutdownStarted:    make(chan struct{}),
+	}
+	h := newHarvest(time.Now(), testHarvestCfgr)
+	h.Metrics.addSingleCount("zip", forced)
+	h.Metrics.addSingleCount("zap", forced)
+	go func() {
+		reply := <-app.pendingMetricsChan
+		reply <- h.Metrics.names()
+	}()
+	if names := app.PendingMetricNames(); !reflect.DeepEqual(names, []string{"zap", "zip"}) {
+		t.Error(names)
+	}
+
+	close(app.shutdownStarted)
+	if names := app.PendingMetricNames(); names != nil {
+		t.Error(names)
+	}
+}
+
+// collectorRecorder is a collector transport which accepts connections and
+// records the methods of the data sent.  Data requests block while block is
+// not closed.
+type collectorRecorder struct {
+	sync.Mutex
+	methods []string
+	block   chan struct{}
+}
+
+func (c *collectorRecorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	switch cmd := r.URL.Query().Get("method"); cmd {
+	case cmdPreconnect:
+		return makeResponse(200, redirectBody), nil
+	case cmdConnect:
+		return makeResponse(200, connectBody), nil
+	default:
+		if nil != c.block {
+			<-c.block
+		}
+		c.Lock()
+		c.methods = append(c.methods, cmd)
+		c.Unlock()
+		return makeResponse(200, `{"return_value":null}`), nil
+	}
+}
+
+func (c *collectorRecorder) sent(method string) bool {
+	c.Lock()
+	defer c.Unlock()
+	for _, m := range c.methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func newGracefulShutdownApp(t *testing.T, transport http.RoundTripper) *Application {
+	app, err := NewApplication(
+		ConfigAppName(sampleAppName),
+		ConfigLicense(testLicenseKey),
+		func(cfg *Config) {
+			cfg.Transport = transport
+			cfg.Utilization.DetectAWS = false
+			cfg.Utilization.DetectAzure = false
+			cfg.Utilization.DetectPCF = false
+			cfg.Utilization.DetectGCP = false
+			cfg.Utilization.DetectDocker = false
+			cfg.Utilization.DetectKubernetes = false
+		},
+	)
+	if nil != err {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func TestGracefulShutdownHarvests(t *testing.T) {
+	collector := &collectorRecorder{}
+	app := newGracefulShutdownApp(t, collector)
+	if err := app.WaitForConnection(10 * time.Second); nil != err {
+		t.Fatal(err)
+	}
+	app.RecordCustomEvent("myEvent", map[string]interface{}{"zip": "zap"})
+	app.StartTransaction("hello").End()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := app.GracefulShutdown(ctx); nil != err {
+		t.Fatal(err)
+	}
+	for _, method := range []string{cmdMetrics, cmdCustomEvents, cmdTxnEvents} {
+		if !collector.sent(method) {
+			t.Error("data not harvested", method)
+		}
+	}
+	if txn := app.StartTransaction("after"); nil == txn {
+		t.Error("nil transaction after shutdown")
+	}
+}
+
+func TestGracefulShutdownDeadline(t *testing.T) {
+	collector := &collectorRecorder{block: make(chan struct{})}
+	defer close(collector.block)
+	app := newGracefulShutdownApp(t, collector)
+	if err := app.WaitForConnection(10 * time.Second); nil != err {
+		t.Fatal(err)
+	}
+	app.RecordCustomMetric("myMetric", 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := app.GracefulShutdown(ctx); err != context.DeadlineExceeded {
+		t.Error(err)
+	}
+}
+
+func TestGracefulShutdownNotConnected(t *testing.T) {
+	transport := roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("unreachable")
+	})
+	app := newGracefulShutdownApp(t, transport)
+	app.RecordCustomMetric("myMetric", 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := app.GracefulShutdown(ctx); nil != err {
+		t.Error(err)
+	}
+}
+
+func TestGracefulShutdownDisabled(t *testing.T) {
+	app := testApp(nil, nil, t)
+	if err := app.GracefulShutdown(context.Background()); nil != err {
+		t.Error(err)
+	}
+}
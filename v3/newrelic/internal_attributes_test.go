@@ -8,7 +8,10 @@ import (
 	"math"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/newrelic/go-agent/v3/internal"
 )
@@ -36,6 +39,785 @@ func TestAddAttributeHighSecurity(t *testing.T) {
 	}})
 }
 
+func TestSetRoutePattern(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetName("custom name")
+	txn.SetRoutePattern("/hello/:id")
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	txn.SetRoutePattern("/goodbye/:id")
+	app.expectSingleLoggedError(t, "unable to set route pattern", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/custom name",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeRoutePattern: "/hello/:id",
+		},
+	}})
+}
+
+func TestSetRoutePatternTooLong(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetRoutePattern("/" + strings.Repeat("a", attributeValueLengthLimit))
+	app.expectSingleLoggedError(t, "unable to set route pattern", map[string]interface{}{
+		"reason": errRoutePatternTooLong.Error(),
+	})
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestSetOperationName(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("")
+	txn.SetOperationName("resize-image")
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	txn.SetOperationName("other")
+	app.expectSingleLoggedError(t, "unable to set operation name", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+
+	txn = app.StartTransaction("resize-image/retry")
+	txn.SetOperationName("resize-image")
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/resize-image",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeOperationName: "resize-image",
+		},
+	}, {
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/resize-image/retry",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeOperationName: "resize-image",
+		},
+	}})
+}
+
+func TestSetOperationNameTooLong(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetOperationName(strings.Repeat("a", attributeValueLengthLimit+1))
+	app.expectSingleLoggedError(t, "unable to set operation name", map[string]interface{}{
+		"reason": errOperationNameTooLong.Error(),
+	})
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestSetUserID(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetUserID("user-123")
+	txn.NoticeError(myError{})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":  "OtherTransaction/Go/hello",
+			"error": true,
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeTotalErrors:          1,
+			AttributeDistinctErrorClasses: 1,
+			AttributeUserID:               "user-123",
+		},
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeUserID: "user-123",
+		},
+	}})
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+		AgentAttributes: map[string]interface{}{
+			AttributeUserID: "user-123",
+		},
+	}})
+}
+
+func TestSetUserIDExcludedFromTxnEvents(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.TransactionEvents.Attributes.Exclude = []string{AttributeUserID}
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.SetUserID("user-123")
+	txn.NoticeError(myError{})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":  "OtherTransaction/Go/hello",
+			"error": true,
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeTotalErrors:          1,
+			AttributeDistinctErrorClasses: 1,
+		},
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeUserID: "user-123",
+		},
+	}})
+}
+
+func TestSetUserIDHighSecurity(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.HighSecurity = true
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.SetUserID("user-123")
+	app.expectSingleLoggedError(t, "unable to set user ID", map[string]interface{}{
+		"reason": errHighSecurityEnabled.Error(),
+	})
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestSetUserIDTooLong(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetUserID(strings.Repeat("a", attributeValueLengthLimit+1))
+	app.expectSingleLoggedError(t, "unable to set user ID", map[string]interface{}{
+		"reason": errUserIDTooLong.Error(),
+	})
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestSetRequestID(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetRequestID("abc-123")
+	if id := txn.GetLinkingMetadata().RequestID; id != "abc-123" {
+		t.Error(id)
+	}
+	txn.NoticeError(myError{})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	txn.SetRequestID("def-456")
+	app.expectSingleLoggedError(t, "unable to set request ID", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":  "OtherTransaction/Go/hello",
+			"error": true,
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeTotalErrors:          1,
+			AttributeDistinctErrorClasses: 1,
+			AttributeRequestID:            "abc-123",
+		},
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeRequestID: "abc-123",
+		},
+	}})
+}
+
+func TestSetRequestIDInvalid(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	for _, id := range []string{
+		"abc\nNR-LINKING|forged",
+		"abc def",
+		"abc|def",
+		"abc\tdef",
+		"abc\x00def",
+		"abc\u2028def",
+	} {
+		txn := app.StartTransaction("hello")
+		txn.SetRequestID(id)
+		app.expectSingleLoggedError(t, "unable to set request ID", map[string]interface{}{
+			"reason": errInvalidRequestID.Error(),
+		})
+		if got := txn.GetLinkingMetadata().RequestID; got != "" {
+			t.Errorf("request ID %q recorded as %q", id, got)
+		}
+		txn.End()
+	}
+	want := internal.WantEvent{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+	}
+	app.ExpectTxnEvents(t, []internal.WantEvent{want, want, want, want, want, want})
+}
+
+func TestSetRequestIDHighSecurity(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.HighSecurity = true
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.SetRequestID("abc-123")
+	app.expectSingleLoggedError(t, "unable to set request ID", map[string]interface{}{
+		"reason": errHighSecurityEnabled.Error(),
+	})
+	if id := txn.GetLinkingMetadata().RequestID; id != "" {
+		t.Error(id)
+	}
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestSetRequestIDExcluded(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.Attributes.Exclude = []string{AttributeRequestID}
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.SetRequestID("abc-123")
+	app.expectNoLoggedErrors(t)
+	if id := txn.GetLinkingMetadata().RequestID; id != "" {
+		t.Error(id)
+	}
+	txn.End()
+
+	app = testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.AttributeFilter = func(key string, val interface{}, dest AttributeDestination) bool {
+			return key != AttributeRequestID
+		}
+	}, t)
+	txn = app.StartTransaction("hello")
+	txn.SetRequestID("abc-123")
+	if id := txn.GetLinkingMetadata().RequestID; id != "" {
+		t.Error(id)
+	}
+	txn.End()
+
+	// Excluding the attribute from some destinations does not remove it
+	// from linking metadata.
+	app = testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.TransactionEvents.Attributes.Exclude = []string{AttributeRequestID}
+	}, t)
+	txn = app.StartTransaction("hello")
+	txn.SetRequestID("abc-123")
+	if id := txn.GetLinkingMetadata().RequestID; id != "abc-123" {
+		t.Error(id)
+	}
+	txn.End()
+}
+
+func TestRecordTransactionStartTime(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.RecordTransactionStartTime = true
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.End()
+
+	start, _ := txn.thread.Attrs.GetAgentValue(AttributeTransactionStartTime, destTxnEvent)
+	parsed, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Equal(txn.thread.Start) {
+		t.Errorf("start time mismatch: %s %s", parsed, txn.thread.Start)
+	}
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeTransactionStartTime: internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestRecordTransactionStartTimeDisabled(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+		UserAttributes:  map[string]interface{}{},
+	}})
+}
+
+func TestRecordTransactionLockWait(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.RecordTransactionLockWait = true
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+
+	// Hold the lock so that AddAttribute must wait for it.
+	txn.thread.txn.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		txn.AddAttribute("zip", "zap")
+	}()
+	time.Sleep(10 * time.Millisecond)
+	txn.thread.txn.Unlock()
+	<-done
+	txn.End()
+
+	_, wait := txn.thread.Attrs.GetAgentValue(AttributeTransactionLockWait, destTxnEvent)
+	if seconds, ok := wait.(float64); !ok || seconds <= 0 {
+		t.Errorf("unexpected lock wait: %v", wait)
+	}
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeTransactionLockWait: internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{
+			"zip": "zap",
+		},
+	}})
+}
+
+func TestSetLatencyTarget(t *testing.T) {
+	now := time.Date(2023, time.November, 14, 22, 13, 20, 0, time.UTC)
+	defer func(orig func() time.Time) { timeNow = orig }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("met")
+	txn.SetLatencyTarget(3 * time.Second)
+	now = now.Add(2 * time.Second)
+	txn.End()
+
+	txn = app.StartTransaction("missed")
+	txn.SetLatencyTarget(time.Second)
+	now = now.Add(2 * time.Second)
+	txn.End()
+
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/met",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeMetLatencyTarget: true,
+		},
+		UserAttributes: map[string]interface{}{},
+	}, {
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/missed",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeMetLatencyTarget: false,
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestSetLatencyTargetInvalid(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetLatencyTarget(0)
+	app.expectSingleLoggedError(t, "unable to set latency target", map[string]interface{}{
+		"reason": errInvalidLatencyTarget.Error(),
+	})
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+		UserAttributes:  map[string]interface{}{},
+	}})
+}
+
+func TestSetAttemptNumber(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetAttemptNumber(-1)
+	app.expectSingleLoggedError(t, "unable to set attempt number", map[string]interface{}{
+		"reason": errNegativeAttemptNumber.Error(),
+	})
+	txn.SetAttemptNumber(3)
+	txn.End()
+	txn.SetAttemptNumber(4)
+	app.expectSingleLoggedError(t, "unable to set attempt number", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeAttemptNumber: 3,
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestAddBusyTime(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.AddBusyTime(-time.Second)
+	app.expectSingleLoggedError(t, "unable to add busy time", map[string]interface{}{
+		"reason": errNegativeBusyTime.Error(),
+	})
+	txn.AddBusyTime(1500 * time.Millisecond)
+	txn.AddBusyTime(500 * time.Millisecond)
+	txn.End()
+	txn.AddBusyTime(time.Second)
+	app.expectSingleLoggedError(t, "unable to add busy time", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeBusyTime: 2.0,
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+	app.ExpectMetrics(t, append([]internal.WantMetric{
+		{Name: "BusyTime/OtherTransaction/Go/hello", Scope: "", Forced: false, Data: []float64{1, 2, 2, 2, 2, 4}},
+	}, backgroundMetrics...))
+}
+
+func TestSetColdStart(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetColdStart(false)
+	txn.SetColdStart(true)
+	txn.End()
+	txn.SetColdStart(false)
+	app.expectSingleLoggedError(t, "unable to set cold start", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeAWSLambdaColdStart: true,
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestSetColdStartExcluded(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.TransactionEvents.Attributes.Exclude = []string{AttributeAWSLambdaColdStart}
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.SetColdStart(true)
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+		UserAttributes:  map[string]interface{}{},
+	}})
+}
+
+func TestApplicationAttributeDestinations(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.ErrorCollector.Attributes.Exclude = []string{"zip"}
+		cfg.BrowserMonitoring.Attributes.Enabled = false
+		cfg.SpanEvents.Attributes.Enabled = false
+		cfg.TransactionTracer.Segments.Attributes.Enabled = false
+	}, t)
+	dests := app.AttributeDestinations("zip")
+	if len(dests) != 2 ||
+		dests[0] != AttributeDestinationTransactionEvents ||
+		dests[1] != AttributeDestinationTransactionTraces {
+		t.Error(dests)
+	}
+
+	var nilApp *Application
+	if dests := nilApp.AttributeDestinations("zip"); nil != dests {
+		t.Error(dests)
+	}
+}
+
+func TestRecordBytesRead(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			txn.RecordBytesRead(100)
+		}()
+	}
+	wg.Wait()
+	txn.RecordBytesRead(-5)
+	txn.RecordBytesRead(24)
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	txn.RecordBytesRead(1)
+	app.expectSingleLoggedError(t, "unable to record bytes read", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeRequestBodyBytesRead: 1024,
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestRecordBytesReadNone(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.RecordBytesRead(0)
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+		UserAttributes:  map[string]interface{}{},
+	}})
+}
+
+func TestUserAttributeOnlyOnErrors(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.TransactionEvents.Attributes.Exclude = []string{"request.bodySnippet"}
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.AddAttribute("request.bodySnippet", "{\"id\":1}")
+	txn.AddAttribute("zip", "zap")
+	txn.NoticeError(myError{})
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":  "OtherTransaction/Go/hello",
+			"error": true,
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeTotalErrors:          1,
+			AttributeDistinctErrorClasses: 1,
+		},
+		UserAttributes: map[string]interface{}{
+			"zip": "zap",
+		},
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+		UserAttributes: map[string]interface{}{
+			"request.bodySnippet": "{\"id\":1}",
+			"zip":                 "zap",
+		},
+	}})
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+		UserAttributes: map[string]interface{}{
+			"request.bodySnippet": "{\"id\":1}",
+			"zip":                 "zap",
+		},
+	}})
+}
+
+func TestApdexThresholdAttribute(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		reply.KeyTxnApdex = map[string]float64{"WebTransaction/Go/hello": 0.25}
+	}
+	app := testApp(replyfn, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequest(WebRequest{})
+	txn.End()
+	txn = app.StartTransaction("background")
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "S",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold: 250,
+		},
+		UserAttributes: map[string]interface{}{},
+	}, {
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/background",
+		},
+		AgentAttributes: map[string]interface{}{},
+		UserAttributes:  map[string]interface{}{},
+	}})
+}
+
+func TestMaxAttributesPerTransaction(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.MaxAttributesPerTransaction = 2
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.AddAttribute("zip", 1)
+	txn.AddAttribute("zap", 2)
+	txn.AddAttribute("zop", 3)
+	txn.AddAttribute("zup", 4)
+	txn.AddAttribute("zip", 5)
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+		UserAttributes: map[string]interface{}{
+			"zip": 5,
+			"zap": 2,
+		},
+	}})
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: supportUserAttrsDropped, Scope: "", Forced: true, Data: []float64{2, 0, 0, 0, 0, 0}},
+	})
+}
+
+func TestMaxAttributesPerTransactionError(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.MaxAttributesPerTransaction = 1
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.AddAttribute("zip", 1)
+	app.expectNoLoggedErrors(t)
+	txn.AddAttribute("zap", 2)
+	app.expectSingleLoggedError(t, "unable to add attribute", map[string]interface{}{
+		"reason": errTooManyTxnAttributes{key: "zap", limit: 1}.Error(),
+	})
+	txn.End()
+}
+
+func TestDisableAttributes(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(helloRequest)
+	txn.AddAttribute("before", 1)
+	txn.NoticeError(Error{
+		Message:    "my msg",
+		Class:      "my class",
+		Attributes: map[string]interface{}{"zip": "zap"},
+	})
+	app.expectNoLoggedErrors(t)
+	txn.DisableAttributes()
+	txn.SetUserID("user")
+	txn.AddAttribute("after", 2)
+	app.expectSingleLoggedError(t, "unable to add attribute", map[string]interface{}{
+		"reason": errAttributesDisabled.Error(),
+	})
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "F",
+			"error":            true,
+		},
+		AgentAttributes: map[string]interface{}{},
+		UserAttributes:  map[string]interface{}{},
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "my class",
+			"error.message":   "my msg",
+			"transactionName": "WebTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+		UserAttributes:  map[string]interface{}{},
+	}})
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName:         "WebTransaction/Go/hello",
+		Msg:             "my msg",
+		Klass:           "my class",
+		AgentAttributes: map[string]interface{}{},
+		UserAttributes:  map[string]interface{}{},
+	}})
+}
+
 func TestAddAttributeSecurityPolicyDisablesParameters(t *testing.T) {
 	replyfn := func(reply *internal.ConnectReply) {
 		reply.SecurityPolicies.CustomParameters.SetEnabled(false)
@@ -81,8 +863,12 @@ func TestAddAttributeSecurityPolicyDisablesInclude(t *testing.T) {
 			"name":             "WebTransaction/Go/hello",
 			"nr.apdexPerfZone": "F",
 		},
-		AgentAttributes: map[string]interface{}{},
-		UserAttributes:  map[string]interface{}{},
+		AgentAttributes: map[string]interface{}{
+			AttributeTotalErrors:          1,
+			AttributeDistinctErrorClasses: 1,
+			AttributeApdexThreshold:       500,
+		},
+		UserAttributes: map[string]interface{}{},
 	}})
 	app.ExpectErrors(t, []internal.WantError{{
 		TxnName: "WebTransaction/Go/hello",
@@ -131,8 +917,11 @@ func TestUserAttributeBasics(t *testing.T) {
 		Intrinsics: map[string]interface{}{
 			"name": "OtherTransaction/Go/hello",
 		},
-		AgentAttributes: agentAttributes,
-		UserAttributes:  userAttributes,
+		AgentAttributes: mergeAttributes(agentAttributes, map[string]interface{}{
+			AttributeTotalErrors:          1,
+			AttributeDistinctErrorClasses: 1,
+		}),
+		UserAttributes: userAttributes,
 	}})
 	app.ExpectErrors(t, []internal.WantError{{
 		TxnName:         "OtherTransaction/Go/hello",
@@ -187,8 +976,11 @@ func TestUserAttributeConfiguration(t *testing.T) {
 		Intrinsics: map[string]interface{}{
 			"name": "OtherTransaction/Go/hello",
 		},
-		AgentAttributes: map[string]interface{}{},
-		UserAttributes:  map[string]interface{}{"only_txn_events": 2},
+		AgentAttributes: map[string]interface{}{
+			AttributeTotalErrors:          1,
+			AttributeDistinctErrorClasses: 1,
+		},
+		UserAttributes: map[string]interface{}{"only_txn_events": 2},
 	}})
 	app.ExpectErrors(t, []internal.WantError{{
 		TxnName:         "OtherTransaction/Go/hello",
@@ -215,8 +1007,8 @@ func TestUserAttributeConfiguration(t *testing.T) {
 }
 
 var (
-	// Agent attributes expected in txn events from usualAttributeTestTransaction.
-	agent1 = map[string]interface{}{
+	// Agent attributes expected in all destinations from usualAttributeTestTransaction.
+	agent0 = map[string]interface{}{
 		AttributeHostDisplayName:        `my\host\display\name`,
 		AttributeResponseCode:           `404`,
 		AttributeResponseCodeDeprecated: `404`,
@@ -229,8 +1021,14 @@ var (
 		AttributeRequestHost:            "my_domain.com",
 		AttributeRequestURI:             "/hello",
 	}
+	// Agent attributes expected in txn events from usualAttributeTestTransaction.
+	agent1 = mergeAttributes(agent0, map[string]interface{}{
+		AttributeApdexThreshold:       500,
+		AttributeTotalErrors:          1,
+		AttributeDistinctErrorClasses: 1,
+	})
 	// Agent attributes expected in errors and traces from usualAttributeTestTransaction.
-	agent2 = mergeAttributes(agent1, map[string]interface{}{
+	agent2 = mergeAttributes(agent0, map[string]interface{}{
 		AttributeRequestUserAgent:           "Mozilla/5.0",
 		AttributeRequestUserAgentDeprecated: "Mozilla/5.0",
 		AttributeRequestReferer:             "http://en.wikipedia.org/zip",
@@ -354,6 +1152,7 @@ func TestDefaultResponseCode(t *testing.T) {
 		AgentAttributes: map[string]interface{}{
 			AttributeResponseCode:           200,
 			AttributeResponseCodeDeprecated: 200,
+			AttributeApdexThreshold:         500,
 		},
 		UserAttributes: map[string]interface{}{},
 	}})
@@ -372,8 +1171,10 @@ func TestNoResponseCode(t *testing.T) {
 			"name":             "WebTransaction/Go/hello",
 			"nr.apdexPerfZone": "S",
 		},
-		AgentAttributes: map[string]interface{}{},
-		UserAttributes:  map[string]interface{}{},
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold: 500,
+		},
+		UserAttributes: map[string]interface{}{},
 	}})
 }
 
@@ -444,6 +1245,23 @@ var (
 		AttributeRequestUserAgent,
 		AttributeRequestUserAgentDeprecated,
 		AttributeRequestReferer,
+		AttributeApdexThreshold,
+		AttributeRequestScheme,
+		AttributeRequestClientRegion,
+		AttributeRequestHeaders,
+		AttributeRequestBody,
+		AttributeTotalErrors,
+		AttributeDistinctErrorClasses,
+		AttributeAttemptNumber,
+		AttributeQueueDuration,
+		AttributeBusyTime,
+		AttributeLogCountTrace,
+		AttributeLogCountDebug,
+		AttributeLogCountInfo,
+		AttributeLogCountWarn,
+		AttributeLogCountError,
+		AttributeLogCountFatal,
+		AttributeLogCountUnknown,
 	}
 )
 
@@ -539,8 +1357,12 @@ func TestRequestURIPresent(t *testing.T) {
 			"name":             "WebTransaction/Go/hello",
 			"nr.apdexPerfZone": "F",
 		},
-		AgentAttributes: agentAttributes,
-		UserAttributes:  userAttributes,
+		AgentAttributes: mergeAttributes(agentAttributes, map[string]interface{}{
+			AttributeTotalErrors:          1,
+			AttributeDistinctErrorClasses: 1,
+			AttributeApdexThreshold:       500,
+		}),
+		UserAttributes: userAttributes,
 	}})
 	app.ExpectErrors(t, []internal.WantError{{
 		TxnName:         "WebTransaction/Go/hello",
@@ -591,8 +1413,12 @@ func TestRequestURIExcluded(t *testing.T) {
 			"name":             "WebTransaction/Go/hello",
 			"nr.apdexPerfZone": "F",
 		},
-		AgentAttributes: agentAttributes,
-		UserAttributes:  userAttributes,
+		AgentAttributes: mergeAttributes(agentAttributes, map[string]interface{}{
+			AttributeApdexThreshold:       500,
+			AttributeTotalErrors:          1,
+			AttributeDistinctErrorClasses: 1,
+		}),
+		UserAttributes: userAttributes,
 	}})
 	app.ExpectErrors(t, []internal.WantError{{
 		TxnName:         "WebTransaction/Go/hello",
@@ -618,6 +1444,273 @@ func TestRequestURIExcluded(t *testing.T) {
 	}})
 }
 
+func TestCaptureRequestHeaders(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.CaptureRequestHeaders = []string{"X-Tenant-ID", "x-region", "Authorization", "X-Missing"}
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	hdrs := http.Header{}
+	hdrs.Set("X-Tenant-ID", "tenant-1")
+	hdrs.Set("X-Region", "us-east")
+	hdrs.Set("Authorization", "secret")
+	txn.SetWebRequest(WebRequest{Header: hdrs})
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "S",
+		},
+		AgentAttributes: map[string]interface{}{
+			"request.headers.x-tenant-id": "tenant-1",
+			"request.headers.x-region":    "us-east",
+			AttributeApdexThreshold:       500,
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestCaptureRequestHeadersExcluded(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.CaptureRequestHeaders = []string{"X-Tenant-ID"}
+		cfg.TransactionEvents.Attributes.Exclude = []string{"request.headers.x-tenant-id"}
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	hdrs := http.Header{}
+	hdrs.Set("X-Tenant-ID", "tenant-1")
+	txn.SetWebRequest(WebRequest{Header: hdrs})
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "S",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold: 500,
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestCaptureRequestHeadersHighSecurity(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.HighSecurity = true
+		cfg.CaptureRequestHeaders = []string{"X-Tenant-ID"}
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	hdrs := http.Header{}
+	hdrs.Set("X-Tenant-ID", "tenant-1")
+	txn.SetWebRequest(WebRequest{Header: hdrs})
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "S",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold: 500,
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestCaptureRequestHeadersAsJSON(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.CaptureRequestHeadersAsJSON = true
+		cfg.CaptureRequestHeaders = []string{"X-Tenant-ID", "Authorization", "Accept"}
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	hdrs := http.Header{}
+	hdrs.Set("Accept", "text/html")
+	hdrs.Set("Content-Type", "text/plain")
+	hdrs.Set("X-Tenant-ID", "tenant-1")
+	hdrs.Set("Authorization", "secret")
+	hdrs.Set("Cookie", "secret")
+	txn.SetWebRequest(WebRequest{Header: hdrs, Host: "example.com"})
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "S",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeRequestHeaders: `{"accept":"text/html","content-type":"text/plain","host":"example.com","x-tenant-id":"tenant-1"}`,
+			AttributeApdexThreshold: 500,
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestCaptureRequestHeadersAsJSONDestinations(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.CaptureRequestHeadersAsJSON = true
+		cfg.CaptureRequestHeaders = []string{"X-Tenant-ID", "X-Secret"}
+		cfg.Attributes.Exclude = []string{AttributeRequestContentType}
+		cfg.TransactionEvents.Attributes.Exclude = []string{"request.headers.x-secret"}
+		cfg.AttributeFilter = func(key string, val interface{}, dest AttributeDestination) bool {
+			return val != "forbidden"
+		}
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	hdrs := http.Header{}
+	hdrs.Set("Accept", "forbidden")
+	hdrs.Set("Content-Type", "text/plain")
+	hdrs.Set("User-Agent", "curl")
+	hdrs.Set("Referer", "http://example.com/")
+	hdrs.Set("X-Tenant-ID", "tenant-1")
+	hdrs.Set("X-Secret", "secret")
+	txn.SetWebRequest(WebRequest{Header: hdrs, Host: "example.com"})
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "S",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeRequestHeaders: `{"host":"example.com","x-tenant-id":"tenant-1"}`,
+			AttributeApdexThreshold: 500,
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestCaptureRequestHeadersAsJSONLengthLimit(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.CaptureRequestHeadersAsJSON = true
+		cfg.CaptureRequestHeaders = []string{"X-Long", "X-Short"}
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	hdrs := http.Header{}
+	hdrs.Set("Accept", "text/html")
+	hdrs.Set("X-Long", strings.Repeat("a", 250))
+	hdrs.Set("X-Short", "b")
+	txn.SetWebRequest(WebRequest{Header: hdrs})
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "S",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeRequestHeaders: `{"accept":"text/html","x-short":"b"}`,
+			AttributeApdexThreshold: 500,
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestAttributeFilter(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.TransactionTracer.Threshold.IsApdexFailing = false
+		cfg.TransactionTracer.Threshold.Duration = 0
+		cfg.AttributeFilter = func(name string, value interface{}, dest AttributeDestination) bool {
+			if value == "" {
+				return false
+			}
+			if name == "secret" && dest != AttributeDestinationTransactionTraces {
+				return false
+			}
+			return name != AttributeRequestMethod || dest != AttributeDestinationErrors
+		}
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequest(WebRequest{Method: "GET"})
+	txn.AddAttribute("empty", "")
+	txn.AddAttribute("secret", "shh")
+	txn.AddAttribute("zip", "zap")
+	txn.NoticeError(Error{
+		Message:    "my msg",
+		Class:      "my class",
+		Attributes: map[string]interface{}{"errEmpty": ""},
+	})
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "F",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeRequestMethod:        "GET",
+			AttributeApdexThreshold:       500,
+			AttributeTotalErrors:          1,
+			AttributeDistinctErrorClasses: 1,
+		},
+		UserAttributes: map[string]interface{}{"zip": "zap"},
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "my class",
+			"error.message":   "my msg",
+			"transactionName": "WebTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+		UserAttributes:  map[string]interface{}{"zip": "zap"},
+	}})
+	app.ExpectTxnTraces(t, []internal.WantTxnTrace{{
+		MetricName:      "WebTransaction/Go/hello",
+		NumSegments:     0,
+		AgentAttributes: map[string]interface{}{AttributeRequestMethod: "GET"},
+		UserAttributes:  map[string]interface{}{"zip": "zap", "secret": "shh"},
+	}})
+}
+
+func TestAttributeFilterPanicRecovered(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.TransactionTracer.Threshold.IsApdexFailing = false
+		cfg.TransactionTracer.Threshold.Duration = 0
+		cfg.AttributeFilter = func(name string, value interface{}, dest AttributeDestination) bool {
+			if name == "boom" && dest == AttributeDestinationTransactionEvents {
+				panic("oops")
+			}
+			return true
+		}
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.AddAttribute("boom", "value")
+	app.expectSingleLoggedError(t, "panic in attribute filter", map[string]interface{}{
+		"attribute":   "boom",
+		"destination": string(AttributeDestinationTransactionEvents),
+		"panic":       "oops",
+	})
+	txn.AddAttribute("zip", "zap")
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		UserAttributes: map[string]interface{}{"zip": "zap"},
+	}})
+	app.ExpectTxnTraces(t, []internal.WantTxnTrace{{
+		MetricName:      "OtherTransaction/Go/hello",
+		NumSegments:     0,
+		AgentAttributes: map[string]interface{}{},
+		UserAttributes:  map[string]interface{}{"zip": "zap", "boom": "value"},
+	}})
+}
+
 func TestMessageAttributes(t *testing.T) {
 	// test that adding message attributes as agent attributes filters them,
 	// but as user attributes does not filter them.
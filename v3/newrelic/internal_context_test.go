@@ -24,7 +24,7 @@ func TestWrapHandlerContext(t *testing.T) {
 	h(nil, req)
 
 	scope := "WebTransaction/Go/GET myTxn"
-	app.ExpectMetrics(t, []internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", []internal.WantMetric{
 		{Name: "WebTransaction/Go/GET myTxn", Scope: "", Forced: true, Data: nil},
 		{Name: "WebTransaction", Scope: "", Forced: true, Data: nil},
 		{Name: "WebTransactionTotalTime/Go/GET myTxn", Scope: "", Forced: false, Data: nil},
@@ -34,7 +34,7 @@ func TestWrapHandlerContext(t *testing.T) {
 		{Name: "Apdex/Go/GET myTxn", Scope: "", Forced: false, Data: nil},
 		{Name: "Custom/mySegment", Scope: "", Forced: false, Data: nil},
 		{Name: "Custom/mySegment", Scope: scope, Forced: false, Data: nil},
-	})
+	}))
 }
 
 func TestStartExternalSegmentNilTransaction(t *testing.T) {
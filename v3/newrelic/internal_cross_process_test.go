@@ -87,14 +87,15 @@ func TestCrossProcessWriteHeaderSuccess(t *testing.T) {
 		t.Error(w.Header().Get(cat.NewRelicAppDataName))
 	}
 
-	app.ExpectMetrics(t, webMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", withResponseClassMetric("2xx", webMetrics)))
 	app.ExpectTxnEvents(t, []internal.WantEvent{{
 		Intrinsics: catIntrinsics,
 		AgentAttributes: map[string]interface{}{
-			"request.method":   "GET",
-			"httpResponseCode": 200,
-			"http.statusCode":  200,
-			"request.uri":      "newrelic.com",
+			AttributeApdexThreshold: 500,
+			"request.method":        "GET",
+			"httpResponseCode":      200,
+			"http.statusCode":       200,
+			"request.uri":           "newrelic.com",
 		},
 		UserAttributes: map[string]interface{}{},
 	}})
@@ -120,7 +121,7 @@ func TestCrossProcessWriteSuccess(t *testing.T) {
 		t.Error(w.Header().Get(cat.NewRelicAppDataName))
 	}
 
-	app.ExpectMetrics(t, webMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", withResponseClassMetric("2xx", webMetrics)))
 	app.ExpectTxnEvents(t, []internal.WantEvent{{
 		Intrinsics: catIntrinsics,
 		// Do not test attributes here:  In Go 1.5
@@ -148,7 +149,7 @@ func TestCrossProcessLocallyDisabled(t *testing.T) {
 		t.Error(w.Header().Get(cat.NewRelicAppDataName))
 	}
 
-	app.ExpectMetrics(t, webMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", withResponseClassMetric("2xx", webMetrics)))
 	app.ExpectTxnEvents(t, []internal.WantEvent{{
 		Intrinsics: map[string]interface{}{
 			"name":             "WebTransaction/Go/hello",
@@ -182,7 +183,7 @@ func TestCrossProcessDisabledByServerSideConfig(t *testing.T) {
 		t.Error(w.Header().Get(cat.NewRelicAppDataName))
 	}
 
-	app.ExpectMetrics(t, webMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", withResponseClassMetric("2xx", webMetrics)))
 	app.ExpectTxnEvents(t, []internal.WantEvent{{
 		Intrinsics: map[string]interface{}{
 			"name":             "WebTransaction/Go/hello",
@@ -217,7 +218,7 @@ func TestCrossProcessEnabledByServerSideConfig(t *testing.T) {
 		t.Error(w.Header().Get(cat.NewRelicAppDataName))
 	}
 
-	app.ExpectMetrics(t, webMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", withResponseClassMetric("2xx", webMetrics)))
 	app.ExpectTxnEvents(t, []internal.WantEvent{{
 		Intrinsics: catIntrinsics,
 		// Do not test attributes here:  In Go 1.5
@@ -808,6 +808,123 @@ func TestParseExceptionSupportabilityMetric(t *testing.T) {
 	}, backgroundUnknownCallerWithTransport...))
 }
 
+func TestDistributedTraceHeadersJSONRoundTrip(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+
+	outbound := app.StartTransaction("outbound")
+	hdrs := http.Header{}
+	outbound.InsertDistributedTraceHeaders(hdrs)
+	payload := make(map[string]string, len(hdrs))
+	for k := range hdrs {
+		payload[k] = hdrs.Get(k)
+	}
+	js, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inbound := app.StartTransaction("inbound")
+	if err := inbound.AcceptDistributedTraceHeadersFromJSON(TransportHTTP, string(js)); err != nil {
+		t.Fatal(err)
+	}
+	app.expectNoLoggedErrors(t)
+
+	if out, in := outbound.GetTraceMetadata().TraceID, inbound.GetTraceMetadata().TraceID; out != in {
+		t.Errorf("trace ids do not match: outbound=%s inbound=%s", out, in)
+	}
+
+	inbound.End()
+	outbound.End()
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Supportability/TraceContext/Accept/Success", Scope: "", Forced: true, Data: singleCount},
+	})
+}
+
+func TestDistributedTraceHeadersFromJSONInvalidPayload(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+
+	txn := app.StartTransaction("hello")
+	js := `{"newrelic": "not a payload"}`
+	if err := txn.AcceptDistributedTraceHeadersFromJSON(TransportHTTP, js); err != nil {
+		t.Fatal(err)
+	}
+	txn.End()
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Supportability/DistributedTrace/AcceptPayload/ParseException", Scope: "", Forced: true, Data: singleCount},
+	})
+}
+
+func TestDistributedTracePayloadRoundTrip(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+
+	outbound := app.StartTransaction("outbound")
+	payload := outbound.CreateDistributedTracePayload()
+	hdrs, err := DistributedTraceHeadersFromJSON(payload)
+	if err != nil {
+		t.Fatal(payload, err)
+	}
+	for _, h := range []string{
+		DistributedTraceNewRelicHeader,
+		DistributedTraceW3CTraceParentHeader,
+		DistributedTraceW3CTraceStateHeader,
+	} {
+		if hdrs.Get(h) == "" {
+			t.Error("missing header", h, payload)
+		}
+	}
+
+	inbound := app.StartTransaction("inbound")
+	inbound.AcceptDistributedTracePayload(payload)
+	app.expectNoLoggedErrors(t)
+
+	if out, in := outbound.GetTraceMetadata().TraceID, inbound.GetTraceMetadata().TraceID; out != in {
+		t.Errorf("trace ids do not match: outbound=%s inbound=%s", out, in)
+	}
+
+	inbound.End()
+	outbound.End()
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Supportability/TraceContext/Create/Success", Scope: "", Forced: true, Data: singleCount},
+		{Name: "Supportability/TraceContext/Accept/Success", Scope: "", Forced: true, Data: singleCount},
+	})
+}
+
+func TestCreateDistributedTracePayloadDisabled(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	if payload := txn.CreateDistributedTracePayload(); payload != "" {
+		t.Error(payload)
+	}
+	txn.End()
+
+	var nilTxn *Transaction
+	if payload := nilTxn.CreateDistributedTracePayload(); payload != "" {
+		t.Error(payload)
+	}
+	nilTxn.AcceptDistributedTracePayload(`{}`)
+}
+
+func TestAcceptDistributedTracePayloadInvalid(t *testing.T) {
+	for _, payload := range []string{
+		`not json`,
+		`["traceparent"]`,
+		`{"traceparent": 1}`,
+	} {
+		app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+		txn := app.StartTransaction("hello")
+		txn.AcceptDistributedTracePayload(payload)
+		app.expectNoLoggedErrors(t)
+		txn.End()
+
+		app.ExpectMetricsPresent(t, []internal.WantMetric{
+			{Name: "Supportability/DistributedTrace/AcceptPayload/ParseException", Scope: "", Forced: true, Data: singleCount},
+		})
+	}
+}
+
 func TestErrorsByCaller(t *testing.T) {
 	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
 
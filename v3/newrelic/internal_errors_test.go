@@ -57,7 +57,7 @@ func TestNoticeErrorWeb(t *testing.T) {
 		},
 		AgentAttributes: helloRequestAttributes,
 	}})
-	app.ExpectMetrics(t, webErrorMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webErrorMetrics))
 }
 
 func TestNoticeErrorTxnEnded(t *testing.T) {
@@ -169,7 +169,640 @@ func TestErrorsEnabledByServerSideConfig(t *testing.T) {
 	}
 	app := testApp(replyfn, cfgFn, t)
 	txn := app.StartTransaction("hello")
+	if !txn.ErrorsEnabled() {
+		t.Error("errors should be enabled by server side config")
+	}
+	txn.NoticeError(myError{})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		UserAttributes:  map[string]interface{}{},
+		AgentAttributes: map[string]interface{}{},
+	}})
+	app.ExpectMetrics(t, backgroundErrorMetrics)
+}
+
+func TestErrorsEnabled(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	if !txn.ErrorsEnabled() {
+		t.Error("errors should be enabled")
+	}
+	txn.End()
+	if txn.ErrorsEnabled() {
+		t.Error("errors should not be enabled after End")
+	}
+
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.Enabled = false
+	}
+	app = testApp(nil, cfgFn, t)
+	txn = app.StartTransaction("hello")
+	if txn.ErrorsEnabled() {
+		t.Error("errors should be disabled locally")
+	}
+}
+
+func TestNoticeErrorTracedErrorsRemotelyDisabled(t *testing.T) {
+	// This tests that the connect reply field "collect_errors" controls the
+	// collection of traced-errors, not error-events.
+	replyfn := func(reply *internal.ConnectReply) { reply.CollectErrors = false }
+	app := testApp(replyfn, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	if txn.ErrorsEnabled() {
+		t.Error("errors should be disabled by the connect reply")
+	}
+	txn.NoticeError(myError{})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		UserAttributes:  map[string]interface{}{},
+		AgentAttributes: map[string]interface{}{},
+	}})
+	app.ExpectMetrics(t, backgroundErrorMetrics)
+}
+
+func TestNoticeErrorNil(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(nil)
+	app.expectSingleLoggedError(t, "unable to notice error", map[string]interface{}{
+		"reason": errNilError.Error(),
+	})
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{})
+	app.ExpectErrorEvents(t, []internal.WantEvent{})
+	app.ExpectMetrics(t, backgroundMetrics)
+}
+
+func TestNoticeErrorEventsLocallyDisabled(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.ErrorCollector.CaptureEvents = false
+		cfg.DistributedTracer.Enabled = false
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{})
+	app.ExpectMetrics(t, backgroundErrorMetrics)
+}
+
+func TestNoticeErrorTracesLocallyDisabled(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.ErrorCollector.CaptureTraces = false
+		cfg.DistributedTracer.Enabled = false
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+	}})
+	app.ExpectMetrics(t, backgroundErrorMetrics)
+}
+
+func TestNoticeErrorTracesSampledOnly(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.ErrorCollector.CaptureTracesSampledOnly = true
+	}
+	app := testApp(sampleEverythingReplyFn, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+			"guid":            internal.MatchAnything,
+			"traceId":         internal.MatchAnything,
+			"priority":        internal.MatchAnything,
+			"spanId":          internal.MatchAnything,
+			"sampled":         true,
+		},
+	}})
+}
+
+func TestNoticeErrorTracesSampledOnlyNotSampled(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		reply.SetSampleNothing()
+	}
+	cfgFn := func(cfg *Config) {
+		cfg.ErrorCollector.CaptureTracesSampledOnly = true
+	}
+	app := testApp(replyfn, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+			"guid":            internal.MatchAnything,
+			"traceId":         internal.MatchAnything,
+			"priority":        internal.MatchAnything,
+			"sampled":         false,
+		},
+	}})
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Errors/all", Scope: "", Forced: true, Data: singleCount},
+	})
+}
+
+func TestNoticeHandledError(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(helloRequest)
+	txn.NoticeHandledError(myError{})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"error.handled":   true,
+			"transactionName": "WebTransaction/Go/hello",
+		},
+	}})
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "F",
+			"error":            true,
+		},
+	}})
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webErrorMetrics))
+}
+
+func TestNoticeHandledErrorExcludedFromApdex(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.ErrorCollector.ExcludeHandledFromApdex = true
+		cfg.DistributedTracer.Enabled = false
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(helloRequest)
+	txn.NoticeHandledError(myError{})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "S",
+			"error":            true,
+		},
+	}})
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webErrorMetrics))
+}
+
+func TestMarkSuccess(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(helloRequest)
+	txn.NoticeError(myError{})
+	txn.MarkSuccess()
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "S",
+			"error":            true,
+		},
+	}})
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}})
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webErrorMetrics))
+}
+
+func TestMarkSuccessAfterEnd(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.End()
+	txn.MarkSuccess()
+	app.expectSingleLoggedError(t, "unable to mark transaction success", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+}
+
+func TestErrorDefaultAttributes(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.DefaultAttributes = map[string]interface{}{
+			"build.sha": "abc123",
+			"zip":       "default",
+			"txnAttr":   "default",
+			"invalid":   struct{}{},
+		}
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.AddAttribute("txnAttr", "txn")
+	txn.NoticeError(Error{
+		Message:    "my msg",
+		Class:      "my class",
+		Attributes: map[string]interface{}{"zip": "zap"},
+	})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	userAttributes := map[string]interface{}{
+		"build.sha": "abc123",
+		"zip":       "zap",
+		"txnAttr":   "txn",
+	}
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName:        "OtherTransaction/Go/hello",
+		Msg:            "my msg",
+		Klass:          "my class",
+		UserAttributes: userAttributes,
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "my class",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		UserAttributes: userAttributes,
+	}})
+}
+
+func TestErrorDefaultAttributesHighSecurity(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.HighSecurity = true
+		cfg.ErrorCollector.DefaultAttributes = map[string]interface{}{
+			"build.sha": "abc123",
+		}
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	txn.End()
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   highSecurityErrorMsg,
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestNoticeErrorWithAttributes(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeErrorWithAttributes(Error{
+		Message:    "my msg",
+		Class:      "my class",
+		Attributes: map[string]interface{}{"zip": "zap", "code": 1},
+	}, map[string]interface{}{
+		"code":   42,
+		"region": "us-east",
+	})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "my class",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		UserAttributes: map[string]interface{}{
+			"zip":    "zap",
+			"code":   42,
+			"region": "us-east",
+		},
+	}})
+	app.ExpectMetrics(t, backgroundErrorMetrics)
+}
+
+func TestNoticeErrorWithGroupAndAttributes(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.ErrorGroupCallback = func(ErrorInfo) string {
+			return "callback group"
+		}
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeErrorWithGroupAndAttributes(myError{}, " payments ", map[string]interface{}{"code": 42})
+	txn.NoticeErrorWithGroupAndAttributes(myError{}, "", nil)
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeErrorGroupName: "payments",
+		},
+		UserAttributes: map[string]interface{}{
+			"code": 42,
+		},
+	}, {
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeErrorGroupName: "callback group",
+		},
+	}})
+}
+
+func TestNoticeErrorWithGroupAndAttributesInvalid(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeErrorWithGroupAndAttributes(myError{}, "payments", map[string]interface{}{"zip": struct{}{}})
+	app.expectSingleLoggedError(t, "unable to notice error", map[string]interface{}{
+		"reason": errInvalidAttributeType{key: "zip", val: struct{}{}}.Error(),
+	})
+	txn.End()
+	app.ExpectErrorEvents(t, []internal.WantEvent{})
+}
+
+func TestNoticeErrorWithID(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	id := txn.NoticeErrorWithID(myError{})
+	app.expectNoLoggedErrors(t)
+	if len(id) != 32 {
+		t.Fatal(id)
+	}
+	if other := txn.NoticeErrorWithID(myError{}); other == id {
+		t.Error("error ids should be unique", other)
+	}
+	txn.End()
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"error.id":        id,
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+	}, {
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"error.id":        internal.MatchAnything,
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+	}})
+}
+
+func TestNoticeErrorWithIDNotRecorded(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.Enabled = false
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	if id := txn.NoticeErrorWithID(myError{}); id != "" {
+		t.Error(id)
+	}
+	app.expectSingleLoggedError(t, "unable to notice error", map[string]interface{}{
+		"reason": errorsDisabled.Error(),
+	})
+	txn.End()
+	app.ExpectErrorEvents(t, []internal.WantEvent{})
+}
+
+func TestNoticeErrorWithIDRecordOnlyClasses(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.RecordOnlyClasses = []string{"my class"}
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	if id := txn.NoticeErrorWithID(myError{}); id != "" {
+		t.Error(id)
+	}
+	if id := txn.NoticeErrorWithID(Error{Message: "oops", Class: "my class"}); len(id) != 32 {
+		t.Error(id)
+	}
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "my class",
+			"error.message":   "oops",
+			"error.id":        internal.MatchAnything,
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+	}})
+}
+
+func TestNoticeErrorWithIDPastErrorLimit(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	for i := 0; i < maxTxnErrors; i++ {
+		if id := txn.NoticeErrorWithID(myError{}); len(id) != 32 {
+			t.Error(i, id)
+		}
+	}
+	if id := txn.NoticeErrorWithID(myError{}); id != "" {
+		t.Error(id)
+	}
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	want := make([]internal.WantEvent, maxTxnErrors)
+	for i := range want {
+		want[i] = internal.WantEvent{
+			Intrinsics: map[string]interface{}{
+				"error.class":     "newrelic.myError",
+				"error.message":   "my msg",
+				"error.id":        internal.MatchAnything,
+				"transactionName": "OtherTransaction/Go/hello",
+			},
+		}
+	}
+	app.ExpectErrorEvents(t, want)
+}
+
+func TestNoticeErrorWithSeverity(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.ExpectSeverities = []string{"Warning"}
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeErrorWithSeverity(myError{}, " critical ")
+	txn.NoticeErrorWithSeverity(myError{}, "warning")
+	txn.NoticeErrorWithSeverity(myError{}, "")
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"error.severity":  "critical",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+	}, {
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"error.severity":  "warning",
+			"error.expected":  true,
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+	}, {
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+	}})
+}
+
+func TestNoticeErrorWithExpectedSeverityApdex(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.ExpectSeverities = []string{"warning"}
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequest(WebRequest{Transport: TransportHTTP})
+	txn.NoticeErrorWithSeverity(myError{}, "warning")
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "S",
+		},
+	}})
+}
+
+func TestRecordOnlyClasses(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.RecordOnlyClasses = []string{"my class"}
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	txn.NoticeError(Error{Message: "my msg", Class: "my class"})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   "my class",
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "my class",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+	}})
+	app.ExpectMetrics(t, backgroundErrorMetrics)
+}
+
+func TestRecordOnlyClassesNoneRecorded(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.RecordOnlyClasses = []string{"my class"}
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{})
+	app.ExpectErrorEvents(t, []internal.WantEvent{})
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":  "OtherTransaction/Go/hello",
+			"error": true,
+		},
+	}})
+	app.ExpectMetrics(t, backgroundErrorMetrics)
+}
+
+func TestDistinctErrorClasses(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
 	txn.NoticeError(myError{})
+	txn.NoticeError(Error{Message: "my msg", Class: "my class"})
+	txn.NoticeError(myError{})
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":  "OtherTransaction/Go/hello",
+			"error": true,
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeTotalErrors:          3,
+			AttributeDistinctErrorClasses: 2,
+		},
+	}})
+}
+
+func TestDistinctErrorClassesBounded(t *testing.T) {
+	txn := &txn{}
+	for i := 0; i < 2*maxDistinctErrorClasses; i++ {
+		txn.countError(strconv.Itoa(i))
+	}
+	if txn.totalErrors != 2*maxDistinctErrorClasses {
+		t.Error(txn.totalErrors)
+	}
+	if n := len(txn.errorClasses); n != maxDistinctErrorClasses {
+		t.Error(n)
+	}
+}
+
+func TestRecordErrorInfoOnly(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.RecordErrorInfoOnly(myError{})
 	app.expectNoLoggedErrors(t)
 	txn.End()
 	app.ExpectErrors(t, []internal.WantError{{
@@ -181,66 +814,120 @@ func TestErrorsEnabledByServerSideConfig(t *testing.T) {
 		Intrinsics: map[string]interface{}{
 			"error.class":     "newrelic.myError",
 			"error.message":   "my msg",
+			"error.expected":  true,
 			"transactionName": "OtherTransaction/Go/hello",
 		},
-		UserAttributes:  map[string]interface{}{},
+	}})
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":  "OtherTransaction/Go/hello",
+			"error": false,
+		},
 		AgentAttributes: map[string]interface{}{},
 	}})
-	app.ExpectMetrics(t, backgroundErrorMetrics)
+	app.ExpectMetrics(t, backgroundMetrics)
 }
 
-func TestNoticeErrorTracedErrorsRemotelyDisabled(t *testing.T) {
-	// This tests that the connect reply field "collect_errors" controls the
-	// collection of traced-errors, not error-events.
-	replyfn := func(reply *internal.ConnectReply) { reply.CollectErrors = false }
-	app := testApp(replyfn, ConfigDistributedTracerEnabled(false), t)
+func TestRecordErrorInfoOnlyApdex(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
 	txn := app.StartTransaction("hello")
-	txn.NoticeError(myError{})
-	app.expectNoLoggedErrors(t)
+	txn.SetWebRequest(WebRequest{Transport: TransportHTTP})
+	txn.RecordErrorInfoOnly(myError{})
 	txn.End()
-	app.ExpectErrors(t, []internal.WantError{})
-	app.ExpectErrorEvents(t, []internal.WantEvent{{
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
 		Intrinsics: map[string]interface{}{
-			"error.class":     "newrelic.myError",
-			"error.message":   "my msg",
-			"transactionName": "OtherTransaction/Go/hello",
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "S",
+			"error":            false,
 		},
-		UserAttributes:  map[string]interface{}{},
-		AgentAttributes: map[string]interface{}{},
 	}})
-	app.ExpectMetrics(t, backgroundErrorMetrics)
 }
 
-func TestNoticeErrorNil(t *testing.T) {
-	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+func TestRecordErrorInfoOnlyNil(t *testing.T) {
+	app := testApp(nil, nil, t)
 	txn := app.StartTransaction("hello")
-	txn.NoticeError(nil)
-	app.expectSingleLoggedError(t, "unable to notice error", map[string]interface{}{
+	txn.RecordErrorInfoOnly(nil)
+	app.expectSingleLoggedError(t, "unable to record error", map[string]interface{}{
 		"reason": errNilError.Error(),
 	})
 	txn.End()
 	app.ExpectErrors(t, []internal.WantError{})
-	app.ExpectErrorEvents(t, []internal.WantEvent{})
-	app.ExpectMetrics(t, backgroundMetrics)
 }
 
-func TestNoticeErrorEventsLocallyDisabled(t *testing.T) {
+func TestNoticeErrorWithAttributesHighSecurity(t *testing.T) {
 	cfgFn := func(cfg *Config) {
-		cfg.ErrorCollector.CaptureEvents = false
 		cfg.DistributedTracer.Enabled = false
+		cfg.HighSecurity = true
 	}
 	app := testApp(nil, cfgFn, t)
 	txn := app.StartTransaction("hello")
-	txn.NoticeError(myError{})
+	txn.NoticeErrorWithAttributes(Error{
+		Message:    "my msg",
+		Class:      "my class",
+		Attributes: map[string]interface{}{"count": 3},
+	}, map[string]interface{}{
+		"code":      42,
+		"retryable": true,
+		"region":    "us-east",
+		"ratio":     1.5,
+	})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "my class",
+			"error.message":   highSecurityErrorMsg,
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		UserAttributes: map[string]interface{}{
+			"code":      42,
+			"retryable": true,
+		},
+	}})
+}
+
+func TestNoticeErrorWithAttributesSecurityPolicy(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) { reply.SecurityPolicies.CustomParameters.SetEnabled(false) }
+	app := testApp(replyfn, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeErrorWithAttributes(Error{
+		Message:    "my msg",
+		Class:      "my class",
+		Attributes: map[string]interface{}{"count": 3},
+	}, map[string]interface{}{
+		"code":      42,
+		"retryable": true,
+		"region":    "us-east",
+	})
 	app.expectNoLoggedErrors(t)
 	txn.End()
 	app.ExpectErrors(t, []internal.WantError{{
-		TxnName: "OtherTransaction/Go/hello",
-		Msg:     "my msg",
-		Klass:   "newrelic.myError",
+		TxnName:        "OtherTransaction/Go/hello",
+		Msg:            "my msg",
+		Klass:          "my class",
+		UserAttributes: map[string]interface{}{},
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "my class",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		UserAttributes: map[string]interface{}{},
 	}})
+}
+
+func TestNoticeErrorWithAttributesInvalid(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeErrorWithAttributes(myError{}, map[string]interface{}{
+		"invalid": struct{}{},
+	})
+	app.expectSingleLoggedError(t, "unable to notice error", map[string]interface{}{
+		"reason": errInvalidAttributeType{key: "invalid", val: struct{}{}}.Error(),
+	})
+	txn.End()
 	app.ExpectErrorEvents(t, []internal.WantEvent{})
-	app.ExpectMetrics(t, backgroundErrorMetrics)
 }
 
 func TestNoticeErrorEventsRemotelyDisabled(t *testing.T) {
@@ -699,6 +1386,32 @@ func TestNoticeErrorWriteHeaderSpanID(t *testing.T) {
 	app.ExpectMetrics(t, backgroundErrorMetricsUnknownCaller)
 }
 
+func TestNoticeErrorUserRecoveredPanicHasNoSource(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.RecordPanics = true
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				txn.NoticeError(Error{Message: "oops", Class: "panic"})
+			}
+		}()
+		panic("oops")
+	}()
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "panic",
+			"error.message":   "oops",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+	}})
+}
+
 func TestNoticeErrorPanicRecoverySpanID(t *testing.T) {
 	cfgfn := func(cfg *Config) {
 		enableBetterCAT(cfg)
@@ -725,6 +1438,7 @@ func TestNoticeErrorPanicRecoverySpanID(t *testing.T) {
 		Intrinsics: map[string]interface{}{
 			"error.class":     "panic",
 			"error.message":   "oops",
+			"error.source":    "recovered_panic",
 			"guid":            "52fdfc072182654f",
 			"priority":        1.437714,
 			"sampled":         true,
@@ -733,5 +1447,211 @@ func TestNoticeErrorPanicRecoverySpanID(t *testing.T) {
 			"transactionName": "OtherTransaction/Go/hello",
 		},
 	}})
-	app.ExpectMetrics(t, backgroundErrorMetricsUnknownCaller)
+	app.ExpectMetrics(t, withPanicMetric(backgroundErrorMetricsUnknownCaller))
+}
+
+func TestConfigOnError(t *testing.T) {
+	var infos []ErrorInfo
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.OnError = func(info ErrorInfo) {
+			infos = append(infos, info)
+		}
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(Error{
+		Message:    "my msg",
+		Class:      "my class",
+		Attributes: map[string]interface{}{"zip": "zap"},
+	})
+	txn.End()
+
+	if len(infos) != 1 {
+		t.Fatal(len(infos))
+	}
+	info := infos[0]
+	if info.Message != "my msg" || info.Class != "my class" || info.Expected {
+		t.Error(info.Message, info.Class, info.Expected)
+	}
+	if info.TransactionName != "OtherTransaction/Go/hello" {
+		t.Error(info.TransactionName)
+	}
+	if v, ok := info.GetErrorAttribute("zip"); !ok || v != "zap" {
+		t.Error(v, ok)
+	}
+}
+
+func TestConfigOnErrorScrubbed(t *testing.T) {
+	var infos []ErrorInfo
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.HighSecurity = true
+		cfg.OnError = func(info ErrorInfo) {
+			infos = append(infos, info)
+		}
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(Error{
+		Message:    "my msg",
+		Class:      "my class",
+		Attributes: map[string]interface{}{"zip": "zap"},
+	})
+	txn.End()
+
+	if len(infos) != 1 {
+		t.Fatal(len(infos))
+	}
+	if infos[0].Message != highSecurityErrorMsg {
+		t.Error(infos[0].Message)
+	}
+	if _, ok := infos[0].GetErrorAttribute("zip"); ok {
+		t.Error("attribute not removed")
+	}
+}
+
+func TestConfigOnErrorNotCalledWhenDisabled(t *testing.T) {
+	called := false
+	onError := func(ErrorInfo) { called = true }
+
+	app := testApp(nil, func(cfg *Config) {
+		cfg.ErrorCollector.Enabled = false
+		cfg.OnError = onError
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	txn.End()
+
+	app = testApp(func(reply *internal.ConnectReply) {
+		reply.CollectErrors = false
+	}, func(cfg *Config) {
+		cfg.OnError = onError
+	}, t)
+	txn = app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	txn.End()
+
+	app = testApp(nil, func(cfg *Config) {
+		cfg.ErrorCollector.RecordOnlyClasses = []string{"other class"}
+		cfg.OnError = onError
+	}, t)
+	txn = app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	txn.End()
+
+	if called {
+		t.Error("OnError called for an error which was not collected")
+	}
+}
+
+func TestConfigOnErrorNotCalledPastErrorLimit(t *testing.T) {
+	calls := 0
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.OnError = func(ErrorInfo) { calls++ }
+	}, t)
+	txn := app.StartTransaction("hello")
+	for i := 0; i < maxTxnErrors+2; i++ {
+		txn.NoticeError(myError{})
+	}
+	txn.End()
+	if calls != maxTxnErrors {
+		t.Error(calls)
+	}
+}
+
+func TestConfigOnErrorPanicRecovered(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.OnError = func(ErrorInfo) { panic("oops") }
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	app.expectSingleLoggedError(t, "panic in error callback", map[string]interface{}{
+		"panic": "oops",
+	})
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}})
+}
+
+func TestPreserveErrorMessages(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.HighSecurity = true
+		cfg.AllowPerTxnSecurityOverride = true
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.PreserveErrorMessages()
+	txn.NoticeError(myError{})
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}})
+
+	// Other transactions are still redacted.
+	txn = app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	txn.End()
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+	}, {
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   highSecurityErrorMsg,
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+	}})
+}
+
+func TestPreserveErrorMessagesNotAllowed(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.HighSecurity = true
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.PreserveErrorMessages()
+	app.expectSingleLoggedError(t, "unable to preserve error messages", map[string]interface{}{
+		"reason": errOverrideDisabled.Error(),
+	})
+	txn.NoticeError(myError{})
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     highSecurityErrorMsg,
+		Klass:   "newrelic.myError",
+	}})
+}
+
+func TestPreserveErrorMessagesSecurityPolicy(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		reply.SecurityPolicies.AllowRawExceptionMessages.SetEnabled(false)
+	}
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.AllowPerTxnSecurityOverride = true
+	}
+	app := testApp(replyfn, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.PreserveErrorMessages()
+	txn.NoticeError(myError{})
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     securityPolicyErrorMsg,
+		Klass:   "newrelic.myError",
+	}})
 }
@@ -20,6 +20,9 @@ func (rw *replacementResponseWriter) Header() http.Header {
 }
 
 func (rw *replacementResponseWriter) Write(b []byte) (n int, err error) {
+	if err := rw.thd.checkWriteAfterEnd(); nil != err {
+		return 0, err
+	}
 	hdr := rw.original.Header()
 
 	// This is safe to call unconditionally, even if Write() is called multiple
@@ -37,6 +40,9 @@ func (rw *replacementResponseWriter) Write(b []byte) (n int, err error) {
 }
 
 func (rw *replacementResponseWriter) WriteHeader(code int) {
+	if nil != rw.thd.checkWriteAfterEnd() {
+		return
+	}
 	hdr := rw.original.Header()
 
 	addCrossProcessHeaders(rw.thd.txn, hdr)
@@ -56,6 +62,9 @@ func (rw *replacementResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, erro
 	return rw.original.(http.Hijacker).Hijack()
 }
 func (rw *replacementResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if err := rw.thd.checkWriteAfterEnd(); nil != err {
+		return 0, err
+	}
 	return rw.original.(io.ReaderFrom).ReadFrom(r)
 }
 
@@ -8,7 +8,11 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
 )
 
 type rwNoExtraMethods struct {
@@ -110,3 +114,137 @@ func TestTransactionTwoExtraMethods(t *testing.T) {
 		t.Error("wrong methods called", rw)
 	}
 }
+
+func TestWrapHandleFuncServerSentEvents(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	_, handler := WrapHandleFunc(app.Application, "/events", func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("wrapped ResponseWriter does not implement http.Flusher")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, msg := range []string{"one", "two"} {
+			io.WriteString(w, "data: "+msg+"\n\n")
+			flusher.Flush()
+		}
+	})
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler(rec, req)
+
+	if !rec.Flushed {
+		t.Error("underlying ResponseWriter was not flushed")
+	}
+	if body := rec.Body.String(); body != "data: one\n\ndata: two\n\n" {
+		t.Error(body)
+	}
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/GET /events",
+			"nr.apdexPerfZone": internal.MatchAnything,
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold:         500,
+			AttributeRequestMethod:          "GET",
+			AttributeRequestURI:             "/events",
+			AttributeResponseCode:           200,
+			AttributeResponseCodeDeprecated: 200,
+			AttributeResponseContentType:    "text/event-stream",
+		},
+	}})
+}
+
+func TestWriteAfterEndPassThrough(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	rec := httptest.NewRecorder()
+	w := txn.SetWebResponse(rec)
+	txn.End()
+	if n, err := w.Write([]byte("body")); n != 4 || err != nil {
+		t.Error(n, err)
+	}
+	if body := rec.Body.String(); body != "body" {
+		t.Error(body)
+	}
+	app.ExpectMetrics(t, backgroundMetrics)
+}
+
+func TestWriteAfterEndRecordMetric(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.WritesAfterEnd.RecordMetric = true
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	rec := httptest.NewRecorder()
+	w := txn.SetWebResponse(rec)
+	w.Write([]byte("before"))
+	txn.End()
+	w.Write([]byte("after"))
+	w.Write([]byte("again"))
+	if body := rec.Body.String(); body != "beforeafteragain" {
+		t.Error(body)
+	}
+	app.ExpectMetrics(t, append([]internal.WantMetric{
+		{Name: "Supportability/Go/ResponseWriter/WriteAfterEnd", Scope: "", Forced: true, Data: []float64{2, 0, 0, 0, 0, 0}},
+	}, backgroundMetrics...))
+}
+
+func TestWriteAfterEndConsumedOnce(t *testing.T) {
+	a := &app{
+		dataChan:        make(chan appData, 10),
+		shutdownStarted: make(chan struct{}),
+	}
+	run := &appRun{Reply: &internal.ConnectReply{RunID: "run"}}
+	run.Config.WritesAfterEnd.RecordMetric = true
+	thd := &thread{txn: &txn{app: a, appRun: run, finished: true}}
+
+	for i := 0; i < 3; i++ {
+		if err := thd.checkWriteAfterEnd(); nil != err {
+			t.Error(err)
+		}
+	}
+	if n := len(a.dataChan); n != 1 {
+		t.Fatal("writes after end consumed", n, "times")
+	}
+	h := newHarvest(time.Now(), testHarvestCfgr)
+	(<-a.dataChan).data.MergeIntoHarvest(h)
+	expectMetrics(t, h.Metrics, []internal.WantMetric{
+		{Name: "Supportability/Go/ResponseWriter/WriteAfterEnd", Scope: "", Forced: true, Data: []float64{3, 0, 0, 0, 0, 0}},
+	})
+
+	// Writes after the merge are sent to the next harvest.
+	thd.checkWriteAfterEnd()
+	if n := len(a.dataChan); n != 1 {
+		t.Fatal("writes after end consumed", n, "times")
+	}
+}
+
+func TestWriteAfterEndReturnError(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.WritesAfterEnd.ReturnError = true
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	rec := httptest.NewRecorder()
+	w := txn.SetWebResponse(rec)
+	if _, err := w.Write([]byte("before")); err != nil {
+		t.Error(err)
+	}
+	txn.End()
+	if n, err := w.Write([]byte("after")); n != 0 || err != ErrWriteAfterEnd {
+		t.Error(n, err)
+	}
+	w.WriteHeader(http.StatusTeapot)
+	if body := rec.Body.String(); body != "before" {
+		t.Error(body)
+	}
+	if rec.Code != 200 {
+		t.Error(rec.Code)
+	}
+	app.ExpectMetrics(t, backgroundMetrics)
+}
@@ -4,6 +4,7 @@
 package newrelic
 
 import (
+	"crypto/tls"
 	"net/http"
 	"net/url"
 	"testing"
@@ -74,7 +75,9 @@ func TestSetWebRequestNil(t *testing.T) {
 		{Name: "DurationByCaller/Unknown/Unknown/Unknown/Unknown/allWeb", Scope: "", Forced: false, Data: nil},
 	})
 	app.ExpectTxnEvents(t, []internal.WantEvent{{
-		AgentAttributes: map[string]interface{}{},
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold: 500,
+		},
 		Intrinsics: map[string]interface{}{
 			"name":             "WebTransaction/Go/hello",
 			"guid":             internal.MatchAnything,
@@ -106,7 +109,9 @@ func TestSetWebRequestHTTPNil(t *testing.T) {
 		{Name: "DurationByCaller/Unknown/Unknown/Unknown/Unknown/allWeb", Scope: "", Forced: false, Data: nil},
 	})
 	app.ExpectTxnEvents(t, []internal.WantEvent{{
-		AgentAttributes: map[string]interface{}{},
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold: 500,
+		},
 		Intrinsics: map[string]interface{}{
 			"name":             "WebTransaction/Go/hello",
 			"guid":             internal.MatchAnything,
@@ -125,7 +130,7 @@ func TestSetWebRequestHTTPRequest(t *testing.T) {
 	txn.SetWebRequestHTTP(sampleHTTPRequest)
 	app.expectNoLoggedErrors(t)
 	txn.End()
-	app.ExpectMetrics(t, []internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", []internal.WantMetric{
 		{Name: "WebTransaction/Go/hello", Scope: "", Forced: true, Data: nil},
 		{Name: "WebTransaction", Scope: "", Forced: true, Data: nil},
 		{Name: "WebTransactionTotalTime/Go/hello", Scope: "", Forced: false, Data: nil},
@@ -135,9 +140,11 @@ func TestSetWebRequestHTTPRequest(t *testing.T) {
 		{Name: "Apdex/Go/hello", Scope: "", Forced: false, Data: nil},
 		{Name: "DurationByCaller/Unknown/Unknown/Unknown/HTTP/all", Scope: "", Forced: false, Data: nil},
 		{Name: "DurationByCaller/Unknown/Unknown/Unknown/HTTP/allWeb", Scope: "", Forced: false, Data: nil},
-	})
+	}))
 	app.ExpectTxnEvents(t, []internal.WantEvent{{
-		AgentAttributes: sampleRequestAgentAttributes,
+		AgentAttributes: mergeAttributes(sampleRequestAgentAttributes, map[string]interface{}{
+			AttributeApdexThreshold: 500,
+		}),
 		Intrinsics: map[string]interface{}{
 			"name":             "WebTransaction/Go/hello",
 			"guid":             internal.MatchAnything,
@@ -194,7 +201,7 @@ func TestSetWebRequestWithDistributedTracing(t *testing.T) {
 	txn.SetWebRequest(req)
 	app.expectNoLoggedErrors(t)
 	txn.End()
-	app.ExpectMetrics(t, []internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", []internal.WantMetric{
 		{Name: "WebTransaction/Go/hello", Scope: "", Forced: true, Data: nil},
 		{Name: "WebTransaction", Scope: "", Forced: true, Data: nil},
 		{Name: "WebTransactionTotalTime/Go/hello", Scope: "", Forced: false, Data: nil},
@@ -207,12 +214,13 @@ func TestSetWebRequestWithDistributedTracing(t *testing.T) {
 		{Name: "TransportDuration/App/123/456/HTTP/all", Scope: "", Forced: false, Data: nil},
 		{Name: "TransportDuration/App/123/456/HTTP/allWeb", Scope: "", Forced: false, Data: nil},
 		{Name: "Supportability/TraceContext/Accept/Success", Scope: "", Forced: true, Data: singleCount},
-	})
+	}))
 	app.ExpectTxnEvents(t, []internal.WantEvent{{
 		AgentAttributes: map[string]interface{}{
-			"request.method":       "GET",
-			"request.uri":          "http://www.newrelic.com",
-			"request.headers.host": "myhost",
+			AttributeApdexThreshold: 500,
+			"request.method":        "GET",
+			"request.uri":           "http://www.newrelic.com",
+			"request.headers.host":  "myhost",
 		},
 		Intrinsics: map[string]interface{}{
 			"name":                     "WebTransaction/Go/hello",
@@ -277,7 +285,9 @@ func TestSetWebRequestIncompleteRequest(t *testing.T) {
 		{Name: "DurationByCaller/Unknown/Unknown/Unknown/Unknown/allWeb", Scope: "", Forced: false, Data: nil},
 	})
 	app.ExpectTxnEvents(t, []internal.WantEvent{{
-		AgentAttributes: map[string]interface{}{},
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold: 500,
+		},
 		Intrinsics: map[string]interface{}{
 			"name":             "WebTransaction/Go/hello",
 			"guid":             internal.MatchAnything,
@@ -288,3 +298,232 @@ func TestSetWebRequestIncompleteRequest(t *testing.T) {
 		},
 	}})
 }
+
+func TestSetWebRequestHTTPTLS(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	req, err := http.NewRequest("GET", "https://www.newrelic.com", nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	req.TLS = &tls.ConnectionState{
+		Version:     tls.VersionTLS13,
+		CipherSuite: tls.TLS_AES_128_GCM_SHA256,
+	}
+	txn.SetWebRequestHTTP(req)
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold:        500,
+			AttributeRequestMethod:         "GET",
+			AttributeRequestHost:           "www.newrelic.com",
+			AttributeRequestURI:            "https://www.newrelic.com",
+			AttributeRequestTLSVersion:     "TLS 1.3",
+			AttributeRequestTLSCipherSuite: "TLS_AES_128_GCM_SHA256",
+			AttributeRequestScheme:         "https",
+		},
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": internal.MatchAnything,
+		},
+	}})
+}
+
+func TestSetWebRequestTLSExcluded(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.TransactionEvents.Attributes.Exclude = []string{"request.tls.*"}
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequest(WebRequest{
+		TLS: &tls.ConnectionState{
+			Version:     tls.VersionTLS12,
+			CipherSuite: tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		},
+	})
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold: 500,
+			AttributeRequestScheme:  "https",
+		},
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": internal.MatchAnything,
+		},
+	}})
+}
+
+func TestSetWebRequestHTTPForwardedScheme(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	req, err := http.NewRequest("GET", "http://www.newrelic.com", nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Forwarded-Proto", "https")
+	txn.SetWebRequestHTTP(req)
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold: 500,
+			AttributeRequestMethod:  "GET",
+			AttributeRequestHost:    "www.newrelic.com",
+			AttributeRequestURI:     "http://www.newrelic.com",
+			AttributeRequestScheme:  "https",
+		},
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": internal.MatchAnything,
+		},
+	}})
+}
+
+func TestSetWebRequestHTTPClientRegion(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ClientRegionHeader = "CloudFront-Viewer-Country"
+	}, t)
+	txn := app.StartTransaction("hello")
+	req, err := http.NewRequest("GET", "http://www.newrelic.com", nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	req.Header.Set("CloudFront-Viewer-Country", "NZ")
+	txn.SetWebRequestHTTP(req)
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold:      500,
+			AttributeRequestMethod:       "GET",
+			AttributeRequestHost:         "www.newrelic.com",
+			AttributeRequestURI:          "http://www.newrelic.com",
+			AttributeRequestClientRegion: "NZ",
+		},
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": internal.MatchAnything,
+		},
+	}})
+}
+
+func TestRequestClientRegionAttribute(t *testing.T) {
+	testcases := []struct {
+		header string
+		value  string
+		expect interface{}
+	}{
+		{header: "CloudFront-Viewer-Country", value: "US", expect: "US"},
+		{header: "CloudFront-Viewer-Country", value: " DE ", expect: "DE"},
+		{header: "CloudFront-Viewer-Country", value: "", expect: nil},
+		{header: "CloudFront-Viewer-Country", value: "   ", expect: nil},
+		{header: "", value: "US", expect: nil},
+	}
+	cfg := createAttributeConfig(config{Config: defaultConfig()}, true)
+	for _, tc := range testcases {
+		hdrs := http.Header{}
+		if tc.value != "" {
+			hdrs.Set("CloudFront-Viewer-Country", tc.value)
+		}
+		attrs := newAttributes(cfg)
+		requestClientRegionAttribute(attrs, hdrs, tc.header)
+		region := agentAttributesMap(attrs, destAll)[AttributeRequestClientRegion]
+		if region != tc.expect {
+			t.Errorf("header %q, value %q: got %v, want %v", tc.header, tc.value, region, tc.expect)
+		}
+	}
+	requestClientRegionAttribute(newAttributes(cfg), nil, "CloudFront-Viewer-Country")
+}
+
+func TestRequestSchemeAttribute(t *testing.T) {
+	testcases := []struct {
+		forwarded string
+		tls       bool
+		expect    interface{}
+	}{
+		{forwarded: "", tls: false, expect: nil},
+		{forwarded: "", tls: true, expect: "https"},
+		{forwarded: "http", tls: true, expect: "http"},
+		{forwarded: "HTTPS", tls: false, expect: "https"},
+		{forwarded: "https, http", tls: false, expect: "https"},
+		{forwarded: "gopher", tls: true, expect: nil},
+	}
+	cfg := createAttributeConfig(config{Config: defaultConfig()}, true)
+	for _, tc := range testcases {
+		hdrs := http.Header{}
+		if tc.forwarded != "" {
+			hdrs.Set("X-Forwarded-Proto", tc.forwarded)
+		}
+		var state *tls.ConnectionState
+		if tc.tls {
+			state = &tls.ConnectionState{}
+		}
+		attrs := newAttributes(cfg)
+		requestSchemeAttribute(attrs, hdrs, state)
+		scheme := agentAttributesMap(attrs, destAll)[AttributeRequestScheme]
+		if scheme != tc.expect {
+			t.Errorf("X-Forwarded-Proto %q, tls %v: got %v, want %v", tc.forwarded, tc.tls, scheme, tc.expect)
+		}
+	}
+}
+
+func TestTLSVersionName(t *testing.T) {
+	if name := tlsVersionName(tls.VersionTLS12); name != "TLS 1.2" {
+		t.Error(name)
+	}
+	if name := tlsVersionName(0x0305); name != "0x0305" {
+		t.Error(name)
+	}
+}
+
+func TestHTTPMethodMetric(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequest(WebRequest{Method: "post", Transport: TransportHTTP})
+	txn.End()
+	app.ExpectMetrics(t, withHTTPMethodMetric("POST", webMetrics))
+}
+
+func TestHTTPMethodMetricNoMethod(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequest(WebRequest{Transport: TransportHTTP})
+	txn.End()
+	app.ExpectMetrics(t, webMetrics)
+}
+
+func TestRequestMethodMetricSuffix(t *testing.T) {
+	for method, suffix := range map[string]string{
+		"":        "",
+		"GET":     "GET",
+		"delete":  "DELETE",
+		"OPTIONS": "OPTIONS",
+		"PURGE":   "Other",
+	} {
+		if s := requestMethodMetricSuffix(method); s != suffix {
+			t.Errorf("method %q: expected %q got %q", method, suffix, s)
+		}
+	}
+}
+
+func TestHTTPMethodMetricWithoutAttributes(t *testing.T) {
+	excludeMethod := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.Attributes.Exclude = []string{AttributeRequestMethod}
+	}
+	app := testApp(nil, excludeMethod, t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(helloRequest)
+	txn.End()
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webMetrics))
+
+	app = testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn = app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(helloRequest)
+	txn.DisableAttributes()
+	txn.End()
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webMetrics))
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/newrelic/go-agent/v3/internal"
 )
@@ -86,3 +87,104 @@ func TestSetWebResponseTxnUpgraded(t *testing.T) {
 		t.Error("should have Flusher now")
 	}
 }
+
+func TestResponseClassMetric(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(helloRequest)
+	rw := txn.SetWebResponse(httptest.NewRecorder())
+	rw.WriteHeader(302)
+	txn.End()
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", withResponseClassMetric("3xx", webMetrics)))
+}
+
+func TestResponseClassMetricBackground(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	rw := txn.SetWebResponse(httptest.NewRecorder())
+	rw.WriteHeader(200)
+	txn.End()
+	app.ExpectMetrics(t, backgroundMetrics)
+}
+
+func TestResponseCodeClass(t *testing.T) {
+	for code, class := range map[int]string{
+		0:   "",
+		99:  "",
+		100: "1xx",
+		200: "2xx",
+		299: "2xx",
+		301: "3xx",
+		404: "4xx",
+		503: "5xx",
+		600: "",
+	} {
+		if c := responseCodeClass(code); c != class {
+			t.Errorf("code %d: expected %q got %q", code, class, c)
+		}
+	}
+}
+
+func TestResponseRetryAfter(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	rw := txn.SetWebResponse(httptest.NewRecorder())
+	rw.Header().Set("Retry-After", "120")
+	rw.WriteHeader(429)
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		AgentAttributes: map[string]interface{}{
+			"httpResponseCode":     "429",
+			"http.statusCode":      429,
+			"response.retryAfter":  120,
+			"totalErrors":          1,
+			"distinctErrorClasses": 1,
+		},
+		Intrinsics: map[string]interface{}{"name": "OtherTransaction/Go/hello"},
+	}})
+}
+
+func TestResponseRetryAfterAttribute(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		code   int
+		header string
+		want   interface{}
+	}{
+		{code: 429, header: "120", want: int64(120)},
+		{code: 503, header: " 5 ", want: int64(5)},
+		{code: 503, header: "Wed, 01 Jan 2020 12:01:30 GMT", want: int64(90)},
+		{code: 503, header: "Wed, 01 Jan 2020 11:00:00 GMT", want: int64(0)},
+		{code: 429, header: "-1", want: nil},
+		{code: 429, header: "soon", want: nil},
+		{code: 429, header: "", want: nil},
+		{code: 200, header: "120", want: nil},
+		{code: 500, header: "120", want: nil},
+	} {
+		attrs := newAttributes(createAttributeConfig(config{Config: defaultConfig()}, true))
+		hdr := http.Header{}
+		if tc.header != "" {
+			hdr.Set("Retry-After", tc.header)
+		}
+		responseRetryAfterAttribute(attrs, tc.code, hdr, now)
+		if _, v := attrs.GetAgentValue(AttributeResponseRetryAfter, destTxnEvent); v != tc.want {
+			t.Errorf("code=%d header=%q: expected %v got %v", tc.code, tc.header, tc.want, v)
+		}
+	}
+}
+
+func TestResponseRetryAfterAttributeUsesClock(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	defer func(orig func() time.Time) { timeNow = orig }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	rw := txn.SetWebResponse(httptest.NewRecorder())
+	rw.Header().Set("Retry-After", "Wed, 01 Jan 2020 12:01:30 GMT")
+	rw.WriteHeader(http.StatusServiceUnavailable)
+	if _, v := txn.thread.Attrs.GetAgentValue(AttributeResponseRetryAfter, destTxnEvent); v != int64(90) {
+		t.Error(v)
+	}
+	txn.End()
+}
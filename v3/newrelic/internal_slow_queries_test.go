@@ -170,7 +170,7 @@ func TestSlowQueryHostProvided(t *testing.T) {
 		PortPathOrID: "unknown",
 	}})
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "Datastore/all", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/MySQL/all", Scope: "", Forced: true, Data: nil},
@@ -179,7 +179,7 @@ func TestSlowQueryHostProvided(t *testing.T) {
 		{Name: "Datastore/statement/MySQL/users/INSERT", Scope: "", Forced: false, Data: nil},
 		{Name: "Datastore/statement/MySQL/users/INSERT", Scope: scope, Forced: false, Data: nil},
 		{Name: "Datastore/instance/MySQL/db-server-1/unknown", Scope: "", Forced: false, Data: nil},
-	}, webMetrics...))
+	}, webMetrics...)))
 }
 
 func TestSlowQueryPortProvided(t *testing.T) {
@@ -212,7 +212,7 @@ func TestSlowQueryPortProvided(t *testing.T) {
 		PortPathOrID: "98021",
 	}})
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "Datastore/all", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/MySQL/all", Scope: "", Forced: true, Data: nil},
@@ -221,7 +221,7 @@ func TestSlowQueryPortProvided(t *testing.T) {
 		{Name: "Datastore/statement/MySQL/users/INSERT", Scope: "", Forced: false, Data: nil},
 		{Name: "Datastore/statement/MySQL/users/INSERT", Scope: scope, Forced: false, Data: nil},
 		{Name: "Datastore/instance/MySQL/unknown/98021", Scope: "", Forced: false, Data: nil},
-	}, webMetrics...))
+	}, webMetrics...)))
 }
 
 func TestSlowQueryHostPortProvided(t *testing.T) {
@@ -255,7 +255,7 @@ func TestSlowQueryHostPortProvided(t *testing.T) {
 		PortPathOrID: "98021",
 	}})
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "Datastore/all", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/MySQL/all", Scope: "", Forced: true, Data: nil},
@@ -264,7 +264,7 @@ func TestSlowQueryHostPortProvided(t *testing.T) {
 		{Name: "Datastore/statement/MySQL/users/INSERT", Scope: "", Forced: false, Data: nil},
 		{Name: "Datastore/statement/MySQL/users/INSERT", Scope: scope, Forced: false, Data: nil},
 		{Name: "Datastore/instance/MySQL/db-server-1/98021", Scope: "", Forced: false, Data: nil},
-	}, webMetrics...))
+	}, webMetrics...)))
 }
 
 func TestSlowQueryAggregation(t *testing.T) {
@@ -377,14 +377,14 @@ func TestSlowQueryMissingEverything(t *testing.T) {
 		PortPathOrID: "",
 	}})
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "Datastore/all", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/Unknown/all", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/Unknown/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/operation/Unknown/other", Scope: "", Forced: false, Data: nil},
 		{Name: "Datastore/operation/Unknown/other", Scope: scope, Forced: false, Data: nil},
-	}, webMetrics...))
+	}, webMetrics...)))
 }
 
 func TestSlowQueryWithQueryParameters(t *testing.T) {
@@ -653,7 +653,7 @@ func TestSlowQueryInstanceDisabled(t *testing.T) {
 		PortPathOrID: "",
 	}})
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "Datastore/all", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/MySQL/all", Scope: "", Forced: true, Data: nil},
@@ -661,7 +661,7 @@ func TestSlowQueryInstanceDisabled(t *testing.T) {
 		{Name: "Datastore/operation/MySQL/INSERT", Scope: "", Forced: false, Data: nil},
 		{Name: "Datastore/statement/MySQL/users/INSERT", Scope: "", Forced: false, Data: nil},
 		{Name: "Datastore/statement/MySQL/users/INSERT", Scope: scope, Forced: false, Data: nil},
-	}, webMetrics...))
+	}, webMetrics...)))
 }
 
 func TestSlowQueryInstanceDisabledLocalhost(t *testing.T) {
@@ -696,7 +696,7 @@ func TestSlowQueryInstanceDisabledLocalhost(t *testing.T) {
 		PortPathOrID: "",
 	}})
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "Datastore/all", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/MySQL/all", Scope: "", Forced: true, Data: nil},
@@ -704,7 +704,7 @@ func TestSlowQueryInstanceDisabledLocalhost(t *testing.T) {
 		{Name: "Datastore/operation/MySQL/INSERT", Scope: "", Forced: false, Data: nil},
 		{Name: "Datastore/statement/MySQL/users/INSERT", Scope: "", Forced: false, Data: nil},
 		{Name: "Datastore/statement/MySQL/users/INSERT", Scope: scope, Forced: false, Data: nil},
-	}, webMetrics...))
+	}, webMetrics...)))
 }
 
 func TestSlowQueryDatabaseNameDisabled(t *testing.T) {
@@ -809,7 +809,7 @@ func TestDatastoreAPICrossAgent(t *testing.T) {
 		var scope string
 		if tc.Input.IsWeb {
 			scope = "WebTransaction/Go/hello"
-			metrics = append([]internal.WantMetric{}, webMetrics...)
+			metrics = withHTTPMethodMetric("GET", webMetrics)
 		} else {
 			scope = "OtherTransaction/Go/hello"
 			metrics = append([]internal.WantMetric{}, backgroundMetrics...)
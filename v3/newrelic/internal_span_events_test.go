@@ -184,6 +184,85 @@ func TestSpanEventDatastoreExternal(t *testing.T) {
 	})
 }
 
+func TestSpanEventDatastoreRowCounts(t *testing.T) {
+	// Test that row counts are added to datastore span events, and that
+	// unknown (negative) row counts are omitted.
+	replyfn := func(reply *internal.ConnectReply) {
+		reply.SetSampleEverything()
+	}
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = true
+	}
+	app := testApp(replyfn, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	affected := int64(3)
+	returned := int64(-1)
+	segment := DatastoreSegment{
+		StartTime:          txn.StartSegmentNow(),
+		Product:            DatastoreMySQL,
+		Collection:         "mycollection",
+		Operation:          "myoperation",
+		ParameterizedQuery: "myquery",
+		RowsAffected:       &affected,
+		RowsReturned:       &returned,
+	}
+	segment.End()
+	returned = 0
+	segment = DatastoreSegment{
+		StartTime:          txn.StartSegmentNow(),
+		Product:            DatastoreMySQL,
+		Collection:         "mycollection",
+		Operation:          "myoperation",
+		ParameterizedQuery: "myquery",
+		RowsReturned:       &returned,
+	}
+	segment.End()
+	txn.End()
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"parentId":  internal.MatchAnything,
+				"name":      "Datastore/statement/MySQL/mycollection/myoperation",
+				"category":  "datastore",
+				"component": "MySQL",
+				"span.kind": "client",
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"db.statement":    "myquery",
+				"db.collection":   "mycollection",
+				"db.rowsAffected": 3,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"parentId":  internal.MatchAnything,
+				"name":      "Datastore/statement/MySQL/mycollection/myoperation",
+				"category":  "datastore",
+				"component": "MySQL",
+				"span.kind": "client",
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"db.statement":    "myquery",
+				"db.collection":   "mycollection",
+				"db.rowsReturned": 0,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/hello",
+				"transaction.name": "OtherTransaction/Go/hello",
+				"sampled":          true,
+				"category":         "generic",
+				"nr.entryPoint":    true,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+		},
+	})
+}
+
 func TestSpanEventAttributesDisabled(t *testing.T) {
 	// Test that SpanEvents.Attributes.Enabled correctly disables span
 	// attributes.
@@ -422,8 +501,10 @@ func TestSpanAttributesFromTxnExcludedOnTxn(t *testing.T) {
 				"traceId":          internal.MatchAnything,
 				"priority":         internal.MatchAnything,
 			},
-			UserAttributes:  map[string]interface{}{},
-			AgentAttributes: map[string]interface{}{},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				AttributeApdexThreshold: 500,
+			},
 		},
 	})
 	app.ExpectSpanEvents(t, []internal.WantEvent{
@@ -472,9 +553,10 @@ func TestSpanAttributesFromTxnExcludedByDefault(t *testing.T) {
 			},
 			UserAttributes: map[string]interface{}{},
 			AgentAttributes: map[string]interface{}{
-				"request.method":       "GET",
-				"request.uri":          "http://example.com",
-				"request.headers.host": "example.com",
+				AttributeApdexThreshold: 500,
+				"request.method":        "GET",
+				"request.uri":           "http://example.com",
+				"request.headers.host":  "example.com",
 			},
 		},
 	})
@@ -518,9 +600,10 @@ func TestSpanAttributesFromTxnExcludedByDefault(t *testing.T) {
 			},
 			UserAttributes: map[string]interface{}{},
 			AgentAttributes: map[string]interface{}{
-				"request.method":       "GET",
-				"request.uri":          "http://example.com",
-				"request.headers.host": "example.com",
+				AttributeApdexThreshold: 500,
+				"request.method":        "GET",
+				"request.uri":           "http://example.com",
+				"request.headers.host":  "example.com",
 			},
 		},
 	})
@@ -575,9 +658,10 @@ func TestSpanAttributesFromTxnExcludedOnSpan(t *testing.T) {
 			},
 			UserAttributes: map[string]interface{}{},
 			AgentAttributes: map[string]interface{}{
-				"request.method":       "GET",
-				"request.uri":          "http://example.com",
-				"request.headers.host": "example.com",
+				AttributeApdexThreshold: 500,
+				"request.method":        "GET",
+				"request.uri":           "http://example.com",
+				"request.headers.host":  "example.com",
 			},
 		},
 	})
@@ -625,8 +709,10 @@ func TestSpanAttributesFromTxnExcludedGlobally(t *testing.T) {
 				"traceId":          internal.MatchAnything,
 				"priority":         internal.MatchAnything,
 			},
-			UserAttributes:  map[string]interface{}{},
-			AgentAttributes: map[string]interface{}{},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				AttributeApdexThreshold: 500,
+			},
 		},
 	})
 	app.ExpectSpanEvents(t, []internal.WantEvent{
@@ -893,6 +979,52 @@ func TestSpanEventHTTPStatusCode(t *testing.T) {
 	})
 }
 
+func TestSpanEventAttributeFilter(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, func(cfg *Config) {
+		enableBetterCAT(cfg)
+		cfg.AttributeFilter = func(key string, val interface{}, dest AttributeDestination) bool {
+			return val != 13 || dest != AttributeDestinationSpanEvents
+		}
+	}, t)
+	txn := app.StartTransaction("hello")
+	s := ExternalSegment{
+		StartTime: txn.StartSegmentNow(),
+		URL:       "http://example.com/",
+		Procedure: "GET",
+	}
+	s.SetStatusCode(13)
+	s.End()
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"parentId":  internal.MatchAnything,
+				"name":      "External/example.com/http/GET",
+				"category":  "http",
+				"component": "http",
+				"span.kind": "client",
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"http.url":    "http://example.com/",
+				"http.method": "GET",
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/hello",
+				"transaction.name": "OtherTransaction/Go/hello",
+				"sampled":          true,
+				"category":         "generic",
+				"nr.entryPoint":    true,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+		},
+	})
+}
+
 func TestSpanEvent_TxnCustomAttrsAreCopied(t *testing.T) {
 	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
 	txn := app.StartTransaction("hello")
@@ -191,3 +191,57 @@ func TestSyntheticsStandalone(t *testing.T) {
 		Intrinsics: expectedIntrinsics,
 	}})
 }
+
+func TestSyntheticsAgentAttributes(t *testing.T) {
+	app := testApp(syntheticsConnectReplyFn, nil, t)
+	txn := app.StartTransaction("helloSynthetics")
+	txn.SetWebRequestHTTP(inboundSyntheticsRequestBuilder(false, false))
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold:       500,
+			"request.method":              "GET",
+			"request.uri":                 "newrelic.com",
+			AttributeSyntheticsResourceID: "rrrrrrr-rrrr-1234-rrrr-rrrrrrrrrrrr",
+			AttributeSyntheticsJobID:      "jjjjjjj-jjjj-1234-jjjj-jjjjjjjjjjjj",
+			AttributeSyntheticsMonitorID:  "mmmmmmm-mmmm-1234-mmmm-mmmmmmmmmmmm",
+		},
+	}})
+}
+
+func TestSyntheticsAgentAttributesHighSecurity(t *testing.T) {
+	cfgFn := func(cfg *Config) { cfg.HighSecurity = true }
+	app := testApp(syntheticsConnectReplyFn, cfgFn, t)
+	txn := app.StartTransaction("helloSynthetics")
+	txn.SetWebRequestHTTP(inboundSyntheticsRequestBuilder(false, false))
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold: 500,
+			"request.method":        "GET",
+			"request.uri":           "newrelic.com",
+		},
+	}})
+}
+
+func TestSyntheticsAgentAttributesMalformedHeader(t *testing.T) {
+	app := testApp(syntheticsConnectReplyFn, nil, t)
+	txn := app.StartTransaction("helloSynthetics")
+	req, err := http.NewRequest("GET", "newrelic.com", nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	req.Header.Add("X-NewRelic-Synthetics", "not-a-valid-header")
+	txn.SetWebRequestHTTP(req)
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold: 500,
+			"request.method":        "GET",
+			"request.uri":           "newrelic.com",
+		},
+	}})
+}
@@ -366,6 +366,18 @@ func TestRecordCustomMetricSuccess(t *testing.T) {
 	})
 }
 
+func TestRecordCustomMetricPrefix(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.CustomMetricPrefix = "Team/"
+	}, t)
+	app.RecordCustomMetric("myMetric", 123.0)
+	app.expectNoLoggedErrors(t)
+	expectData := []float64{1, 123.0, 123.0, 123.0, 123.0, 123.0 * 123.0}
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "Team/myMetric", Scope: "", Forced: false, Data: expectData},
+	})
+}
+
 func TestRecordCustomMetricNameEmpty(t *testing.T) {
 	app := testApp(nil, nil, t)
 	app.RecordCustomMetric("", 123.0)
@@ -402,6 +414,50 @@ func TestRecordCustomMetricNegativeInf(t *testing.T) {
 	})
 }
 
+func TestRecordScopedMetric(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.RecordScopedMetric("myMetric", 2)
+	txn.RecordScopedMetric("myMetric", 1)
+	txn.RecordScopedMetric("", 1)
+	app.expectSingleLoggedError(t, "unable to record scoped metric", map[string]interface{}{
+		"metric-name": "",
+		"reason":      errMetricNameEmpty.Error(),
+	})
+	txn.RecordScopedMetric("myMetric", math.NaN())
+	app.expectSingleLoggedError(t, "unable to record scoped metric", map[string]interface{}{
+		"metric-name": "myMetric",
+		"reason":      errMetricNaN.Error(),
+	})
+	txn.End()
+	txn.RecordScopedMetric("myMetric", 1)
+	app.expectSingleLoggedError(t, "unable to record scoped metric", map[string]interface{}{
+		"metric-name": "myMetric",
+		"reason":      errAlreadyEnded.Error(),
+	})
+	expectData := []float64{2, 3, 3, 1, 2, 5}
+	app.ExpectMetrics(t, append([]internal.WantMetric{
+		{Name: "Custom/myMetric", Scope: "", Forced: false, Data: expectData},
+		{Name: "Custom/myMetric", Scope: "OtherTransaction/Go/hello", Forced: false, Data: expectData},
+	}, backgroundMetrics...))
+}
+
+func TestRecordScopedMetricPrefix(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.CustomMetricPrefix = "Team/"
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.RecordScopedMetric("myMetric", 2)
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	expectData := []float64{1, 2, 2, 2, 2, 4}
+	app.ExpectMetrics(t, append([]internal.WantMetric{
+		{Name: "Team/myMetric", Scope: "", Forced: false, Data: expectData},
+		{Name: "Team/myMetric", Scope: "OtherTransaction/Go/hello", Forced: false, Data: expectData},
+	}, backgroundMetrics...))
+}
+
 type sampleResponseWriter struct {
 	code    int
 	written int
@@ -461,6 +517,80 @@ func TestTransactionEventBackground(t *testing.T) {
 	}})
 }
 
+func TestTransactionEventGoroutineCount(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.CaptureGoroutineCountThreshold = time.Nanosecond
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	time.Sleep(time.Millisecond)
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeGoroutineCount: internal.MatchAnything,
+		},
+	}})
+}
+
+func TestTransactionEventGoroutineCountBelowThreshold(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.CaptureGoroutineCountThreshold = time.Hour
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestTransactionEventHeapAlloc(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.CaptureMemStatsThreshold = time.Nanosecond
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	time.Sleep(time.Millisecond)
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeHeapAlloc: internal.MatchAnything,
+		},
+	}})
+}
+
+func TestTransactionEventHeapAllocBelowThreshold(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.CaptureMemStatsThreshold = time.Hour
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+	}})
+}
+
 func TestTransactionEventLocallyDisabled(t *testing.T) {
 	cfgFn := func(cfg *Config) {
 		cfg.TransactionEvents.Enabled = false
@@ -474,6 +604,28 @@ func TestTransactionEventLocallyDisabled(t *testing.T) {
 	app.ExpectTxnEvents(t, []internal.WantEvent{})
 }
 
+func TestTransactionEventSampleRate(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.TransactionEvents.SampleRate = 0.0
+		cfg.DistributedTracer.Enabled = false
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(helloRequest)
+	txn.NoticeError(myError{})
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "WebTransaction/Go/hello",
+		},
+	}})
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webErrorMetrics))
+}
+
 func TestTransactionEventRemotelyDisabled(t *testing.T) {
 	replyfn := func(reply *internal.ConnectReply) { reply.CollectAnalyticsEvents = false }
 	app := testApp(replyfn, ConfigDistributedTracerEnabled(false), t)
@@ -497,6 +649,346 @@ func TestSetName(t *testing.T) {
 	app.ExpectMetrics(t, backgroundMetrics)
 }
 
+func TestClearName(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("one")
+	txn.SetName("hello")
+	txn.ClearName()
+	if name := txn.Name(); name != "" {
+		t.Error(name)
+	}
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	txn.ClearName()
+	app.expectSingleLoggedError(t, "unable to clear transaction name", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/",
+		},
+	}})
+}
+
+func TestTransactionNameCallback(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.RecordPanics = true
+		cfg.TransactionNameCallback = func(name string, failed bool) string {
+			if failed {
+				return name + "-failed"
+			}
+			return name
+		}
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("ok")
+	txn.End()
+	txn = app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	txn.End()
+	txn = app.StartTransaction("expected")
+	txn.NoticeExpectedError(myError{})
+	txn.End()
+	txn = app.StartTransaction("panic")
+	if r := deferEndPanic(txn, "oops"); r != "oops" {
+		t.Error("panic not propagated", r)
+	}
+	app.ExpectTxnEvents(t, []internal.WantEvent{
+		{Intrinsics: map[string]interface{}{"name": "OtherTransaction/Go/ok"}},
+		{Intrinsics: map[string]interface{}{"name": "OtherTransaction/Go/hello-failed", "error": true}},
+		{Intrinsics: map[string]interface{}{"name": "OtherTransaction/Go/expected", "error": true}},
+		{Intrinsics: map[string]interface{}{"name": "OtherTransaction/Go/panic-failed", "error": true}},
+	})
+}
+
+func TestTransactionTypeResolver(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.TransactionTypeResolver = func(r *http.Request) TransactionType {
+			switch r.Header.Get("X-Type") {
+			case "web":
+				return TransactionTypeWeb
+			case "background":
+				return TransactionTypeBackground
+			case "rpc":
+				return TransactionTypeRPC
+			}
+			return TransactionTypeDefault
+		}
+	}
+	app := testApp(nil, cfgfn, t)
+	for _, typ := range []string{"default", "web", "background", "rpc"} {
+		r, err := http.NewRequest("POST", "/resolver", nil)
+		if nil != err {
+			t.Fatal(err)
+		}
+		r.Header.Set("X-Type", typ)
+		txn := app.StartTransaction(typ)
+		txn.SetWebRequestHTTP(r)
+		txn.End()
+	}
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{
+		{Intrinsics: map[string]interface{}{"name": "WebTransaction/Go/default", "nr.apdexPerfZone": internal.MatchAnything}},
+		{Intrinsics: map[string]interface{}{"name": "WebTransaction/Go/web", "nr.apdexPerfZone": internal.MatchAnything}},
+		{Intrinsics: map[string]interface{}{"name": "OtherTransaction/Go/background"}},
+		{Intrinsics: map[string]interface{}{"name": "OtherTransaction/Go/rpc"}},
+	})
+}
+
+func TestTransactionTypeResolverRPCRequest(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.TransactionTypeResolver = func(r *http.Request) TransactionType {
+			if r.Header.Get("X-Type") == "rpc" {
+				return TransactionTypeRPC
+			}
+			return TransactionTypeBackground
+		}
+	}
+	app := testApp(nil, cfgfn, t)
+	for _, typ := range []string{"background", "rpc"} {
+		r, err := http.NewRequest("POST", "/resolver", nil)
+		if nil != err {
+			t.Fatal(err)
+		}
+		r.Header.Set("X-Type", typ)
+		r.Header.Set("Content-Type", "application/grpc")
+		txn := app.StartTransaction(typ)
+		txn.SetWebRequestHTTP(r)
+		txn.NoticeError(myError{})
+		txn.End()
+	}
+	app.expectNoLoggedErrors(t)
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/background",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+		AgentAttributes: map[string]interface{}{
+			AttributeRequestMethod:      "POST",
+			AttributeRequestURI:         "/resolver",
+			AttributeRequestContentType: "application/grpc",
+		},
+	}, {
+		TxnName: "OtherTransaction/Go/rpc",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+		AgentAttributes: map[string]interface{}{
+			AttributeRequestContentType: "application/grpc",
+		},
+	}})
+}
+
+func TestTransactionTypeResolverPanicRecovered(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.TransactionTypeResolver = func(r *http.Request) TransactionType {
+			panic("oops")
+		}
+	}
+	app := testApp(nil, cfgfn, t)
+	r, err := http.NewRequest("GET", "/resolver", nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(r)
+	app.expectSingleLoggedError(t, "panic in transaction type resolver", map[string]interface{}{
+		"panic": "oops",
+	})
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{
+		{Intrinsics: map[string]interface{}{"name": "WebTransaction/Go/hello", "nr.apdexPerfZone": internal.MatchAnything}},
+	})
+}
+
+func TestTransactionTypeResolverNotCalledWithoutRequest(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.TransactionTypeResolver = func(r *http.Request) TransactionType {
+			t.Error("resolver should not be called", r)
+			return TransactionTypeBackground
+		}
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequest(WebRequest{Method: "GET"})
+	txn.End()
+	txn = app.StartTransaction("nil")
+	txn.SetWebRequestHTTP(nil)
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{
+		{Intrinsics: map[string]interface{}{"name": "WebTransaction/Go/hello", "nr.apdexPerfZone": internal.MatchAnything}},
+		{Intrinsics: map[string]interface{}{"name": "WebTransaction/Go/nil", "nr.apdexPerfZone": internal.MatchAnything}},
+	})
+}
+
+func TestApdexThreshold(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		reply.ApdexThresholdSeconds = 0.5
+		reply.KeyTxnApdex = map[string]float64{"WebTransaction/Go/key": 0.1}
+	}
+	app := testApp(replyfn, nil, t)
+
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(helloRequest)
+	if threshold := txn.ApdexThreshold(); threshold != 0 {
+		t.Error("threshold should be zero before End", threshold)
+	}
+	if s, tol := txn.ApdexThresholds(); s != 0 || tol != 0 {
+		t.Error("thresholds should be zero before End", s, tol)
+	}
+	txn.End()
+	if threshold := txn.ApdexThreshold(); threshold != 500*time.Millisecond {
+		t.Error(threshold)
+	}
+	if s, tol := txn.ApdexThresholds(); s != 500*time.Millisecond || tol != 2*time.Second {
+		t.Error(s, tol)
+	}
+
+	txn = app.StartTransaction("key")
+	txn.SetWebRequestHTTP(helloRequest)
+	txn.End()
+	if threshold := txn.ApdexThreshold(); threshold != 100*time.Millisecond {
+		t.Error(threshold)
+	}
+	if s, tol := txn.ApdexThresholds(); s != 100*time.Millisecond || tol != 400*time.Millisecond {
+		t.Error(s, tol)
+	}
+
+	txn = app.StartTransaction("background")
+	txn.End()
+	if threshold := txn.ApdexThreshold(); threshold != 0 {
+		t.Error("non-web transactions should have no threshold", threshold)
+	}
+}
+
+func TestCurrentApdexZone(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		reply.ApdexThresholdSeconds = 10
+		reply.KeyTxnApdex = map[string]float64{"WebTransaction/Go/key": 0.000001}
+	}
+	app := testApp(replyfn, nil, t)
+
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(helloRequest)
+	if zone := txn.CurrentApdexZone(); zone != ApdexSatisfying {
+		t.Error(zone)
+	}
+	txn.NoticeError(myError{})
+	if zone := txn.CurrentApdexZone(); zone != ApdexFailing {
+		t.Error(zone)
+	}
+	txn.End()
+	if zone := txn.CurrentApdexZone(); zone != ApdexFailing {
+		t.Error(zone)
+	}
+
+	txn = app.StartTransaction("key")
+	txn.SetWebRequestHTTP(helloRequest)
+	time.Sleep(time.Millisecond)
+	if zone := txn.CurrentApdexZone(); zone != ApdexFailing {
+		t.Error(zone)
+	}
+	txn.End()
+
+	txn = app.StartTransaction("background")
+	if zone := txn.CurrentApdexZone(); zone != ApdexNone {
+		t.Error(zone)
+	}
+	txn.End()
+}
+
+func TestApdexIgnoreTransactions(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.Apdex.IgnoreTransactions = []string{"WebTransaction/Go/health*"}
+	}, t)
+	txn := app.StartTransaction("healthz")
+	txn.SetWebRequestHTTP(nil)
+	if zone := txn.CurrentApdexZone(); zone != ApdexNone {
+		t.Error(zone)
+	}
+	txn.End()
+	if zone := txn.CurrentApdexZone(); zone != ApdexNone {
+		t.Error(zone)
+	}
+
+	txn = app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(nil)
+	txn.End()
+	if zone := txn.CurrentApdexZone(); zone != ApdexSatisfying {
+		t.Error(zone)
+	}
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "WebTransaction/Go/healthz",
+		},
+	}, {
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "S",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold: 500,
+		},
+	}})
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "WebTransaction/Go/healthz", Scope: "", Forced: true, Data: nil},
+		{Name: "WebTransaction/Go/hello", Scope: "", Forced: true, Data: nil},
+		{Name: "WebTransaction", Scope: "", Forced: true, Data: nil},
+		{Name: "WebTransactionTotalTime/Go/healthz", Scope: "", Forced: false, Data: nil},
+		{Name: "WebTransactionTotalTime/Go/hello", Scope: "", Forced: false, Data: nil},
+		{Name: "WebTransactionTotalTime", Scope: "", Forced: true, Data: nil},
+		{Name: "HttpDispatcher", Scope: "", Forced: true, Data: nil},
+		{Name: "Apdex", Scope: "", Forced: true, Data: []float64{1, 0, 0, 0.5, 0.5, 0}},
+		{Name: "Apdex/Go/hello", Scope: "", Forced: false, Data: []float64{1, 0, 0, 0.5, 0.5, 0}},
+	})
+}
+
+func TestElapsed(t *testing.T) {
+	app := testApp(nil, nil, t)
+	txn := app.StartTransaction("hello")
+	time.Sleep(time.Millisecond)
+	before := txn.Elapsed()
+	if before < time.Millisecond {
+		t.Error(before)
+	}
+	txn.End()
+	final := txn.Elapsed()
+	if final < before {
+		t.Error(final, before)
+	}
+	time.Sleep(time.Millisecond)
+	if d := txn.Elapsed(); d != final {
+		t.Error(d, final)
+	}
+}
+
+func TestTimeNowReplaced(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	defer func(orig func() time.Time) { timeNow = orig }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	now = now.Add(2 * time.Second)
+	if d := txn.Elapsed(); d != 2*time.Second {
+		t.Error(d)
+	}
+	txn.NoticeError(myError{})
+	now = now.Add(time.Second)
+	txn.End()
+	if d := txn.Elapsed(); d != 3*time.Second {
+		t.Error(d)
+	}
+	if when := txn.thread.Errors[0].When; !when.Equal(time.Date(2020, time.January, 1, 12, 0, 2, 0, time.UTC)) {
+		t.Error(when)
+	}
+}
+
 type advancedError struct {
 	error
 }
@@ -575,6 +1067,30 @@ func TestErrorWithCallback(t *testing.T) {
 	}})
 }
 
+// withResponseClassMetric adds the HttpResponseClass metric recorded by web
+// transactions which write a response code.
+func withResponseClassMetric(class string, metrics []internal.WantMetric) []internal.WantMetric {
+	return append([]internal.WantMetric{
+		{Name: httpResponseClassPrefix + class, Scope: "", Forced: true, Data: singleCount},
+	}, metrics...)
+}
+
+// withHTTPMethodMetric adds the HttpMethod metric recorded by web
+// transactions which have a request method.
+func withHTTPMethodMetric(method string, metrics []internal.WantMetric) []internal.WantMetric {
+	return append([]internal.WantMetric{
+		{Name: httpMethodPrefix + method, Scope: "", Forced: true, Data: nil},
+	}, metrics...)
+}
+
+// withPanicMetric adds the metric recorded for transactions in which a panic
+// was recovered.
+func withPanicMetric(metrics []internal.WantMetric) []internal.WantMetric {
+	return append([]internal.WantMetric{
+		{Name: supportTxnPanic, Scope: "", Forced: true, Data: singleCount},
+	}, metrics...)
+}
+
 func deferEndPanic(txn *Transaction, panicMe interface{}) (r interface{}) {
 	defer func() {
 		r = recover()
@@ -589,7 +1105,7 @@ func enableRecordPanics(cfg *Config) { cfg.ErrorCollector.RecordPanics = true }
 
 func TestPanicNotEnabled(t *testing.T) {
 	// Test that panics are not recorded as errors if the config setting has
-	// not been enabled.
+	// not been enabled, but that the panic metric is still recorded.
 	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
 	txn := app.StartTransaction("hello")
 
@@ -601,7 +1117,7 @@ func TestPanicNotEnabled(t *testing.T) {
 
 	app.ExpectErrors(t, []internal.WantError{})
 	app.ExpectErrorEvents(t, []internal.WantEvent{})
-	app.ExpectMetrics(t, backgroundMetrics)
+	app.ExpectMetrics(t, withPanicMetric(backgroundMetrics))
 }
 
 func TestPanicError(t *testing.T) {
@@ -626,10 +1142,73 @@ func TestPanicError(t *testing.T) {
 		Intrinsics: map[string]interface{}{
 			"error.class":     panicErrorKlass,
 			"error.message":   "my msg",
+			"error.source":    "recovered_panic",
 			"transactionName": "OtherTransaction/Go/hello",
 		},
 	}})
-	app.ExpectMetrics(t, backgroundErrorMetrics)
+	app.ExpectMetrics(t, withPanicMetric(backgroundErrorMetrics))
+}
+
+func TestTransactionGoRecoverPanics(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.ErrorCollector.RecoverPanics = true
+		cfg.DistributedTracer.Enabled = false
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.runRecovered(func() { panic(myError{}) })
+	txn.End()
+
+	app.expectNoLoggedErrors(t)
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   panicErrorKlass,
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     panicErrorKlass,
+			"error.message":   "my msg",
+			"error.source":    "recovered_panic",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+	}})
+	app.ExpectMetrics(t, withPanicMetric(backgroundErrorMetrics))
+}
+
+func TestTransactionGoRepanics(t *testing.T) {
+	app := testApp(nil, nil, t)
+	txn := app.StartTransaction("hello")
+	e := myError{}
+	r := func() (r interface{}) {
+		defer func() { r = recover() }()
+		txn.runRecovered(func() { panic(e) })
+		return nil
+	}()
+	if r != e {
+		t.Error("panic not propagated", r)
+	}
+	txn.End()
+
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   panicErrorKlass,
+	}})
+}
+
+func TestTransactionGoAfterEnd(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.ErrorCollector.RecoverPanics = true
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.End()
+	done := make(chan struct{})
+	txn.Go(func() {
+		defer close(done)
+		panic(myError{})
+	})
+	<-done
+	app.ExpectErrors(t, []internal.WantError{})
 }
 
 func TestPanicErrorWithCallback(t *testing.T) {
@@ -670,10 +1249,11 @@ func TestPanicErrorWithCallback(t *testing.T) {
 		Intrinsics: map[string]interface{}{
 			"error.class":     panicErrorKlass,
 			"error.message":   "my msg",
+			"error.source":    "recovered_panic",
 			"transactionName": "OtherTransaction/Go/hello",
 		},
 	}})
-	app.ExpectMetrics(t, backgroundErrorMetrics)
+	app.ExpectMetrics(t, withPanicMetric(backgroundErrorMetrics))
 
 }
 
@@ -699,10 +1279,11 @@ func TestPanicString(t *testing.T) {
 		Intrinsics: map[string]interface{}{
 			"error.class":     panicErrorKlass,
 			"error.message":   "my string",
+			"error.source":    "recovered_panic",
 			"transactionName": "OtherTransaction/Go/hello",
 		},
 	}})
-	app.ExpectMetrics(t, backgroundErrorMetrics)
+	app.ExpectMetrics(t, withPanicMetric(backgroundErrorMetrics))
 }
 
 func TestPanicInt(t *testing.T) {
@@ -727,10 +1308,11 @@ func TestPanicInt(t *testing.T) {
 		Intrinsics: map[string]interface{}{
 			"error.class":     panicErrorKlass,
 			"error.message":   "22",
+			"error.source":    "recovered_panic",
 			"transactionName": "OtherTransaction/Go/hello",
 		},
 	}})
-	app.ExpectMetrics(t, backgroundErrorMetrics)
+	app.ExpectMetrics(t, withPanicMetric(backgroundErrorMetrics))
 }
 
 func TestPanicNil(t *testing.T) {
@@ -782,7 +1364,7 @@ func TestResponseCodeError(t *testing.T) {
 			"http.statusCode":  "400",
 		}),
 	}})
-	app.ExpectMetrics(t, webErrorMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", withResponseClassMetric("4xx", webErrorMetrics)))
 }
 
 func AssertStringEqual(t *testing.T, field string, expect string, actual string) {
@@ -849,7 +1431,7 @@ func TestResponseCodeErrorWithCallback(t *testing.T) {
 			AttributeErrorGroupName: "testGroup",
 		}),
 	}})
-	app.ExpectMetrics(t, webErrorMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", withResponseClassMetric("4xx", webErrorMetrics)))
 }
 
 func TestErrorGroupCallbackWithHighSecurity(t *testing.T) {
@@ -858,7 +1440,7 @@ func TestErrorGroupCallbackWithHighSecurity(t *testing.T) {
 			t.Errorf("expected ErrorInfo.Error to be nil, but got %v", e.Error)
 		}
 		AssertStringEqual(t, "ErrorInfo.TransactionName", `WebTransaction/Go/hello`, e.TransactionName)
-		AssertStringEqual(t, "ErrorInfo.Message", "Bad Request", e.Message)
+		AssertStringEqual(t, "ErrorInfo.Message", highSecurityErrorMsg, e.Message)
 		AssertStringEqual(t, "ErrorInfo.Class", "400", e.Class)
 		AssertStringEqual(t, "Request URI", "/hello", e.GetRequestURI())
 		AssertStringEqual(t, "Request Method", "GET", e.GetRequestMethod())
@@ -912,7 +1494,7 @@ func TestResponseCode404Filtered(t *testing.T) {
 
 	app.ExpectErrors(t, []internal.WantError{})
 	app.ExpectErrorEvents(t, []internal.WantEvent{})
-	app.ExpectMetrics(t, webMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", withResponseClassMetric("4xx", webMetrics)))
 }
 
 func TestResponseCodeCustomFilter(t *testing.T) {
@@ -932,7 +1514,7 @@ func TestResponseCodeCustomFilter(t *testing.T) {
 
 	app.ExpectErrors(t, []internal.WantError{})
 	app.ExpectErrorEvents(t, []internal.WantEvent{})
-	app.ExpectMetrics(t, webMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", withResponseClassMetric("4xx", webMetrics)))
 }
 
 func TestResponseCodeServerSideFilterObserved(t *testing.T) {
@@ -956,7 +1538,7 @@ func TestResponseCodeServerSideFilterObserved(t *testing.T) {
 
 	app.ExpectErrors(t, []internal.WantError{})
 	app.ExpectErrorEvents(t, []internal.WantEvent{})
-	app.ExpectMetrics(t, webMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", withResponseClassMetric("4xx", webMetrics)))
 }
 
 func TestResponseCodeServerSideOverwriteLocal(t *testing.T) {
@@ -994,7 +1576,7 @@ func TestResponseCodeServerSideOverwriteLocal(t *testing.T) {
 			"http.statusCode":  "404",
 		}),
 	}})
-	app.ExpectMetrics(t, webErrorMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", withResponseClassMetric("4xx", webErrorMetrics)))
 }
 
 func TestResponseCodeAfterEnd(t *testing.T) {
@@ -1013,7 +1595,7 @@ func TestResponseCodeAfterEnd(t *testing.T) {
 
 	app.ExpectErrors(t, []internal.WantError{})
 	app.ExpectErrorEvents(t, []internal.WantEvent{})
-	app.ExpectMetrics(t, webMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webMetrics))
 }
 
 func TestResponseCodeAfterWrite(t *testing.T) {
@@ -1038,7 +1620,7 @@ func TestResponseCodeAfterWrite(t *testing.T) {
 
 	app.ExpectErrors(t, []internal.WantError{})
 	app.ExpectErrorEvents(t, []internal.WantEvent{})
-	app.ExpectMetrics(t, webMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", withResponseClassMetric("2xx", webMetrics)))
 }
 
 func TestQueueTime(t *testing.T) {
@@ -1070,16 +1652,24 @@ func TestQueueTime(t *testing.T) {
 			"request.method": "GET",
 		},
 	}})
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "WebFrontend/QueueTime", Scope: "", Forced: true, Data: nil},
-	}, webErrorMetrics...))
+		{Name: "WebFrontend/TotalResponseTime", Scope: "", Forced: true, Data: nil},
+	}, webErrorMetrics...)))
 	app.ExpectTxnEvents(t, []internal.WantEvent{{
 		Intrinsics: map[string]interface{}{
 			"name":             "WebTransaction/Go/hello",
 			"nr.apdexPerfZone": "F",
 			"queueDuration":    internal.MatchAnything,
 		},
-		AgentAttributes: nil,
+		AgentAttributes: map[string]interface{}{
+			"request.uri":                 "/hello",
+			"request.method":              "GET",
+			AttributeApdexThreshold:       500,
+			AttributeQueueDuration:        internal.MatchAnything,
+			AttributeTotalErrors:          1,
+			AttributeDistinctErrorClasses: 1,
+		},
 	}})
 }
 
@@ -1092,10 +1682,93 @@ func TestIgnore(t *testing.T) {
 	txn.End()
 	app.ExpectErrors(t, []internal.WantError{})
 	app.ExpectErrorEvents(t, []internal.WantEvent{})
-	app.ExpectMetrics(t, []internal.WantMetric{})
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "Supportability/Go/Transaction/Ignored/Explicit", Scope: "", Forced: true, Data: singleCount},
+	})
+	app.ExpectTxnEvents(t, []internal.WantEvent{})
+}
+
+func TestIgnorePanicMetric(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		enableRecordPanics(cfg)
+		cfg.DistributedTracer.Enabled = false
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.Ignore()
+	e := myError{}
+	if r := deferEndPanic(txn, e); r != e {
+		t.Error("panic not propagated", r)
+	}
+	app.ExpectMetrics(t, withPanicMetric([]internal.WantMetric{
+		{Name: "Supportability/Go/Transaction/Ignored/Explicit", Scope: "", Forced: true, Data: singleCount},
+	}))
+}
+
+func TestIgnoreCaptureErrors(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.CaptureIgnoredTransactionErrors = true
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	txn.Ignore()
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+	}})
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "Supportability/Go/Transaction/Ignored/Explicit", Scope: "", Forced: true, Data: singleCount},
+	})
 	app.ExpectTxnEvents(t, []internal.WantEvent{})
 }
 
+func TestIgnoreCaptureErrorsOperationName(t *testing.T) {
+	cfgFn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.CaptureIgnoredTransactionErrors = true
+	}
+	app := testApp(nil, cfgFn, t)
+	txn := app.StartTransaction("")
+	txn.SetOperationName("resize-image")
+	txn.NoticeError(myError{})
+	txn.Ignore()
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/resize-image",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}})
+}
+
+func TestIgnoredByNameRules(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		js := `[{"ignore":true,"match_expression":"ignore_me","eval_order":1}]`
+		if err := json.Unmarshal([]byte(js), &reply.TxnNameRules); nil != err {
+			t.Fatal(err)
+		}
+	}
+	app := testApp(replyfn, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("ignore_me")
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{})
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "Supportability/Go/Transaction/Ignored/EmptyName", Scope: "", Forced: true, Data: singleCount},
+	})
+}
+
 func TestIgnoreAlreadyEnded(t *testing.T) {
 	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
 	txn := app.StartTransaction("hello")
@@ -1273,10 +1946,10 @@ func TestTraceSegmentDefer(t *testing.T) {
 	}()
 	txn.End()
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "Custom/segment", Scope: "", Forced: false, Data: nil},
 		{Name: "Custom/segment", Scope: scope, Forced: false, Data: nil},
-	}, webMetrics...))
+	}, webMetrics...)))
 }
 
 func TestTraceSegmentNilErr(t *testing.T) {
@@ -1287,10 +1960,10 @@ func TestTraceSegmentNilErr(t *testing.T) {
 	app.expectNoLoggedErrors(t)
 	txn.End()
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "Custom/segment", Scope: "", Forced: false, Data: nil},
 		{Name: "Custom/segment", Scope: scope, Forced: false, Data: nil},
-	}, webMetrics...))
+	}, webMetrics...)))
 }
 
 func TestTraceSegmentOutOfOrder(t *testing.T) {
@@ -1307,10 +1980,10 @@ func TestTraceSegmentOutOfOrder(t *testing.T) {
 	})
 	txn.End()
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "Custom/s1", Scope: "", Forced: false, Data: nil},
 		{Name: "Custom/s1", Scope: scope, Forced: false, Data: nil},
-	}, webMetrics...))
+	}, webMetrics...)))
 }
 
 func TestTraceSegmentEndedBeforeStartSegment(t *testing.T) {
@@ -1323,7 +1996,7 @@ func TestTraceSegmentEndedBeforeStartSegment(t *testing.T) {
 	app.expectSingleLoggedError(t, "unable to end segment", map[string]interface{}{
 		"reason": errAlreadyEnded.Error(),
 	})
-	app.ExpectMetrics(t, webMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webMetrics))
 }
 
 func TestTraceSegmentEndedBeforeEndSegment(t *testing.T) {
@@ -1336,7 +2009,7 @@ func TestTraceSegmentEndedBeforeEndSegment(t *testing.T) {
 	app.expectSingleLoggedError(t, "unable to end segment", map[string]interface{}{
 		"reason": errAlreadyEnded.Error(),
 	})
-	app.ExpectMetrics(t, webMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webMetrics))
 }
 
 func TestTraceSegmentPanic(t *testing.T) {
@@ -1371,12 +2044,12 @@ func TestTraceSegmentPanic(t *testing.T) {
 
 	txn.End()
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "Custom/f1", Scope: "", Forced: false, Data: nil},
 		{Name: "Custom/f1", Scope: scope, Forced: false, Data: nil},
 		{Name: "Custom/f3", Scope: "", Forced: false, Data: nil},
 		{Name: "Custom/f3", Scope: scope, Forced: false, Data: nil},
-	}, webMetrics...))
+	}, webMetrics...)))
 }
 
 func TestTraceSegmentNilTxn(t *testing.T) {
@@ -1387,7 +2060,7 @@ func TestTraceSegmentNilTxn(t *testing.T) {
 	s.End()
 	app.expectNoLoggedErrors(t)
 	txn.End()
-	app.ExpectMetrics(t, webMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webMetrics))
 }
 
 func TestTraceDatastore(t *testing.T) {
@@ -1404,7 +2077,7 @@ func TestTraceDatastore(t *testing.T) {
 	txn.NoticeError(myError{})
 	txn.End()
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "Datastore/all", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/MySQL/all", Scope: "", Forced: true, Data: nil},
@@ -1412,7 +2085,7 @@ func TestTraceDatastore(t *testing.T) {
 		{Name: "Datastore/operation/MySQL/SELECT", Scope: "", Forced: false, Data: nil},
 		{Name: "Datastore/statement/MySQL/my_table/SELECT", Scope: "", Forced: false, Data: nil},
 		{Name: "Datastore/statement/MySQL/my_table/SELECT", Scope: scope, Forced: false, Data: nil},
-	}, webErrorMetrics...))
+	}, webErrorMetrics...)))
 	app.ExpectErrorEvents(t, []internal.WantEvent{{
 		Intrinsics: map[string]interface{}{
 			"error.class":       "newrelic.myError",
@@ -1485,14 +2158,14 @@ func TestTraceDatastoreMissingProductOperationCollection(t *testing.T) {
 	txn.NoticeError(myError{})
 	txn.End()
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "Datastore/all", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/Unknown/all", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/Unknown/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/operation/Unknown/other", Scope: "", Forced: false, Data: nil},
 		{Name: "Datastore/operation/Unknown/other", Scope: scope, Forced: false, Data: nil},
-	}, webErrorMetrics...))
+	}, webErrorMetrics...)))
 	app.ExpectErrorEvents(t, []internal.WantEvent{{
 		Intrinsics: map[string]interface{}{
 			"error.class":       "newrelic.myError",
@@ -1524,7 +2197,7 @@ func TestTraceDatastoreNilTxn(t *testing.T) {
 	app.expectNoLoggedErrors(t)
 	txn.NoticeError(myError{})
 	txn.End()
-	app.ExpectMetrics(t, webErrorMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webErrorMetrics))
 	app.ExpectErrorEvents(t, []internal.WantEvent{{
 		Intrinsics: map[string]interface{}{
 			"error.class":     "newrelic.myError",
@@ -1556,7 +2229,7 @@ func TestTraceDatastoreTxnEnded(t *testing.T) {
 	app.expectSingleLoggedError(t, "unable to end datastore segment", map[string]interface{}{
 		"reason": errAlreadyEnded.Error(),
 	})
-	app.ExpectMetrics(t, webErrorMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webErrorMetrics))
 	app.ExpectErrorEvents(t, []internal.WantEvent{{
 		Intrinsics: map[string]interface{}{
 			"error.class":     "newrelic.myError",
@@ -1585,12 +2258,12 @@ func TestTraceExternal(t *testing.T) {
 	txn.NoticeError(myError{})
 	txn.End()
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "External/all", Scope: "", Forced: true, Data: nil},
 		{Name: "External/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "External/example.com/all", Scope: "", Forced: false, Data: nil},
 		{Name: "External/example.com/http", Scope: scope, Forced: false, Data: nil},
-	}, webErrorMetrics...))
+	}, webErrorMetrics...)))
 	app.ExpectErrorEvents(t, []internal.WantEvent{{
 		Intrinsics: map[string]interface{}{
 			"error.class":       "newrelic.myError",
@@ -1632,14 +2305,14 @@ func TestExternalSegmentCustomFieldsWithURL(t *testing.T) {
 	app.expectNoLoggedErrors(t)
 	txn.End()
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "DurationByCaller/Unknown/Unknown/Unknown/Unknown/all", Scope: "", Forced: false, Data: nil},
 		{Name: "DurationByCaller/Unknown/Unknown/Unknown/Unknown/allWeb", Scope: "", Forced: false, Data: nil},
 		{Name: "External/all", Scope: "", Forced: true, Data: nil},
 		{Name: "External/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "External/bufnet/all", Scope: "", Forced: false, Data: nil},
 		{Name: "External/bufnet/grpc/TestApplication/DoUnaryUnary", Scope: scope, Forced: false, Data: nil},
-	}, webMetrics...))
+	}, webMetrics...)))
 	app.ExpectSpanEvents(t, []internal.WantEvent{
 		{
 			Intrinsics: map[string]interface{}{
@@ -1696,14 +2369,14 @@ func TestExternalSegmentCustomFieldsWithRequest(t *testing.T) {
 	app.expectNoLoggedErrors(t)
 	txn.End()
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "DurationByCaller/Unknown/Unknown/Unknown/Unknown/all", Scope: "", Forced: false, Data: nil},
 		{Name: "DurationByCaller/Unknown/Unknown/Unknown/Unknown/allWeb", Scope: "", Forced: false, Data: nil},
 		{Name: "External/all", Scope: "", Forced: true, Data: nil},
 		{Name: "External/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "External/bufnet/all", Scope: "", Forced: false, Data: nil},
 		{Name: "External/bufnet/grpc/TestApplication/DoUnaryUnary", Scope: scope, Forced: false, Data: nil},
-	}, webMetrics...))
+	}, webMetrics...)))
 	app.ExpectSpanEvents(t, []internal.WantEvent{
 		{
 			Intrinsics: map[string]interface{}{
@@ -1767,14 +2440,14 @@ func TestExternalSegmentCustomFieldsWithResponse(t *testing.T) {
 	app.expectNoLoggedErrors(t)
 	txn.End()
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "DurationByCaller/Unknown/Unknown/Unknown/Unknown/all", Scope: "", Forced: false, Data: nil},
 		{Name: "DurationByCaller/Unknown/Unknown/Unknown/Unknown/allWeb", Scope: "", Forced: false, Data: nil},
 		{Name: "External/all", Scope: "", Forced: true, Data: nil},
 		{Name: "External/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "External/bufnet/all", Scope: "", Forced: false, Data: nil},
 		{Name: "External/bufnet/grpc/TestApplication/DoUnaryUnary", Scope: scope, Forced: false, Data: nil},
-	}, webMetrics...))
+	}, webMetrics...)))
 	app.ExpectSpanEvents(t, []internal.WantEvent{
 		{
 			Intrinsics: map[string]interface{}{
@@ -1826,7 +2499,7 @@ func TestTraceExternalBadURL(t *testing.T) {
 	})
 	txn.NoticeError(myError{})
 	txn.End()
-	app.ExpectMetrics(t, webErrorMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webErrorMetrics))
 	app.ExpectErrorEvents(t, []internal.WantEvent{{
 		Intrinsics: map[string]interface{}{
 			"error.class":     "newrelic.myError",
@@ -1890,12 +2563,12 @@ func TestTraceExternalMissingURL(t *testing.T) {
 	txn.NoticeError(myError{})
 	txn.End()
 	scope := "WebTransaction/Go/hello"
-	app.ExpectMetrics(t, append([]internal.WantMetric{
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", append([]internal.WantMetric{
 		{Name: "External/all", Scope: "", Forced: true, Data: nil},
 		{Name: "External/allWeb", Scope: "", Forced: true, Data: nil},
 		{Name: "External/unknown/all", Scope: "", Forced: false, Data: nil},
 		{Name: "External/unknown/http", Scope: scope, Forced: false, Data: nil},
-	}, webErrorMetrics...))
+	}, webErrorMetrics...)))
 	app.ExpectErrorEvents(t, []internal.WantEvent{{
 		Intrinsics: map[string]interface{}{
 			"error.class":       "newrelic.myError",
@@ -1924,7 +2597,7 @@ func TestTraceExternalNilTxn(t *testing.T) {
 	s.End()
 	app.expectNoLoggedErrors(t)
 	txn.End()
-	app.ExpectMetrics(t, webErrorMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webErrorMetrics))
 	app.ExpectErrorEvents(t, []internal.WantEvent{{
 		Intrinsics: map[string]interface{}{
 			"error.class":     "newrelic.myError",
@@ -1954,7 +2627,7 @@ func TestTraceExternalTxnEnded(t *testing.T) {
 	app.expectSingleLoggedError(t, "unable to end external segment", map[string]interface{}{
 		"reason": errAlreadyEnded.Error(),
 	})
-	app.ExpectMetrics(t, webErrorMetrics)
+	app.ExpectMetrics(t, withHTTPMethodMetric("GET", webErrorMetrics))
 	app.ExpectErrorEvents(t, []internal.WantEvent{{
 		Intrinsics: map[string]interface{}{
 			"error.class":     "newrelic.myError",
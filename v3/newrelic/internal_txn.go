@@ -7,16 +7,26 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
 	"reflect"
+	"runtime"
 	"runtime/debug"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
 
 	"github.com/newrelic/go-agent/v3/internal"
 )
 
+// timeNow returns the current time.  It is used for the start and end times
+// of transactions and the times at which errors are noticed, and is replaced
+// in tests to control the passage of time deterministically.
+var timeNow = time.Now
+
 type txn struct {
 	app *app
 	*appRun
@@ -31,11 +41,70 @@ type txn struct {
 	sampledCalculated  bool
 
 	ignore bool
+	// ignoredByUser is true when the transaction was ignored by a call to
+	// Ignore, rather than because its name was ignored by naming rules.
+	ignoredByUser bool
+
+	// requestID is the identifier set by SetRequestID.
+	requestID string
+
+	// attributesDisabled prevents the capture of any attributes for this
+	// transaction.
+	attributesDisabled bool
+
+	// markedSuccess prevents noticed errors from placing the transaction
+	// in the failing Apdex zone.  It is set by MarkSuccess.
+	markedSuccess bool
+
+	// operationName is set by SetOperationName.
+	operationName string
+
+	// bodyBytesRead is the number of request body bytes recorded by
+	// RecordBytesRead.
+	bodyBytesRead int64
 
 	// wroteHeader prevents capturing multiple response code errors if the
 	// user erroneously calls WriteHeader multiple times.
 	wroteHeader bool
 
+	// writesAfterEnd counts the writes to the response after End which
+	// have not yet been merged into a harvest.  It must be accessed
+	// atomically.
+	writesAfterEnd int64
+
+	// totalErrors is the number of errors noticed, and errorClasses the
+	// distinct classes of those errors, up to maxDistinctErrorClasses.
+	totalErrors  int
+	errorClasses map[string]struct{}
+	// errorsSeen is the number of errors noticed which are neither
+	// expected nor informational, including those not captured because
+	// of the errors limit.
+	errorsSeen int
+
+	// latencyTarget is set by SetLatencyTarget.
+	latencyTarget time.Duration
+
+	// logCounts counts the logs recorded by severity, keyed by the
+	// attribute in which the count is recorded.
+	logCounts map[string]int
+
+	// requestBody captures the request body when
+	// Config.ErrorCollector.CaptureRequestBody is enabled.
+	requestBody *requestBodyCapture
+
+	// preserveErrorMessages is set by PreserveErrorMessages to keep error
+	// messages despite high security mode.
+	preserveErrorMessages bool
+
+	// forceBackground and apdexThresholdOverride are set by
+	// TransactionOptions.
+	forceBackground        bool
+	apdexThresholdOverride time.Duration
+
+	// lockWait is the time spent waiting in Lock.  It is only measured
+	// when Config.RecordTransactionLockWait is enabled.
+	lockWait time.Duration
+
 	txnData
 
 	mainThread   tracingThread
@@ -110,7 +179,7 @@ func newTxn(app *app, run *appRun, name string, opts ...TraceOption) *thread {
 	for _, o := range opts {
 		o(&txnOpts)
 	}
-	txn.markStart(time.Now())
+	txn.markStart(timeNow())
 
 	txn.Name = name
 	txn.Attrs = newAttributes(run.AttributeConfig)
@@ -151,6 +220,34 @@ func newTxn(app *app, run *appRun, name string, opts ...TraceOption) *thread {
 	}
 }
 
+// applyOptions applies the TransactionOptions given to
+// Application.StartTransactionWithOptions.  The DefaultAttributes are added
+// separately so that invalid attributes are logged.
+func (txn *txn) applyOptions(opts TransactionOptions) {
+	txn.forceBackground = opts.IsBackground
+	if opts.ApdexThreshold > 0 {
+		txn.apdexThresholdOverride = opts.ApdexThreshold
+	}
+	if opts.Ignore {
+		txn.ignore = true
+		txn.ignoredByUser = true
+	}
+	if nil != opts.AttributeConfig {
+		cfg := txn.Config
+		cfg.Attributes = *opts.AttributeConfig
+		txn.Attrs.config = createAttributeConfig(cfg, txn.Reply.SecurityPolicies.AttributesInclude.Enabled())
+	}
+}
+
+// apdexThreshold returns the apdex threshold for the transaction name,
+// unless it was overridden by TransactionOptions.
+func (txn *txn) apdexThreshold(name string) time.Duration {
+	if txn.apdexThresholdOverride > 0 {
+		return txn.apdexThresholdOverride
+	}
+	return internal.CalculateApdexThreshold(txn.Reply, name)
+}
+
 func (thd *thread) logAPIError(err error, operation string, extraDetails map[string]interface{}) {
 	if nil == thd {
 		return
@@ -208,6 +305,23 @@ func (txn *txn) lazilyCalculateSampled() bool {
 }
 
 func (txn *txn) SetWebRequest(r WebRequest) error {
+	return txn.setWebRequest(r, nil)
+}
+
+// SetWebRequestHTTP sets the web request, using req to resolve the
+// transaction type when Config.TransactionTypeResolver is set.
+func (txn *txn) SetWebRequestHTTP(req *http.Request, r WebRequest) error {
+	return txn.setWebRequest(r, req)
+}
+
+func (txn *txn) setWebRequest(r WebRequest, req *http.Request) error {
+	// The resolver only needs the request, so it is called before the
+	// transaction is locked.
+	typ := TransactionTypeDefault
+	if nil != req {
+		typ = txn.resolveTransactionType(req)
+	}
+
 	txn.Lock()
 	defer txn.Unlock()
 
@@ -215,21 +329,88 @@ func (txn *txn) SetWebRequest(r WebRequest) error {
 		return errAlreadyEnded
 	}
 
-	// Any call to SetWebRequest should indicate a web transaction.
-	txn.IsWeb = true
+	// Any call to SetWebRequest should indicate a web transaction, unless
+	// the resolver decides otherwise.
+	txn.IsWeb = !txn.forceBackground
+	switch typ {
+	case TransactionTypeBackground:
+		txn.IsWeb = false
+	case TransactionTypeRPC:
+		// The request of an RPC transaction is only used to capture its
+		// headers, so its method and URL are not recorded.
+		txn.IsWeb = false
+		r.Method = ""
+		r.URL = nil
+	}
+
+	// The method is kept apart from the request.method attribute so that
+	// the HttpMethod metric does not depend on attribute configuration.
+	txn.requestMethod = r.Method
+
+	if nil != req && txn.shouldCaptureRequestBody() {
+		txn.requestBody = captureRequestBody(req)
+	}
 
 	h := r.Header
 	if nil != h {
 		txn.Queuing = queueDuration(h, txn.Start)
 		txn.acceptDistributedTraceHeadersLocked(r.Transport, h)
 		txn.CrossProcess.InboundHTTPRequest(h)
+		if txn.CrossProcess.IsSynthetics() && !txn.Config.HighSecurity {
+			synthetics := txn.CrossProcess.Synthetics
+			txn.Attrs.Agent.Add(AttributeSyntheticsResourceID, synthetics.ResourceID, nil)
+			txn.Attrs.Agent.Add(AttributeSyntheticsJobID, synthetics.JobID, nil)
+			txn.Attrs.Agent.Add(AttributeSyntheticsMonitorID, synthetics.MonitorID, nil)
+		}
 	}
 
-	requestAgentAttributes(txn.Attrs, r.Method, h, r.URL, r.Host)
+	captured := txn.Config.CaptureRequestHeaders
+	if txn.Config.HighSecurity {
+		captured = nil
+	}
+	if txn.Config.CaptureRequestHeadersAsJSON {
+		requestAgentAttributes(txn.Attrs, r.Method, nil, r.URL, r.Host)
+		requestHeadersJSONAttribute(txn.Attrs, h, r.Host, captured)
+	} else {
+		requestAgentAttributes(txn.Attrs, r.Method, h, r.URL, r.Host)
+		requestCapturedHeaderAttributes(txn.Attrs, h, captured)
+	}
+	requestTLSAttributes(txn.Attrs, r.TLS)
+	requestSchemeAttribute(txn.Attrs, h, r.TLS)
+	requestClientRegionAttribute(txn.Attrs, h, txn.Config.ClientRegionHeader)
 
 	return nil
 }
 
+// resolveTransactionType calls Config.TransactionTypeResolver, if set.  If
+// the resolver panics, the panic is recovered and logged, and the default
+// classification is used.
+func (txn *txn) resolveTransactionType(req *http.Request) (typ TransactionType) {
+	resolve := txn.Config.TransactionTypeResolver
+	if nil == resolve {
+		return TransactionTypeDefault
+	}
+	defer func() {
+		if r := recover(); nil != r {
+			typ = TransactionTypeDefault
+			txn.Config.Logger.Error("panic in transaction type resolver", map[string]interface{}{
+				"panic": fmt.Sprint(r),
+			})
+		}
+	}()
+	return resolve(req)
+}
+
+// shouldCaptureRequestBody returns true if Config.ErrorCollector.CaptureRequestBody
+// is enabled and the request body is not forbidden by high security or by the
+// attributes include and custom parameters security policies.
+func (txn *txn) shouldCaptureRequestBody() bool {
+	return txn.Config.ErrorCollector.CaptureRequestBody &&
+		!txn.Config.HighSecurity &&
+		txn.Reply.SecurityPolicies.AttributesInclude.Enabled() &&
+		txn.Reply.SecurityPolicies.CustomParameters.Enabled()
+}
+
 type dummyResponseWriter struct{}
 
 func (rw dummyResponseWriter) Header() http.Header { return nil }
@@ -269,26 +450,77 @@ func (thd *thread) StoreLog(log *logEvent) {
 		return
 	}
 
+	if log.serviceName == "" {
+		log.serviceName = txn.appRun.firstAppName
+	}
 	if txn.logs == nil {
 		txn.logs = make(logEventHeap, 0, internal.MaxLogEvents)
 	}
+	if log.correlatesWithErrors() && len(txn.Errors) > 0 {
+		e := txn.Errors[len(txn.Errors)-1]
+		log.errorClass = e.Klass
+		log.errorID = e.ID
+	}
 	txn.logs.Add(log)
+
+	if nil == txn.logCounts {
+		txn.logCounts = make(map[string]int)
+	}
+	txn.logCounts[logCountAttribute(log.severityLevel)]++
+}
+
+// applyNameCallback renames the transaction using
+// Config.TransactionNameCallback, now that the transaction's errors are known.
+// It must be called before freezeName.
+func (txn *txn) applyNameCallback() {
+	cb := txn.Config.TransactionNameCallback
+	if nil == cb || txn.ignore || txn.FinalName != "" {
+		return
+	}
+	txn.Name = cb(txn.Name, txn.HasErrors() && txn.NoticeErrors())
 }
 
 func (txn *txn) freezeName() {
 	if txn.ignore || (txn.FinalName != "") {
 		return
 	}
-	txn.FinalName = txn.appRun.createTransactionName(txn.Name, txn.IsWeb)
+	txn.FinalName = txn.createName()
 	if txn.FinalName == "" {
 		txn.ignore = true
 	}
 }
 
+// createName returns the final name for the transaction's current name.
+// Background transactions which have not been named use the name given to
+// SetOperationName.
+func (txn *txn) createName() string {
+	name := txn.Name
+	if name == "" && !txn.IsWeb {
+		name = txn.operationName
+	}
+	return txn.appRun.createTransactionName(name, txn.IsWeb)
+}
+
 func (txn *txn) getsApdex() bool {
 	return txn.IsWeb
 }
 
+// apdexZone classifies the transaction given its name, threshold and
+// duration.
+func (txn *txn) apdexZone(name string, threshold, duration time.Duration) apdexZone {
+	if !txn.getsApdex() {
+		return apdexNone
+	}
+	if apdexIgnored(txn.Config.Apdex.IgnoreTransactions, name) {
+		return apdexNone
+	}
+	if txn.HasErrors() && txn.NoticeErrors() && !txn.markedSuccess &&
+		(txn.unhandledErrors || !txn.Config.ErrorCollector.ExcludeHandledFromApdex) {
+		return apdexFailing
+	}
+	return calculateApdexZone(threshold, duration)
+}
+
 func (txn *txn) shouldSaveTrace() bool {
 	if !txn.Config.TransactionTracer.Enabled {
 		return false
@@ -299,13 +531,15 @@ func (txn *txn) shouldSaveTrace() bool {
 	return txn.Duration >= txn.txnTraceThreshold(txn.ApdexThreshold)
 }
 
-func (txn *txn) MergeIntoHarvest(h *harvest) {
-	var priority priority
+func (txn *txn) harvestPriority() priority {
 	if txn.BetterCAT.Enabled {
-		priority = txn.BetterCAT.Priority
-	} else {
-		priority = newPriority()
+		return txn.BetterCAT.Priority
 	}
+	return newPriority()
+}
+
+func (txn *txn) MergeIntoHarvest(h *harvest) {
+	priority := txn.harvestPriority()
 
 	createTxnMetrics(&txn.txnData, h.Metrics)
 	mergeBreakdownMetrics(&txn.txnData, h.Metrics)
@@ -317,14 +551,57 @@ func (txn *txn) MergeIntoHarvest(h *harvest) {
 		h.LogEvents.Add(&logEvent)
 	}
 
-	if txn.Config.TransactionEvents.Enabled {
+	if txn.Config.TransactionEvents.Enabled && txn.keepTxnEvent(priority) {
 		// Allocate a new TxnEvent to prevent a reference to the large transaction.
 		alloc := new(txnEvent)
 		*alloc = txn.txnData.txnEvent
 		h.TxnEvents.AddTxnEvent(alloc, priority)
 	}
 
-	hs := &highSecuritySettings{txn.Config.HighSecurity, txn.Reply.SecurityPolicies.AllowRawExceptionMessages.Enabled()}
+	txn.mergeErrorsIntoHarvest(h, priority)
+
+	if txn.shouldSaveTrace() {
+		h.TxnTraces.Witness(harvestTrace{
+			txnEvent: txn.txnEvent,
+			Trace:    txn.TxnTrace,
+		})
+	}
+
+	if nil != txn.SlowQueries {
+		h.SlowSQLs.Merge(txn.SlowQueries, txn.txnEvent)
+	}
+
+	if txn.shouldCollectSpanEvents() && !shouldUseTraceObserver(txn.Config) {
+		h.SpanEvents.MergeSpanEvents(txn.txnData.SpanEvents)
+	}
+}
+
+// keepTxnEvent applies Config.TransactionEvents.SampleRate.
+func (txn *txn) keepTxnEvent(p priority) bool {
+	if txn.CrossProcess.IsSynthetics() {
+		return true
+	}
+	return p.keptAtSampleRate(txn.Config.TransactionEvents.SampleRate)
+}
+
+// mergeErrorsIntoHarvest adds the transaction's traced errors and error
+// events to the harvest.
+func (txn *txn) mergeErrorsIntoHarvest(h *harvest, priority priority) {
+	hs := &highSecuritySettings{txn.redactsErrorMessages(), txn.Reply.SecurityPolicies.AllowRawExceptionMessages.Enabled()}
+
+	// Scrub the errors before the error group callback is run so that the
+	// callback never sees a message that will not be sent.
+	for _, e := range txn.Errors {
+		e.scrubErrorForHighSecurity(hs)
+	}
+
+	if defaults := txn.Config.ErrorCollector.DefaultAttributes; len(defaults) > 0 &&
+		!txn.attributesDisabled && !txn.Config.HighSecurity &&
+		txn.Reply.SecurityPolicies.CustomParameters.Enabled() {
+		for _, e := range txn.Errors {
+			e.addDefaultAttributes(defaults, txn.Attrs)
+		}
+	}
 
 	if (txn.Reply.CollectErrors || txn.Config.ErrorCollector.CaptureEvents) && txn.Config.ErrorCollector.ErrorGroupCallback != nil {
 		txn.txnEvent.errGroupCallback = txn.Config.ErrorCollector.ErrorGroupCallback
@@ -333,13 +610,27 @@ func (txn *txn) MergeIntoHarvest(h *harvest) {
 		}
 	}
 
-	if txn.Reply.CollectErrors {
+	if txn.Reply.CollectErrors && txn.Config.ErrorCollector.CaptureTraces &&
+		(!txn.Config.ErrorCollector.CaptureTracesSampledOnly || txn.BetterCAT.Sampled) {
+		if txn.Config.ErrorCollector.CaptureSourceContext {
+			for _, e := range txn.Errors {
+				if nil != e.Stack && nil == e.SourceContext {
+					e.SourceContext = e.Stack.sourceContext()
+				}
+			}
+		}
+		if body := txn.requestBody.String(); body != "" && !txn.attributesDisabled {
+			// The value is stored directly rather than using Add, which
+			// would truncate it to the attribute value length limit.
+			// The attribute configuration and filter are applied when
+			// the traced errors are written.
+			txn.Attrs.Agent[AttributeRequestBody] = agentAttributeValue{stringVal: body}
+		}
 		mergeTxnErrors(&h.ErrorTraces, txn.Errors, txn.txnEvent, hs)
 	}
 
 	if txn.Config.ErrorCollector.CaptureEvents {
 		for _, e := range txn.Errors {
-			e.scrubErrorForHighSecurity(hs)
 			errEvent := &errorEvent{
 				errorData: *e,
 				txnEvent:  txn.txnEvent,
@@ -351,21 +642,66 @@ func (txn *txn) MergeIntoHarvest(h *harvest) {
 			h.ErrorEvents.Add(errEvent, priority)
 		}
 	}
+}
 
-	if txn.shouldSaveTrace() {
-		h.TxnTraces.Witness(harvestTrace{
-			txnEvent: txn.txnEvent,
-			Trace:    txn.TxnTrace,
-		})
+// ignoredTxn is consumed in place of an ignored transaction.  It records a
+// supportability metric for the reason the transaction was ignored and, when
+// Config.ErrorCollector.CaptureIgnoredTransactionErrors is enabled, merges the
+// transaction's errors into the harvest.
+type ignoredTxn struct {
+	txn           *txn
+	captureErrors bool
+}
+
+func (i ignoredTxn) MergeIntoHarvest(h *harvest) {
+	if i.txn.ignoredByUser {
+		h.Metrics.addSingleCount(supportTxnIgnoredExplicit, forced)
+	} else {
+		h.Metrics.addSingleCount(supportTxnIgnoredEmptyName, forced)
+	}
+	if i.txn.panicked {
+		h.Metrics.addSingleCount(supportTxnPanic, forced)
 	}
+	if i.captureErrors {
+		i.txn.mergeErrorsIntoHarvest(h, i.txn.harvestPriority())
+	}
+}
 
-	if nil != txn.SlowQueries {
-		h.SlowSQLs.Merge(txn.SlowQueries, txn.txnEvent)
+// writeAfterEnd merges the writes after End counted since the last merge.
+type writeAfterEnd struct {
+	txn *txn
+}
+
+func (w writeAfterEnd) MergeIntoHarvest(h *harvest) {
+	if n := atomic.SwapInt64(&w.txn.writesAfterEnd, 0); n > 0 {
+		h.Metrics.addCount(supportWriteAfterEnd, float64(n), forced)
 	}
+}
 
-	if txn.shouldCollectSpanEvents() && !shouldUseTraceObserver(txn.Config) {
-		h.SpanEvents.MergeSpanEvents(txn.txnData.SpanEvents)
+// checkWriteAfterEnd applies Config.WritesAfterEnd to a write to the response
+// writer.  It returns ErrWriteAfterEnd if the write should be rejected.
+func (thd *thread) checkWriteAfterEnd() error {
+	txn := thd.txn
+	cfg := txn.Config.WritesAfterEnd
+	if !cfg.RecordMetric && !cfg.ReturnError {
+		return nil
+	}
+	txn.Lock()
+	finished := txn.finished
+	txn.Unlock()
+
+	if !finished {
+		return nil
+	}
+	// Only the first write since the last merge is sent to the harvest,
+	// so that streaming handlers do not send a message per write.
+	if cfg.RecordMetric && nil != txn.app && atomic.AddInt64(&txn.writesAfterEnd, 1) == 1 {
+		txn.app.Consume(txn.Reply.RunID, writeAfterEnd{txn: txn})
+	}
+	if cfg.ReturnError {
+		return ErrWriteAfterEnd
 	}
+	return nil
 }
 
 func headersJustWritten(thd *thread, code int, hdr http.Header) {
@@ -383,9 +719,11 @@ func headersJustWritten(thd *thread, code int, hdr http.Header) {
 
 	responseHeaderAttributes(txn.Attrs, hdr)
 	responseCodeAttribute(txn.Attrs, code)
+	responseRetryAfterAttribute(txn.Attrs, code, hdr, timeNow())
+	txn.responseCode = code
 
 	if txn.appRun.responseCodeIsError(code) {
-		e := txnErrorFromResponseCode(time.Now(), code)
+		e := txnErrorFromResponseCode(timeNow(), code)
 		e.Stack = getStackTrace()
 		expect := txn.appRun.responseCodeIsExpected(code)
 		thd.noticeErrorInternal(e, nil, expect)
@@ -411,7 +749,7 @@ func (txn *txn) responseHeader(hdr http.Header) http.Header {
 	txn.freezeName()
 	contentLength := getContentLengthFromHeader(hdr)
 
-	appData, err := txn.CrossProcess.CreateAppData(txn.FinalName, txn.Queuing, time.Since(txn.Start), contentLength)
+	appData, err := txn.CrossProcess.CreateAppData(txn.FinalName, txn.Queuing, timeNow().Sub(txn.Start), contentLength)
 	if err != nil {
 		txn.Config.Logger.Debug("error generating outbound response header", map[string]interface{}{
 			"error": err,
@@ -433,6 +771,18 @@ func addCrossProcessHeaders(txn *txn, hdr http.Header) {
 	}
 }
 
+// Lock acquires the transaction's mutex, measuring the time spent waiting
+// for it if Config.RecordTransactionLockWait is enabled.
+func (txn *txn) Lock() {
+	if !txn.Config.RecordTransactionLockWait {
+		txn.Mutex.Lock()
+		return
+	}
+	start := time.Now()
+	txn.Mutex.Lock()
+	txn.lockWait += time.Since(start)
+}
+
 func (thd *thread) End(recovered interface{}) error {
 	txn := thd.txn
 	txn.Lock()
@@ -445,13 +795,53 @@ func (thd *thread) End(recovered interface{}) error {
 	txn.finished = true
 
 	if nil != recovered {
-		e := txnErrorFromPanic(time.Now(), recovered)
-		e.Stack = getStackTrace()
-		thd.noticeErrorInternal(e, nil, false)
-		log.Println(string(debug.Stack()))
+		txn.panicked = true
+		if txn.Config.ErrorCollector.RecordPanics {
+			e := txnErrorFromPanic(timeNow(), recovered)
+			e.Stack = getStackTrace()
+			thd.noticeErrorInternal(e, nil, false)
+			log.Println(string(debug.Stack()))
+		}
 	}
 
-	txn.markEnd(time.Now(), thd.thread)
+	txn.markEnd(timeNow(), thd.thread)
+	if t := txn.Config.CaptureGoroutineCountThreshold; t > 0 && txn.Duration > t {
+		txn.Attrs.Agent.Add(AttributeGoroutineCount, "", runtime.NumGoroutine())
+	}
+	if t := txn.Config.CaptureMemStatsThreshold; t > 0 && txn.Duration > t {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		txn.Attrs.Agent.Add(AttributeHeapAlloc, "", ms.HeapAlloc)
+	}
+	if txn.Config.RecordTransactionStartTime {
+		txn.Attrs.Agent.Add(AttributeTransactionStartTime, txn.Start.UTC().Format(time.RFC3339Nano), nil)
+	}
+	if txn.Config.RecordTransactionLockWait {
+		txn.Attrs.Agent.Add(AttributeTransactionLockWait, "", txn.lockWait.Seconds())
+	}
+	if txn.bodyBytesRead > 0 {
+		txn.Attrs.Agent.Add(AttributeRequestBodyBytesRead, "", txn.bodyBytesRead)
+	}
+	for attr, count := range txn.logCounts {
+		txn.Attrs.Agent.Add(attr, "", count)
+	}
+	if txn.busyTime > 0 {
+		txn.Attrs.Agent.Add(AttributeBusyTime, "", txn.busyTime.Seconds())
+	}
+	if txn.Queuing > 0 {
+		txn.Attrs.Agent.Add(AttributeQueueDuration, "", float64(txn.Queuing)/float64(time.Millisecond))
+	}
+	if txn.totalErrors > 0 {
+		txn.Attrs.Agent.Add(AttributeTotalErrors, "", txn.totalErrors)
+		txn.Attrs.Agent.Add(AttributeDistinctErrorClasses, "", len(txn.errorClasses))
+	}
+	if txn.latencyTarget > 0 {
+		txn.Attrs.Agent.Add(AttributeMetLatencyTarget, "", txn.Duration <= txn.latencyTarget)
+	}
+	if txn.attributesDisabled {
+		txn.dropAttributes()
+	}
+	txn.applyNameCallback()
 	txn.freezeName()
 	// Make a sampling decision if there have been no segments or outbound
 	// payloads.
@@ -466,16 +856,11 @@ func (thd *thread) End(recovered interface{}) error {
 
 	// Assign apdexThreshold regardless of whether or not the transaction
 	// gets apdex since it may be used to calculate the trace threshold.
-	txn.ApdexThreshold = internal.CalculateApdexThreshold(txn.Reply, txn.FinalName)
+	txn.ApdexThreshold = txn.apdexThreshold(txn.FinalName)
 
-	if txn.getsApdex() {
-		if txn.HasErrors() && txn.NoticeErrors() {
-			txn.Zone = apdexFailing
-		} else {
-			txn.Zone = calculateApdexZone(txn.ApdexThreshold, txn.Duration)
-		}
-	} else {
-		txn.Zone = apdexNone
+	txn.Zone = txn.apdexZone(txn.FinalName, txn.ApdexThreshold, txn.Duration)
+	if txn.Zone != apdexNone && !txn.attributesDisabled {
+		txn.Attrs.Agent.Add(AttributeApdexThreshold, "", txn.ApdexThreshold.Milliseconds())
 	}
 
 	if txn.Config.Logger.DebugEnabled() {
@@ -532,12 +917,25 @@ func (thd *thread) End(recovered interface{}) error {
 	}
 
 	if !txn.ignore {
-		txn.app.Consume(txn.Reply.RunID, txn)
+		txn.app.recentTxns.add(TransactionSummary{
+			Name:       txn.FinalName,
+			Duration:   txn.Duration,
+			ApdexZone:  ApdexZone(txn.Zone.label()),
+			ErrorsSeen: txn.errorsSeen,
+		})
+		txn.app.errRate.add(txn.Stop, txn.NoticeErrors())
+		txn.app.consumeTxn(txn.Reply.RunID, txn)
 		if observer := txn.app.getObserver(); nil != observer {
 			for _, evt := range txn.SpanEvents {
 				observer.consumeSpan(evt)
 			}
 		}
+	} else {
+		captureErrors := txn.Config.ErrorCollector.CaptureIgnoredTransactionErrors && txn.HasErrors()
+		if captureErrors && txn.FinalName == "" {
+			txn.FinalName = txn.createName()
+		}
+		txn.app.consumeTxn(txn.Reply.RunID, ignoredTxn{txn: txn, captureErrors: captureErrors})
 	}
 
 	// Note that if a consumer uses `panic(nil)`, the panic will not
@@ -555,11 +953,262 @@ func (txn *txn) AddUserID(userID string) error {
 	if txn.finished {
 		return errAlreadyEnded
 	}
+	// The user ID may identify a person, so it is not recorded in high
+	// security mode.
+	if txn.Config.HighSecurity {
+		return errHighSecurityEnabled
+	}
+	if len(userID) > attributeValueLengthLimit {
+		return errUserIDTooLong
+	}
 
 	txn.Attrs.Agent.Add(AttributeUserID, userID, nil)
 	return nil
 }
 
+// validRequestID returns false if the request ID contains characters which
+// could forge or break the lines of logs decorated with it: whitespace,
+// control characters, and the '|' separator of the NR-LINKING payload.
+func validRequestID(id string) bool {
+	for _, r := range id {
+		if r == '|' || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func (txn *txn) SetRequestID(id string) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	if txn.Config.HighSecurity {
+		return errHighSecurityEnabled
+	}
+	if !validRequestID(id) {
+		return errInvalidRequestID
+	}
+
+	txn.requestID = truncateStringValueIfLong(id)
+	txn.Attrs.Agent.Add(AttributeRequestID, id, nil)
+	return nil
+}
+
+// getRequestID returns the request ID for linking metadata and decorated
+// logs.  It is empty if the "request.id" attribute is excluded from every
+// destination by the attribute configuration or Config.AttributeFilter.
+func (txn *txn) getRequestID() string {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.requestID == "" || nil == txn.Attrs {
+		return txn.requestID
+	}
+	dests := txn.Attrs.config.agentDests[AttributeRequestID]
+	if 0 == applyAttributeFilter(txn.Attrs.config, AttributeRequestID, txn.requestID, dests) {
+		return ""
+	}
+	return txn.requestID
+}
+
+func (txn *txn) MarkSuccess() error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+
+	txn.markedSuccess = true
+	return nil
+}
+
+func (txn *txn) RecordBytesRead(n int64) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+
+	if n > 0 {
+		txn.bodyBytesRead += n
+	}
+	return nil
+}
+
+func (txn *txn) DisableAttributes() error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+
+	txn.attributesDisabled = true
+	txn.dropAttributes()
+	return nil
+}
+
+// dropAttributes discards all attributes captured so far, including those
+// attached to noticed errors.
+func (txn *txn) dropAttributes() {
+	txn.Attrs = newAttributes(txn.Attrs.config)
+	for _, e := range txn.Errors {
+		e.ExtraAttributes = nil
+	}
+}
+
+func (txn *txn) SetRoutePattern(pattern string) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	if len(pattern) > attributeValueLengthLimit {
+		return errRoutePatternTooLong
+	}
+
+	txn.Attrs.Agent.Add(AttributeRoutePattern, pattern, nil)
+	return nil
+}
+
+func (txn *txn) SetLatencyTarget(target time.Duration) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	if target <= 0 {
+		return errInvalidLatencyTarget
+	}
+
+	txn.latencyTarget = target
+	return nil
+}
+
+func (txn *txn) SetAttemptNumber(n int) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	if n < 0 {
+		return errNegativeAttemptNumber
+	}
+
+	txn.Attrs.Agent.Add(AttributeAttemptNumber, "", n)
+	return nil
+}
+
+func (txn *txn) AddBusyTime(d time.Duration) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	if d < 0 {
+		return errNegativeBusyTime
+	}
+
+	txn.busyTime += d
+	return nil
+}
+
+func (txn *txn) PreserveErrorMessages() error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	if !txn.Config.AllowPerTxnSecurityOverride {
+		return errOverrideDisabled
+	}
+
+	txn.preserveErrorMessages = true
+	return nil
+}
+
+// redactsErrorMessages returns true if high security mode removes the
+// messages of the transaction's errors.
+func (txn *txn) redactsErrorMessages() bool {
+	return txn.Config.HighSecurity && !txn.preserveErrorMessages
+}
+
+func (txn *txn) RecordScopedMetric(name string, value float64) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	if math.IsNaN(value) {
+		return errMetricNaN
+	}
+	if math.IsInf(value, 0) {
+		return errMetricInf
+	}
+	if name == "" {
+		return errMetricNameEmpty
+	}
+
+	if nil == txn.scopedMetrics {
+		txn.scopedMetrics = make(map[string]*metricData)
+	}
+	d := time.Duration(value * float64(time.Second))
+	m := metricDataFromDuration(d, d)
+	name = customMetricName(txn.Config.CustomMetricPrefix, name)
+	if data, ok := txn.scopedMetrics[name]; ok {
+		data.aggregate(m)
+	} else {
+		txn.scopedMetrics[name] = &m
+	}
+	return nil
+}
+
+func (txn *txn) SetColdStart(coldStart bool) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+
+	txn.Attrs.Agent.Add(AttributeAWSLambdaColdStart, "", coldStart)
+	return nil
+}
+
+func (txn *txn) SetStartTime(start time.Time) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	if start.IsZero() || start.After(timeNow()) {
+		return errInvalidStartTime
+	}
+	// Segments and response codes already recorded are timed relative to
+	// the original start.
+	if txn.stamp > 0 || txn.wroteHeader || len(txn.asyncThreads) > 0 {
+		return errStartTimeAfterWrites
+	}
+
+	txn.Start = start
+	txn.mainThread.start = start
+	return nil
+}
+
+func (txn *txn) SetOperationName(op string) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	if len(op) > attributeValueLengthLimit {
+		return errOperationNameTooLong
+	}
+
+	txn.operationName = op
+	txn.Attrs.Agent.Add(AttributeOperationName, op, nil)
+	return nil
+}
+
 func (txn *txn) AddAttribute(name string, value interface{}) error {
 	txn.Lock()
 	defer txn.Unlock()
@@ -572,37 +1221,111 @@ func (txn *txn) AddAttribute(name string, value interface{}) error {
 		return errSecurityPolicy
 	}
 
+	if txn.attributesDisabled {
+		return errAttributesDisabled
+	}
+
 	if txn.finished {
 		return errAlreadyEnded
 	}
 
-	return addUserAttribute(txn.Attrs, name, value, destAll)
+	err := addUserAttribute(txn.Attrs, name, value, destAll)
+	switch err.(type) {
+	case userAttributeLimitErr, errTooManyTxnAttributes:
+		txn.userAttrsDropped++
+	}
+	return err
 }
 
 var (
-	errorsDisabled        = errors.New("errors disabled")
-	errNilError           = errors.New("nil error")
-	errAlreadyEnded       = errors.New("transaction has already ended")
-	errSecurityPolicy     = errors.New("disabled by security policy")
-	errTransactionIgnored = errors.New("transaction has been ignored")
-	errBrowserDisabled    = errors.New("browser disabled by local configuration")
+	errorsDisabled         = errors.New("errors disabled")
+	errNilError            = errors.New("nil error")
+	errAlreadyEnded        = errors.New("transaction has already ended")
+	errSecurityPolicy      = errors.New("disabled by security policy")
+	errTransactionIgnored  = errors.New("transaction has been ignored")
+	errBrowserDisabled     = errors.New("browser disabled by local configuration")
+	errAttributesDisabled  = errors.New("attributes disabled for this transaction")
+	errRoutePatternTooLong = fmt.Errorf("route pattern exceeds length limit %d",
+		attributeValueLengthLimit)
+	errOperationNameTooLong = fmt.Errorf("operation name exceeds length limit %d",
+		attributeValueLengthLimit)
+	errUserIDTooLong = fmt.Errorf("user ID exceeds length limit %d",
+		attributeValueLengthLimit)
+	errInvalidLatencyTarget  = errors.New("latency target must be positive")
+	errNegativeAttemptNumber = errors.New("attempt number must not be negative")
+	errNegativeBusyTime      = errors.New("busy time must not be negative")
+	errOverrideDisabled      = errors.New("per transaction security override is not allowed by Config.AllowPerTxnSecurityOverride")
+	errInvalidStartTime      = errors.New("start time must be non-zero and not in the future")
+	errInvalidRequestID      = errors.New("request ID must not contain whitespace, control characters, or '|'")
+	errStartTimeAfterWrites  = errors.New("start time cannot be set after segments or a response code are recorded")
 )
 
+// ErrWriteAfterEnd is returned by the Write method of the http.ResponseWriter
+// returned by Transaction.SetWebResponse after the transaction has ended, when
+// Config.WritesAfterEnd.ReturnError is enabled.
+var ErrWriteAfterEnd = errors.New("write after transaction ended")
+
 const (
 	highSecurityErrorMsg   = "message removed by high security setting"
 	securityPolicyErrorMsg = "message removed by security policy"
 )
 
-func (thd *thread) noticeErrorInternal(errData errorData, err error, expect bool) error {
+// countError tracks the number of errors noticed and their distinct classes.
+func (txn *txn) countError(klass string) {
+	txn.totalErrors++
+	if nil == txn.errorClasses {
+		txn.errorClasses = make(map[string]struct{})
+	}
+	if len(txn.errorClasses) < maxDistinctErrorClasses {
+		txn.errorClasses[klass] = struct{}{}
+	}
+}
+
+// shouldRecordErrorClass returns false if Config.ErrorCollector.RecordOnlyClasses
+// is set and does not contain the error class.
+func (txn *txn) shouldRecordErrorClass(klass string) bool {
+	only := txn.Config.ErrorCollector.RecordOnlyClasses
+	if len(only) == 0 {
+		return true
+	}
+	for _, c := range only {
+		if c == klass {
+			return true
+		}
+	}
+	return false
+}
+
+// noticeErrorInternal notices the error, returning whether it was stored to
+// be reported as an error trace and error event.  Errors are not stored if
+// their class is excluded by Config.ErrorCollector.RecordOnlyClasses or if
+// the transaction has already stored maxTxnErrors errors.
+func (thd *thread) noticeErrorInternal(errData errorData, err error, expect bool) (bool, error) {
 	txn := thd.txn
 	if !txn.Config.ErrorCollector.Enabled {
-		return errorsDisabled
+		return false, errorsDisabled
 	}
 
-	if !expect {
-		thd.noticeErrors = true
-	} else {
-		thd.expectedErrors = true
+	// Informational errors do not count towards error metrics, error
+	// counts, or apdex.
+	if !errData.InfoOnly {
+		if !expect {
+			thd.noticeErrors = true
+			if !errData.Handled {
+				thd.unhandledErrors = true
+			}
+			txn.errorsSeen++
+		} else {
+			thd.expectedErrors = true
+		}
+		txn.countError(errData.Klass)
+	}
+
+	if !txn.shouldRecordErrorClass(errData.Klass) {
+		if !errData.InfoOnly {
+			txn.txnData.txnEvent.HasError = true
+		}
+		return false, nil
 	}
 
 	if nil == txn.Errors {
@@ -613,12 +1336,52 @@ func (thd *thread) noticeErrorInternal(errData errorData, err error, expect bool
 
 	if txn.shouldCollectSpanEvents() {
 		errData.SpanID = txn.CurrentSpanIdentifier(thd.thread)
-		addErrorAttrs(thd, errData)
+		if !errData.InfoOnly {
+			addErrorAttrs(thd, errData)
+		}
 	}
 
-	txn.Errors.Add(errData)
-	txn.txnData.txnEvent.HasError = true //mark transaction as having an error
-	return nil
+	stored := txn.Errors.Add(errData)
+	if !errData.InfoOnly {
+		txn.txnData.txnEvent.HasError = true //mark transaction as having an error
+	}
+	if stored && txn.Reply.CollectErrors {
+		txn.notifyOnError(errData)
+	}
+	return stored, nil
+}
+
+// notifyOnError calls Config.OnError for an error that has been recorded,
+// recovering any panic.
+func (txn *txn) notifyOnError(errData errorData) {
+	onError := txn.Config.OnError
+	if nil == onError {
+		return
+	}
+	defer func() {
+		if r := recover(); nil != r {
+			txn.Config.Logger.Error("panic in error callback", map[string]interface{}{
+				"panic": fmt.Sprint(r),
+			})
+		}
+	}()
+	// The name is not frozen until the transaction ends, so the name at
+	// the time of the error is given.
+	name := txn.FinalName
+	if name == "" {
+		name = txn.createName()
+	}
+	onError(ErrorInfo{
+		txnAttributes:   txn.Attrs,
+		TransactionName: name,
+		errAttributes:   errData.ExtraAttributes,
+		stackTrace:      errData.Stack,
+		Error:           errData.RawError,
+		TimeOccured:     errData.When,
+		Message:         scrubbedErrorMessage(errData.Msg, txn),
+		Class:           errData.Klass,
+		Expected:        errData.Expect,
+	})
 }
 
 var errorAttrs = []string{
@@ -682,7 +1445,7 @@ func errDataFromError(input error, expect bool) (data errorData, err error) {
 	cause := errorCause(input)
 	validatedErrorMsg := truncateStringMessageIfLong(input.Error())
 	data = errorData{
-		When:   time.Now(),
+		When:   timeNow(),
 		Msg:    validatedErrorMsg,
 		Expect: expect,
 	}
@@ -737,6 +1500,93 @@ func errDataFromError(input error, expect bool) (data errorData, err error) {
 }
 
 func (thd *thread) NoticeError(input error, expect bool) error {
+	_, err := thd.noticeError(input, noticeErrorParams{Expect: expect})
+	return err
+}
+
+func (thd *thread) NoticeHandledError(input error) error {
+	_, err := thd.noticeError(input, noticeErrorParams{Handled: true})
+	return err
+}
+
+func (thd *thread) NoticeErrorWithAttributes(input error, attrs map[string]interface{}) error {
+	_, err := thd.noticeError(input, noticeErrorParams{Attributes: attrs})
+	return err
+}
+
+// NoticeErrorWithGroupAndAttributes records the error with an explicit error
+// group, which takes precedence over Config.ErrorCollector.ErrorGroupCallback.
+func (thd *thread) NoticeErrorWithGroupAndAttributes(input error, group string, attrs map[string]interface{}) error {
+	_, err := thd.noticeError(input, noticeErrorParams{Attributes: attrs, Group: group})
+	return err
+}
+
+// NoticeErrorWithID records the error along with a newly generated
+// identifier, which is returned.  The identifier is empty if the error is
+// not recorded.
+func (thd *thread) NoticeErrorWithID(input error) (string, error) {
+	id := thd.txn.TraceIDGenerator.GenerateTraceID()
+	stored, err := thd.noticeError(input, noticeErrorParams{ID: id})
+	if nil != err || !stored {
+		return "", err
+	}
+	return id, nil
+}
+
+func (thd *thread) NoticeErrorWithSeverity(input error, severity string) error {
+	_, err := thd.noticeError(input, noticeErrorParams{Severity: severity})
+	return err
+}
+
+// isExpectedSeverity returns true if errors with the given severity are
+// configured to be treated as expected errors.
+func (txn *txn) isExpectedSeverity(severity string) bool {
+	if severity == "" {
+		return false
+	}
+	for _, s := range txn.Config.ErrorCollector.ExpectSeverities {
+		if strings.EqualFold(strings.TrimSpace(s), severity) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHighSecuritySafeAttributeValue returns true for attribute values that
+// cannot contain free text, and so are recorded on errors noticed with
+// Transaction.NoticeErrorWithAttributes even when high security is enabled.
+func isHighSecuritySafeAttributeValue(val interface{}) bool {
+	switch val.(type) {
+	case bool,
+		uint8, uint16, uint32, uint64, int8, int16, int32, int64,
+		uint, int:
+		return true
+	default:
+		return false
+	}
+}
+
+// NoticePanic records a value recovered from a panic as an error, in the
+// same way that a panic recovered by End is recorded.
+func (thd *thread) NoticePanic(recovered interface{}) error {
+	txn := thd.txn
+	txn.Lock()
+	defer txn.Unlock()
+
+	if txn.finished {
+		return errAlreadyEnded
+	}
+
+	txn.panicked = true
+	e := txnErrorFromPanic(timeNow(), recovered)
+	e.Stack = getStackTrace()
+	_, err := thd.noticeErrorInternal(e, nil, false)
+	return err
+}
+
+// RecordErrorInfoOnly records the error in error traces and events without
+// affecting error metrics, error counts, or apdex.
+func (thd *thread) RecordErrorInfoOnly(input error) error {
 	txn := thd.txn
 	txn.Lock()
 	defer txn.Unlock()
@@ -749,15 +1599,83 @@ func (thd *thread) NoticeError(input error, expect bool) error {
 		return errNilError
 	}
 
-	data, err := errDataFromError(input, expect)
+	data, err := errDataFromError(input, true)
 	if nil != err {
 		return err
 	}
-
+	data.InfoOnly = true
 	if txn.Config.HighSecurity || !txn.Reply.SecurityPolicies.CustomParameters.Enabled() {
 		data.ExtraAttributes = nil
 	}
 
+	_, err = thd.noticeErrorInternal(data, input, true)
+	return err
+}
+
+// noticeErrorParams contains the parameters for noticeError.
+type noticeErrorParams struct {
+	Attributes map[string]interface{}
+	ID         string
+	Severity   string
+	Group      string
+	Expect     bool
+	Handled    bool
+}
+
+// noticeError notices the error, returning whether it was stored.
+func (thd *thread) noticeError(input error, p noticeErrorParams) (bool, error) {
+	txn := thd.txn
+	txn.Lock()
+	defer txn.Unlock()
+
+	if txn.finished {
+		return false, errAlreadyEnded
+	}
+
+	if nil == input {
+		return false, errNilError
+	}
+
+	expect := p.Expect
+	severity := truncateStringValueIfLong(strings.TrimSpace(p.Severity))
+	if txn.isExpectedSeverity(severity) {
+		expect = true
+	}
+
+	data, err := errDataFromError(input, expect)
+	if nil != err {
+		return false, err
+	}
+	data.Handled = p.Handled
+	data.ID = p.ID
+	data.Severity = severity
+	data.ErrorGroup = truncateStringValueIfLong(strings.TrimSpace(p.Group))
+
+	// High security allows attribute values which cannot contain free
+	// text, whereas the custom parameters security policy forbids all
+	// custom error attributes.
+	forbidden := !txn.Reply.SecurityPolicies.CustomParameters.Enabled()
+	if txn.Config.HighSecurity || forbidden {
+		data.ExtraAttributes = nil
+	}
+
+	for key, val := range p.Attributes {
+		val, err := validateUserAttribute(key, val)
+		if nil != err {
+			return false, err
+		}
+		if forbidden || (txn.Config.HighSecurity && !isHighSecuritySafeAttributeValue(val)) {
+			continue
+		}
+		if nil == data.ExtraAttributes {
+			data.ExtraAttributes = make(map[string]interface{})
+		}
+		data.ExtraAttributes[key] = val
+	}
+	if len(data.ExtraAttributes) > attributeErrorLimit {
+		return false, errTooManyErrorAttributes
+	}
+
 	return thd.noticeErrorInternal(data, input, expect)
 }
 
@@ -773,6 +1691,18 @@ func (txn *txn) SetName(name string) error {
 	return nil
 }
 
+func (txn *txn) ClearName() error {
+	txn.Lock()
+	defer txn.Unlock()
+
+	if txn.finished {
+		return errAlreadyEnded
+	}
+
+	txn.Name = ""
+	return nil
+}
+
 func (txn *txn) GetName() string {
 	txn.Lock()
 	defer txn.Unlock()
@@ -787,6 +1717,7 @@ func (txn *txn) Ignore() error {
 		return errAlreadyEnded
 	}
 	txn.ignore = true
+	txn.ignoredByUser = true
 	return nil
 }
 
@@ -864,7 +1795,7 @@ func (txn *txn) BrowserTimingHeader() (*BrowserTimingHeader, error) {
 			ApplicationID:         txn.Reply.AppID,
 			TransactionName:       name,
 			QueueTimeMillis:       txn.Queuing.Nanoseconds() / (1000 * 1000),
-			ApplicationTimeMillis: time.Since(txn.Start).Nanoseconds() / (1000 * 1000),
+			ApplicationTimeMillis: timeNow().Sub(txn.Start).Nanoseconds() / (1000 * 1000),
 			ObfuscatedAttributes:  attrs,
 			ErrorBeacon:           txn.Reply.ErrorBeacon,
 			Agent:                 txn.Reply.JSAgentFile,
@@ -956,6 +1887,8 @@ func endDatastore(s *DatastoreSegment) error {
 		Host:               s.Host,
 		PortPathOrID:       s.PortPathOrID,
 		Database:           s.DatabaseName,
+		RowsAffected:       s.RowsAffected,
+		RowsReturned:       s.RowsReturned,
 		ThisHost:           txn.appRun.Config.hostname,
 	})
 }
@@ -1023,6 +1956,7 @@ func endExternal(s *ExternalSegment) error {
 		Library:    s.Library,
 		Method:     externalSegmentMethod(s),
 		StatusCode: s.statusCode,
+		Timings:    s.timings,
 	})
 }
 
@@ -1199,6 +2133,24 @@ func (txn *txn) AcceptDistributedTraceHeaders(t TransportType, hdrs http.Header)
 	return txn.acceptDistributedTraceHeadersLocked(t, hdrs)
 }
 
+// AcceptDistributedTracePayload accepts distributed trace headers serialized
+// as a JSON object.  A payload which cannot be parsed is ignored and
+// recorded as a parse exception.
+func (txn *txn) AcceptDistributedTracePayload(payload string) error {
+	hdrs, err := DistributedTraceHeadersFromJSON(payload)
+
+	txn.Lock()
+	defer txn.Unlock()
+
+	if nil != err {
+		if txn.BetterCAT.Enabled && !txn.finished {
+			txn.DistributedTracingSupport.AcceptPayloadParseException = true
+		}
+		return nil
+	}
+	return txn.acceptDistributedTraceHeadersLocked(TransportUnknown, hdrs)
+}
+
 func (txn *txn) acceptDistributedTraceHeadersLocked(t TransportType, hdrs http.Header) error {
 
 	if !txn.BetterCAT.Enabled {
@@ -1357,6 +2309,7 @@ func (thd *thread) GetLinkingMetadata() (metadata LinkingMetadata) {
 	md := thd.GetTraceMetadata()
 	metadata.TraceID = md.TraceID
 	metadata.SpanID = md.SpanID
+	metadata.RequestID = txn.getRequestID()
 
 	return
 }
@@ -1372,6 +2325,56 @@ func (txn *txn) IsSampled() bool {
 	return txn.lazilyCalculateSampled()
 }
 
+func (txn *txn) ErrorsEnabled() bool {
+	txn.Lock()
+	defer txn.Unlock()
+
+	if txn.finished {
+		return false
+	}
+
+	return txn.Config.ErrorCollector.Enabled && txn.Reply.CollectErrors
+}
+
+func (txn *txn) getApdexThreshold() time.Duration {
+	txn.Lock()
+	defer txn.Unlock()
+
+	if !txn.finished || !txn.getsApdex() {
+		return 0
+	}
+	return txn.ApdexThreshold
+}
+
+func (txn *txn) CurrentApdexZone() ApdexZone {
+	txn.Lock()
+	defer txn.Unlock()
+
+	if txn.finished {
+		return ApdexZone(txn.Zone.label())
+	}
+	if !txn.getsApdex() {
+		return ApdexNone
+	}
+
+	name := txn.createName()
+	threshold := txn.apdexThreshold(name)
+	return ApdexZone(txn.apdexZone(name, threshold, timeNow().Sub(txn.Start)).label())
+}
+
+func (txn *txn) Elapsed() time.Duration {
+	txn.Lock()
+	defer txn.Unlock()
+
+	if txn.finished {
+		return txn.Duration
+	}
+	if d := timeNow().Sub(txn.Start); d > 0 {
+		return d
+	}
+	return 0
+}
+
 func (txn *txn) getCsecData() any {
 	txn.Lock()
 	defer txn.Unlock()
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"net/http"
 	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
@@ -528,8 +529,18 @@ func TestNilTransaction(t *testing.T) {
 	txn.End()
 	txn.Ignore()
 	txn.SetName("hello")
+	txn.ClearName()
 	txn.NoticeError(errors.New("something"))
+	txn.NoticeHandledError(errors.New("something"))
 	txn.AddAttribute("myKey", "myValue")
+	txn.SetRoutePattern("/hello/:id")
+	txn.SetRequestID("request-id")
+	txn.DisableAttributes()
+	txn.NoticeErrorWithID(errors.New("hello"))
+	txn.SetOperationName("op")
+	txn.NoticeErrorWithAttributes(errors.New("zip"), map[string]interface{}{"code": 1})
+	txn.RecordBytesRead(1)
+	txn.MarkSuccess()
 	txn.SetWebRequestHTTP(helloRequest)
 	var x dummyResponseWriter
 	if w := txn.SetWebResponse(x); w != x {
@@ -565,6 +576,35 @@ func TestNilTransaction(t *testing.T) {
 	if s := txn.IsSampled(); s {
 		t.Error(s)
 	}
+	if a := txn.ApdexThreshold(); a != 0 {
+		t.Error(a)
+	}
+	if s, tol := txn.ApdexThresholds(); s != 0 || tol != 0 {
+		t.Error(s, tol)
+	}
+	done := make(chan struct{})
+	txn.Go(func() { close(done) })
+	txn.NoticeErrorWithSeverity(errors.New("my error"), "warning")
+	txn.SetLatencyTarget(time.Second)
+	txn.NoticeErrorWithGroupAndAttributes(errors.New("problem"), "group", map[string]interface{}{"zip": 1})
+	txn.RecordScopedMetric("zip", 1)
+	txn.PreserveErrorMessages()
+	txn.AddBusyTime(time.Second)
+	txn.SetColdStart(true)
+	txn.SetAttemptNumber(2)
+	txn.SetStartTime(time.Now())
+	txn.RecordErrorInfoOnly(errors.New("info"))
+	txn.RecordLog(LogData{Message: "my log", Severity: "info"})
+	<-done
+	if txn.ErrorsEnabled() {
+		t.Error("errors should not be enabled")
+	}
+	if z := txn.CurrentApdexZone(); z != ApdexNone {
+		t.Error(z)
+	}
+	if d := txn.Elapsed(); d != 0 {
+		t.Error(d)
+	}
 }
 
 func TestGetName(t *testing.T) {
@@ -593,8 +633,18 @@ func TestEmptyTransaction(t *testing.T) {
 	txn.End()
 	txn.Ignore()
 	txn.SetName("hello")
+	txn.ClearName()
 	txn.NoticeError(errors.New("something"))
+	txn.NoticeHandledError(errors.New("something"))
 	txn.AddAttribute("myKey", "myValue")
+	txn.SetRoutePattern("/hello/:id")
+	txn.SetRequestID("request-id")
+	txn.DisableAttributes()
+	txn.NoticeErrorWithID(errors.New("hello"))
+	txn.SetOperationName("op")
+	txn.NoticeErrorWithAttributes(errors.New("zip"), map[string]interface{}{"code": 1})
+	txn.RecordBytesRead(1)
+	txn.MarkSuccess()
 	txn.SetWebRequestHTTP(helloRequest)
 	var x dummyResponseWriter
 	if w := txn.SetWebResponse(x); w != x {
@@ -627,6 +677,35 @@ func TestEmptyTransaction(t *testing.T) {
 	if s := txn.IsSampled(); s {
 		t.Error(s)
 	}
+	if a := txn.ApdexThreshold(); a != 0 {
+		t.Error(a)
+	}
+	if s, tol := txn.ApdexThresholds(); s != 0 || tol != 0 {
+		t.Error(s, tol)
+	}
+	done := make(chan struct{})
+	txn.Go(func() { close(done) })
+	txn.NoticeErrorWithSeverity(errors.New("my error"), "warning")
+	txn.SetLatencyTarget(time.Second)
+	txn.NoticeErrorWithGroupAndAttributes(errors.New("problem"), "group", map[string]interface{}{"zip": 1})
+	txn.RecordScopedMetric("zip", 1)
+	txn.PreserveErrorMessages()
+	txn.AddBusyTime(time.Second)
+	txn.SetColdStart(true)
+	txn.SetAttemptNumber(2)
+	txn.SetStartTime(time.Now())
+	txn.RecordErrorInfoOnly(errors.New("info"))
+	txn.RecordLog(LogData{Message: "my log", Severity: "info"})
+	<-done
+	if txn.ErrorsEnabled() {
+		t.Error("errors should not be enabled")
+	}
+	if z := txn.CurrentApdexZone(); z != ApdexNone {
+		t.Error(z)
+	}
+	if d := txn.Elapsed(); d != 0 {
+		t.Error(d)
+	}
 }
 
 func TestDTPriority(t *testing.T) {
@@ -961,3 +1040,97 @@ func TestErrAttrsAddedWhenPanic(t *testing.T) {
 		},
 	})
 }
+
+func TestSetStartTime(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	start := time.Now().Add(-time.Hour)
+	txn.SetStartTime(start)
+	app.expectNoLoggedErrors(t)
+	seg := txn.StartSegment("seg")
+	seg.End()
+	txn.End()
+
+	if !txn.thread.Start.Equal(start) {
+		t.Error(txn.thread.Start)
+	}
+	if d := txn.thread.Duration; d < time.Hour {
+		t.Error(d)
+	}
+	if d := txn.thread.TotalTime; d < time.Hour {
+		t.Error(d)
+	}
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":      "OtherTransaction/Go/hello",
+			"timestamp": float64(timeToIntMillis(start)),
+		},
+	}})
+}
+
+func TestSetStartTimeInvalid(t *testing.T) {
+	app := testApp(nil, nil, t)
+	txn := app.StartTransaction("hello")
+	txn.SetStartTime(time.Time{})
+	app.expectSingleLoggedError(t, "unable to set start time", map[string]interface{}{
+		"reason": errInvalidStartTime.Error(),
+	})
+	app = testApp(nil, nil, t)
+	txn = app.StartTransaction("hello")
+	txn.SetStartTime(time.Now().Add(time.Hour))
+	app.expectSingleLoggedError(t, "unable to set start time", map[string]interface{}{
+		"reason": errInvalidStartTime.Error(),
+	})
+	app = testApp(nil, nil, t)
+	txn = app.StartTransaction("hello")
+	txn.StartSegment("seg").End()
+	txn.SetStartTime(time.Now().Add(-time.Hour))
+	app.expectSingleLoggedError(t, "unable to set start time", map[string]interface{}{
+		"reason": errStartTimeAfterWrites.Error(),
+	})
+	txn.End()
+	txn.SetStartTime(time.Now().Add(-time.Hour))
+	app.expectSingleLoggedError(t, "unable to set start time", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+}
+
+func TestTxnEventPriorityIntrinsic(t *testing.T) {
+	// The sampling priority is already recorded as the "priority"
+	// intrinsic of the transaction event when distributed tracing is
+	// enabled.
+	app := testApp(nil, nil, t)
+	txn := app.StartTransaction("hello")
+	txn.End()
+	events := app.Application.app.testHarvest.TxnEvents.events
+	if len(events) != 1 {
+		t.Fatal(len(events))
+	}
+	event := events[0].jsonWriter.(*txnEvent)
+	if event.BetterCAT.Priority != events[0].priority {
+		t.Error(event.BetterCAT.Priority, events[0].priority)
+	}
+	js, _ := events[0].priority.MarshalJSON()
+	priority, err := strconv.ParseFloat(string(js), 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":     "OtherTransaction/Go/hello",
+			"guid":     internal.MatchAnything,
+			"traceId":  internal.MatchAnything,
+			"priority": priority,
+			"sampled":  internal.MatchAnything,
+		},
+	}})
+
+	app = testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn = app.StartTransaction("hello")
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+	}})
+}
@@ -8,7 +8,15 @@ import (
 )
 
 const (
-	expectErrorAttr = "error.expected"
+	expectErrorAttr   = "error.expected"
+	handledErrorAttr  = "error.handled"
+	errorSourceAttr   = "error.source"
+	errorIDAttr       = "error.id"
+	errorSeverityAttr = "error.severity"
+
+	// errorSourceRecoveredPanic is the error.source value of errors
+	// created from panics recovered by Transaction.End.
+	errorSourceRecoveredPanic = "recovered_panic"
 )
 
 func addOptionalStringField(w *jsonFieldsWriter, key, value string) {
@@ -17,7 +25,7 @@ func addOptionalStringField(w *jsonFieldsWriter, key, value string) {
 	}
 }
 
-func intrinsicsJSON(e *txnEvent, buf *bytes.Buffer, expect bool) {
+func intrinsicsJSON(e *txnEvent, buf *bytes.Buffer, expect bool, errorID string) {
 	w := jsonFieldsWriter{buf: buf}
 
 	buf.WriteByte('{')
@@ -35,6 +43,8 @@ func intrinsicsJSON(e *txnEvent, buf *bytes.Buffer, expect bool) {
 		w.stringField(expectErrorAttr, "true")
 	}
 
+	addOptionalStringField(&w, errorIDAttr, errorID)
+
 	if e.CrossProcess.Used() {
 		addOptionalStringField(&w, "client_cross_process_id", e.CrossProcess.ClientID)
 		addOptionalStringField(&w, "trip_id", e.CrossProcess.TripID)
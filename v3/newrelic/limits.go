@@ -14,6 +14,10 @@ const (
 	// collectorTimeout is the timeout used in the client for communication
 	// with New Relic's servers.
 	collectorTimeout = 20 * time.Second
+	// defaultGracefulShutdownTimeout is the time given to the trace
+	// observer by Application.GracefulShutdown when the context has no
+	// deadline.
+	defaultGracefulShutdownTimeout = 30 * time.Second
 	// appDataChanSize is the size of the channel that contains data sent
 	// the app processor.
 	appDataChanSize           = 200
@@ -46,6 +50,13 @@ const (
 	// provided when noticing an error.
 	attributeErrorLimit       = 32
 	customEventAttributeLimit = 64
+	// maxDistinctErrorClasses bounds the set of error classes tracked per
+	// transaction for AttributeDistinctErrorClasses.
+	maxDistinctErrorClasses = 64
+
+	// maxCapturedRequestBody is the number of bytes of the request body
+	// captured by Config.ErrorCollector.CaptureRequestBody.
+	maxCapturedRequestBody = 2048
 
 	// Limits affecting Config validation are found in the config package.
 
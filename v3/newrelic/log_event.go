@@ -7,24 +7,46 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"runtime"
 	"strings"
 	"time"
 
+	"github.com/newrelic/go-agent/v3/internal/jsonx"
 	"github.com/newrelic/go-agent/v3/internal/logcontext"
 )
 
 const (
 	// MaxLogLength is the maximum number of bytes the log message is allowed to be
 	MaxLogLength = 32768
+	// MaxLogStackTraceLength is the maximum number of bytes of a log's stack
+	// trace that are recorded.  Longer stack traces are truncated.
+	MaxLogStackTraceLength = 16384
 )
 
 type logEvent struct {
-	priority  priority
-	timestamp int64
-	severity  string
-	message   string
-	spanID    string
-	traceID   string
+	priority   priority
+	timestamp  int64
+	severity   string
+	message    string
+	spanID     string
+	traceID    string
+	loggerName string
+	// serviceName is the logical service which emitted the log.
+	serviceName string
+	// severityLevel is the numeric severity, or zero if unknown.
+	severityLevel int
+	// sourceFile and sourceLine locate the code which emitted the log.
+	sourceFile string
+	sourceLine int
+	// traceFlags are the W3C trace-flags of the transaction's sampling
+	// decision, or empty for logs recorded outside a transaction.
+	traceFlags string
+	// stackTrace is the stack trace given separately from the message.
+	stackTrace string
+	// errorClass and errorID identify the error noticed in the same
+	// transaction that the log is correlated with.
+	errorClass string
+	errorID    string
 }
 
 // LogData contains data fields that are needed to generate log events.
@@ -32,13 +54,103 @@ type LogData struct {
 	Timestamp int64  // Optional: Unix Millisecond Timestamp; A timestamp will be generated if unset
 	Severity  string // Optional: Severity of log being consumed
 	Message   string // Optional: Message of log being consumed; Maximum size: 32768 Bytes.
+
+	// LoggerName is optional: the name of the logger that emitted the log.
+	// Names longer than 255 bytes are truncated.
+	LoggerName string
+
+	// ServiceName is optional: the name of the logical service that emitted
+	// the log, allowing logs from several services sharing one Application
+	// to be told apart.  When empty, the first of the application's
+	// Config.AppName names is used.  Names longer than 255 bytes are
+	// truncated.
+	ServiceName string
+
+	// SeverityLevel is optional: the numeric severity of the log, recorded
+	// alongside Severity.  When zero, it is derived from Severity using the
+	// levels of LogSeverityLevel.
+	SeverityLevel int
+
+	// SourceFile and SourceLine are optional: the file name and line number
+	// of the code that emitted the log.  When SourceFile is empty and
+	// Config.ApplicationLogging.Forwarding.CaptureSource is enabled, they
+	// are set to the caller of RecordLog.
+	SourceFile string
+	SourceLine int
+
+	// StackTrace is optional: a stack trace to record separately from the
+	// message, as "error.stack".  Stack traces longer than
+	// MaxLogStackTraceLength bytes are truncated, independently of the
+	// message length limit.  When a log with an error severity and a stack
+	// trace is recorded in a transaction that has already noticed an error,
+	// the log records the class of the most recently noticed error, and its
+	// identifier if it was noticed using Transaction.NoticeErrorWithID, as
+	// "error.class" and "error.id".
+	StackTrace string
+}
+
+// logSeverityLevels maps uppercased severities to numeric levels.  The levels
+// follow the OpenTelemetry severity numbers.
+var logSeverityLevels = map[string]int{
+	"TRACE":    1,
+	"DEBUG":    5,
+	"INFO":     9,
+	"WARN":     13,
+	"WARNING":  13,
+	"ERROR":    17,
+	"CRITICAL": 21,
+	"FATAL":    21,
+	"PANIC":    21,
+}
+
+// logCountAttribute returns the transaction event attribute counting logs of
+// the numeric severity level.  The levels follow the OpenTelemetry severity
+// number ranges.
+func logCountAttribute(level int) string {
+	switch {
+	case level >= 1 && level <= 4:
+		return AttributeLogCountTrace
+	case level >= 5 && level <= 8:
+		return AttributeLogCountDebug
+	case level >= 9 && level <= 12:
+		return AttributeLogCountInfo
+	case level >= 13 && level <= 16:
+		return AttributeLogCountWarn
+	case level >= 17 && level <= 20:
+		return AttributeLogCountError
+	case level >= 21 && level <= 24:
+		return AttributeLogCountFatal
+	default:
+		return AttributeLogCountUnknown
+	}
+}
+
+// LogSeverityLevel returns the numeric level of a log severity string, such as
+// 9 for "info", or zero if the severity is not recognized.  Matching is case
+// insensitive.  Higher levels are more severe.
+func LogSeverityLevel(severity string) int {
+	return logSeverityLevels[strings.ToUpper(strings.TrimSpace(severity))]
 }
 
-// writeJSON prepares JSON in the format expected by the collector.
+// logTimestampRFC3339 is RFC 3339 with fixed millisecond precision, matching
+// the precision of the epoch milliseconds timestamp.
+const logTimestampRFC3339 = "2006-01-02T15:04:05.000Z07:00"
+
+// WriteJSON prepares JSON in the format expected by the collector.
 func (e *logEvent) WriteJSON(buf *bytes.Buffer) {
+	e.writeJSON(buf, false)
+}
+
+// writeJSON prepares JSON in the format expected by the collector, writing the
+// timestamp as an RFC 3339 string rather than epoch milliseconds when
+// rfc3339Time is true.
+func (e *logEvent) writeJSON(buf *bytes.Buffer, rfc3339Time bool) {
 	w := jsonFieldsWriter{buf: buf}
 	buf.WriteByte('{')
 	w.stringField(logcontext.LogSeverityFieldName, e.severity)
+	if e.severityLevel > 0 {
+		w.intField(logcontext.LogSeverityLevelFieldName, int64(e.severityLevel))
+	}
 	w.stringField(logcontext.LogMessageFieldName, e.message)
 
 	if len(e.spanID) > 0 {
@@ -47,10 +159,38 @@ func (e *logEvent) WriteJSON(buf *bytes.Buffer) {
 	if len(e.traceID) > 0 {
 		w.stringField(logcontext.LogTraceIDFieldName, e.traceID)
 	}
+	if len(e.traceFlags) > 0 {
+		w.stringField(logcontext.LogTraceFlagsFieldName, e.traceFlags)
+	}
+	if len(e.loggerName) > 0 {
+		w.stringField(logcontext.LogLoggerNameFieldName, e.loggerName)
+	}
+	if len(e.serviceName) > 0 {
+		w.stringField(logcontext.LogServiceNameFieldName, e.serviceName)
+	}
+	if len(e.sourceFile) > 0 {
+		w.stringField(logcontext.LogFileNameFieldName, e.sourceFile)
+	}
+	if e.sourceLine > 0 {
+		w.intField(logcontext.LogLineNumberFieldName, int64(e.sourceLine))
+	}
+	if len(e.stackTrace) > 0 {
+		w.stringField(logcontext.LogStackTraceFieldName, e.stackTrace)
+	}
+	if len(e.errorClass) > 0 {
+		w.stringField(logcontext.LogErrorClassFieldName, e.errorClass)
+	}
+	if len(e.errorID) > 0 {
+		w.stringField(logcontext.LogErrorIDFieldName, e.errorID)
+	}
 
 	w.needsComma = false
 	buf.WriteByte(',')
-	w.intField(logcontext.LogTimestampFieldName, e.timestamp)
+	if rfc3339Time {
+		w.stringField(logcontext.LogTimestampFieldName, time.UnixMilli(e.timestamp).Format(logTimestampRFC3339))
+	} else {
+		w.intField(logcontext.LogTimestampFieldName, e.timestamp)
+	}
 	buf.WriteByte('}')
 }
 
@@ -66,13 +206,36 @@ var (
 	errLogMessageTooLarge = fmt.Errorf("log message can not exceed %d bytes", MaxLogLength)
 )
 
-func (data *LogData) toLogEvent() (logEvent, error) {
+// scrubLogMessage applies the scrubber to the message.  A panic in the
+// scrubber is recovered and returned as an error, so that the log is dropped
+// rather than its unscrubbed message being recorded.
+func scrubLogMessage(scrubber func(string) string, message string) (scrubbed string, err error) {
+	defer func() {
+		if r := recover(); nil != r {
+			err = fmt.Errorf("panic in log message scrubber: %v", r)
+		}
+	}()
+	return scrubber(message), nil
+}
+
+// toLogEvent validates the log data and creates a log event from it.  The
+// message is passed through scrubber, if it is not nil, after being trimmed
+// and before its length is checked.
+func (data *LogData) toLogEvent(scrubber func(string) string) (logEvent, error) {
 	if data == nil {
 		return logEvent{}, errNilLogData
 	}
 	if data.Severity == "" {
 		data.Severity = logcontext.LogSeverityUnknown
 	}
+	data.Message = strings.TrimSpace(data.Message)
+	if scrubber != nil {
+		msg, err := scrubLogMessage(scrubber, data.Message)
+		if err != nil {
+			return logEvent{}, err
+		}
+		data.Message = msg
+	}
 	if len(data.Message) > MaxLogLength {
 		return logEvent{}, errLogMessageTooLarge
 	}
@@ -80,19 +243,54 @@ func (data *LogData) toLogEvent() (logEvent, error) {
 		data.Timestamp = int64(timeToUnixMilliseconds(time.Now()))
 	}
 
-	data.Message = strings.TrimSpace(data.Message)
 	data.Severity = strings.TrimSpace(data.Severity)
+	data.LoggerName = truncateStringValueIfLong(strings.TrimSpace(data.LoggerName))
+	data.ServiceName = truncateStringValueIfLong(strings.TrimSpace(data.ServiceName))
+	if data.SeverityLevel == 0 {
+		data.SeverityLevel = LogSeverityLevel(data.Severity)
+	}
+	if len(data.StackTrace) > MaxLogStackTraceLength {
+		data.StackTrace = stringLengthByteLimit(data.StackTrace, MaxLogStackTraceLength)
+	}
 
 	event := logEvent{
-		priority:  newPriority(),
-		message:   data.Message,
-		severity:  data.Severity,
-		timestamp: data.Timestamp,
+		priority:   newPriority(),
+		message:    data.Message,
+		severity:   data.Severity,
+		timestamp:  data.Timestamp,
+		loggerName: data.LoggerName,
+
+		serviceName:   data.ServiceName,
+		severityLevel: data.SeverityLevel,
+		sourceFile:    data.SourceFile,
+		sourceLine:    data.SourceLine,
+		stackTrace:    data.StackTrace,
 	}
 
 	return event, nil
 }
 
+// errorLogSeverityLevel is the lowest numeric severity level of error logs.
+const errorLogSeverityLevel = 17
+
+// correlatesWithErrors returns true if the log should record the error
+// noticed in the same transaction.
+func (e *logEvent) correlatesWithErrors() bool {
+	return e.stackTrace != "" && e.severityLevel >= errorLogSeverityLevel
+}
+
+// captureLogSource sets the source of the log to the caller of the function
+// which called captureLogSource, unless a source file has already been given.
+func (data *LogData) captureLogSource() {
+	if data.SourceFile != "" {
+		return
+	}
+	if _, file, line, ok := runtime.Caller(2); ok {
+		data.SourceFile = file
+		data.SourceLine = line
+	}
+}
+
 func (e *logEvent) MergeIntoHarvest(h *harvest) {
 	h.LogEvents.Add(e)
 }
@@ -109,11 +307,22 @@ var (
 	// ErrNoApplication is a type of error that occurs when the New Relic log decorator is passed a nil New Relic Application
 	// when it was expecting a valid, non nil pointer to a New Relic application.
 	ErrNoApplication = fmt.Errorf("%s: a non nil application or transaction must be provided to enrich a log", logDecorationErrorHeader)
+
+	// ErrMissingLinkingMetadata is a type of error that occurs when EnrichLogFromMetadata is not given an entity GUID,
+	// entity name, and hostname.
+	ErrMissingLinkingMetadata = fmt.Errorf("%s: an entity GUID, entity name, and hostname must be provided to enrich a log", logDecorationErrorHeader)
+
+	// ErrNotJSONObject is a type of error that occurs when the New Relic log decorator is configured using WithJSONFormat
+	// and the log is not a JSON object.
+	ErrNotJSONObject = fmt.Errorf("%s: the log must be a JSON object to be enriched in JSON format", logDecorationErrorHeader)
 )
 
 type logEnricherConfig struct {
-	app *Application
-	txn *Transaction
+	app       *Application
+	txn       *Transaction
+	timestamp bool
+	severity  string
+	json      bool
 }
 
 // EnricherOption is a function that configures the enricher based on the source of data it receives.
@@ -129,22 +338,92 @@ func FromTxn(txn *Transaction) EnricherOption {
 	return func(cfg *logEnricherConfig) { cfg.txn = txn }
 }
 
+// WithTimestamp configures the log enricher to include the current time, in
+// epoch milliseconds, in the linking payload.  This gives logs that do not
+// carry their own timestamp a consistent clock.  The timestamp is written
+// into the NR-LINKING payload between the span ID and the entity name, eg.
+// "NR-LINKING|guid|hostname|traceID|spanID|1700000000000|entityName|", so
+// that the positions of the leading fields and the trailing entity name are
+// unchanged.  With WithJSONFormat, it is written as the "timestamp" field.
+func WithTimestamp() EnricherOption {
+	return func(cfg *logEnricherConfig) { cfg.timestamp = true }
+}
+
+// WithJSONFormat configures the log enricher for logs which are written as
+// JSON objects.  Rather than appending the NR-LINKING payload, the linking
+// metadata is added as the "entity.guid", "hostname", "trace.id",
+// "span.id", and "entity.name" fields of the object, along with the
+// "timestamp", "request.id", and "level" fields when they are recorded.
+// ErrNotJSONObject is returned if the buffer does not end with a JSON object.
+func WithJSONFormat() EnricherOption {
+	return func(cfg *logEnricherConfig) { cfg.json = true }
+}
+
+// WithSeverity configures the log enricher to annotate the log with a
+// normalized severity token, such as "level=WARN" for a severity of
+// "warning", for easier searching of local console output.  Unrecognized
+// severities are written as "level=UNKNOWN".  The token is written ahead of
+// the NR-LINKING payload, or as the "level" field with WithJSONFormat.  It
+// only affects the decorated log and not forwarded log events.
+func WithSeverity(severity string) EnricherOption {
+	return func(cfg *logEnricherConfig) { cfg.severity = normalizeLogSeverity(severity) }
+}
+
+// normalizedLogSeverities maps each severity level to a single token.
+var normalizedLogSeverities = map[int]string{
+	1:  "TRACE",
+	5:  "DEBUG",
+	9:  "INFO",
+	13: "WARN",
+	17: "ERROR",
+	21: "FATAL",
+}
+
+func normalizeLogSeverity(severity string) string {
+	if token, ok := normalizedLogSeverities[LogSeverityLevel(severity)]; ok {
+		return token
+	}
+	return logcontext.LogSeverityUnknown
+}
+
 type linkingMetadata struct {
 	traceID    string
 	spanID     string
 	entityGUID string
 	hostname   string
 	entityName string
+	requestID  string
+	// timestamp is the decoration time in epoch milliseconds, or zero if
+	// it should not be written.
+	timestamp int64
+	// severity is the normalized severity token, or empty if it should
+	// not be written.
+	severity string
 }
 
 // EnrichLog appends newrelic linking metadata to a log stored in a byte buffer.
-// This should only be used by plugins built for frameworks.
-func EnrichLog(buf *bytes.Buffer, opts EnricherOption) error {
+// This should only be used by plugins built for frameworks. Options are
+// applied in order. When both FromApp and FromTxn are supplied, the
+// transaction takes precedence since it carries the trace and span IDs.
+func EnrichLog(buf *bytes.Buffer, opts ...EnricherOption) error {
+	_, err := EnrichLogWithResult(buf, opts...)
+	return err
+}
+
+// EnrichLogWithResult behaves like EnrichLog, and additionally reports
+// whether linking metadata was appended to the buffer.  False is returned
+// without an error when local decorating is disabled, allowing frameworks to
+// skip work for logs that were not decorated.
+func EnrichLogWithResult(buf *bytes.Buffer, opts ...EnricherOption) (bool, error) {
 	config := logEnricherConfig{}
-	opts(&config)
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&config)
+		}
+	}
 
 	if buf == nil {
-		return ErrNilLogBuffer
+		return false, ErrNilLogBuffer
 	}
 
 	md := linkingMetadata{}
@@ -152,46 +431,91 @@ func EnrichLog(buf *bytes.Buffer, opts EnricherOption) error {
 	var app *Application
 	var txn *Transaction
 
-	if config.app != nil {
-		app = config.app
-	} else if config.txn != nil {
+	if config.txn != nil {
 		app = config.txn.Application()
 		txn = config.txn
 
 		txnMD := txn.thread.GetTraceMetadata()
 		md.spanID = txnMD.SpanID
 		md.traceID = txnMD.TraceID
+		md.requestID = txn.thread.getRequestID()
+	} else if config.app != nil {
+		app = config.app
 	} else {
-		return ErrNoApplication
+		return false, ErrNoApplication
 	}
 
 	if app.app == nil {
-		return ErrNoApplication
+		return false, ErrNoApplication
 	}
 
 	reply, err := app.app.getState()
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	md.entityGUID = reply.Reply.EntityGUID
 	md.entityName = app.app.config.AppName
 	md.hostname = app.app.config.hostname
+	if config.timestamp {
+		md.timestamp = int64(timeToUnixMilliseconds(timeNow()))
+	}
+	md.severity = config.severity
 
 	if reply.Config.ApplicationLogging.Enabled && reply.Config.ApplicationLogging.LocalDecorating.Enabled {
-		md.appendLinkingMetadata(buf)
+		if config.json {
+			return md.appendLinkingMetadataJSON(buf)
+		}
+		return md.appendLinkingMetadata(buf), nil
 	}
 
+	return false, nil
+}
+
+// EnrichLogFromMetadata appends newrelic linking metadata to a log stored in
+// a byte buffer without an Application, for use by log shippers which
+// receive the metadata from another process.  The entity GUID, entity name,
+// and hostname are required; the trace and span IDs may be empty.  The
+// linking metadata is written in the same format as EnrichLog.
+func EnrichLogFromMetadata(buf *bytes.Buffer, entityGUID, entityName, hostname, traceID, spanID string) error {
+	if buf == nil {
+		return ErrNilLogBuffer
+	}
+	md := linkingMetadata{
+		entityGUID: entityGUID,
+		entityName: entityName,
+		hostname:   hostname,
+		traceID:    traceID,
+		spanID:     spanID,
+	}
+	if !md.appendLinkingMetadata(buf) {
+		return ErrMissingLinkingMetadata
+	}
 	return nil
 }
 
-func (md *linkingMetadata) appendLinkingMetadata(buf *bytes.Buffer) {
+func (md *linkingMetadata) appendLinkingMetadata(buf *bytes.Buffer) bool {
 	if md.entityGUID == "" || md.entityName == "" || md.hostname == "" {
-		return
+		return false
 	}
 
 	addDynamicSpacing(buf)
 
+	// The request ID and severity are written ahead of the linking metadata so that the
+	// NR-LINKING format, which ends with the entity name, is unchanged.
+	if md.requestID != "" {
+		buf.WriteString(AttributeRequestID)
+		buf.WriteByte('=')
+		buf.WriteString(md.requestID)
+		buf.WriteByte(' ')
+	}
+	if md.severity != "" {
+		buf.WriteString(logcontext.LogSeverityFieldName)
+		buf.WriteByte('=')
+		buf.WriteString(md.severity)
+		buf.WriteByte(' ')
+	}
+
 	buf.WriteString("NR-LINKING|")
 	buf.WriteString(md.entityGUID)
 	buf.WriteByte('|')
@@ -201,8 +525,55 @@ func (md *linkingMetadata) appendLinkingMetadata(buf *bytes.Buffer) {
 	buf.WriteByte('|')
 	buf.WriteString(md.spanID)
 	buf.WriteByte('|')
+	if md.timestamp != 0 {
+		jsonx.AppendInt(buf, md.timestamp)
+		buf.WriteByte('|')
+	}
 	buf.WriteString(md.entityName)
 	buf.WriteByte('|')
+	return true
+}
+
+// appendLinkingMetadataJSON adds the linking metadata as fields of the JSON
+// object at the end of the buffer, keeping any trailing whitespace such as
+// a newline after the object.
+func (md *linkingMetadata) appendLinkingMetadataJSON(buf *bytes.Buffer) (bool, error) {
+	if md.entityGUID == "" || md.entityName == "" || md.hostname == "" {
+		return false, nil
+	}
+
+	log := buf.Bytes()
+	end := len(bytes.TrimRight(log, " \t\r\n")) - 1
+	if end < 0 || log[end] != '}' || log[0] != '{' {
+		return false, ErrNotJSONObject
+	}
+	// The closing brace and any trailing whitespace are written after the
+	// new fields.  The object needs a comma before them unless it is empty.
+	closing := string(log[end:])
+	fields := bytes.TrimRight(log[:end], " \t\r\n")
+	buf.Truncate(end)
+
+	w := jsonFieldsWriter{buf: buf, needsComma: len(fields) > 1}
+	w.stringField("entity.guid", md.entityGUID)
+	w.stringField("hostname", md.hostname)
+	if md.traceID != "" {
+		w.stringField(logcontext.LogTraceIDFieldName, md.traceID)
+	}
+	if md.spanID != "" {
+		w.stringField(logcontext.LogSpanIDFieldName, md.spanID)
+	}
+	w.stringField("entity.name", md.entityName)
+	if md.timestamp != 0 {
+		w.intField(logcontext.LogTimestampFieldName, md.timestamp)
+	}
+	if md.requestID != "" {
+		w.stringField(AttributeRequestID, md.requestID)
+	}
+	if md.severity != "" {
+		w.stringField(logcontext.LogSeverityFieldName, md.severity)
+	}
+	buf.WriteString(closing)
+	return true, nil
 }
 
 func addDynamicSpacing(buf *bytes.Buffer) {
@@ -2,8 +2,12 @@ package newrelic
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -29,6 +33,86 @@ func TestWriteJSON(t *testing.T) {
 	}
 }
 
+func TestWriteJSONWithLoggerName(t *testing.T) {
+	event := logEvent{
+		severity:   "INFO",
+		message:    "test message",
+		timestamp:  123456,
+		loggerName: "my.logger",
+	}
+	actual, err := event.MarshalJSON()
+	if err != nil {
+		t.Error(err)
+	}
+
+	expect := `{"level":"INFO","message":"test message","logger.name":"my.logger","timestamp":123456}`
+	actualString := string(actual)
+	if expect != actualString {
+		t.Errorf("Log json did not build correctly: expecting %s, got %s", expect, actualString)
+	}
+}
+
+func TestWriteJSONWithServiceName(t *testing.T) {
+	event := logEvent{
+		severity:    "INFO",
+		message:     "test message",
+		timestamp:   123456,
+		loggerName:  "my.logger",
+		serviceName: "my-service",
+	}
+	actual, err := event.MarshalJSON()
+	if err != nil {
+		t.Error(err)
+	}
+
+	expect := `{"level":"INFO","message":"test message","logger.name":"my.logger","service.name":"my-service","timestamp":123456}`
+	actualString := string(actual)
+	if expect != actualString {
+		t.Errorf("Log json did not build correctly: expecting %s, got %s", expect, actualString)
+	}
+}
+
+func TestWriteJSONWithSource(t *testing.T) {
+	event := logEvent{
+		severity:   "INFO",
+		message:    "test message",
+		timestamp:  123456,
+		sourceFile: "main.go",
+		sourceLine: 42,
+	}
+	actual, err := event.MarshalJSON()
+	if err != nil {
+		t.Error(err)
+	}
+
+	expect := `{"level":"INFO","message":"test message","file.name":"main.go","line.number":42,"timestamp":123456}`
+	actualString := string(actual)
+	if expect != actualString {
+		t.Errorf("Log json did not build correctly: expecting %s, got %s", expect, actualString)
+	}
+}
+
+func TestWriteJSONWithStackTrace(t *testing.T) {
+	event := logEvent{
+		severity:   "ERROR",
+		message:    "test message",
+		timestamp:  123456,
+		stackTrace: "main.main()\n\tmain.go:42",
+		errorClass: "*errors.errorString",
+		errorID:    "abc123",
+	}
+	actual, err := event.MarshalJSON()
+	if err != nil {
+		t.Error(err)
+	}
+
+	expect := `{"level":"ERROR","message":"test message","error.stack":"main.main()\n\tmain.go:42","error.class":"*errors.errorString","error.id":"abc123","timestamp":123456}`
+	actualString := string(actual)
+	if expect != actualString {
+		t.Errorf("Log json did not build correctly: expecting %s, got %s", expect, actualString)
+	}
+}
+
 func TestToLogEvent(t *testing.T) {
 	type testcase struct {
 		name          string
@@ -47,9 +131,10 @@ func TestToLogEvent(t *testing.T) {
 				Message:   "test 123",
 			},
 			expectEvent: logEvent{
-				timestamp: 123456,
-				severity:  "info",
-				message:   "test 123",
+				timestamp:     123456,
+				severity:      "info",
+				message:       "test 123",
+				severityLevel: 9,
 			},
 		},
 		{
@@ -71,11 +156,92 @@ func TestToLogEvent(t *testing.T) {
 				Message:  "test 123",
 			},
 			expectEvent: logEvent{
-				severity: "info",
-				message:  "test 123",
+				severity:      "info",
+				message:       "test 123",
+				severityLevel: 9,
 			},
 			skipTimestamp: true,
 		},
+		{
+			name: "logger name",
+			data: LogData{
+				Timestamp:  123456,
+				Severity:   "info",
+				Message:    "test 123",
+				LoggerName: " my.logger ",
+			},
+			expectEvent: logEvent{
+				timestamp:  123456,
+				severity:   "info",
+				message:    "test 123",
+				loggerName: "my.logger",
+
+				severityLevel: 9,
+			},
+		},
+		{
+			name: "service name",
+			data: LogData{
+				Timestamp:   123456,
+				Severity:    "info",
+				Message:     "test 123",
+				ServiceName: " my-service ",
+			},
+			expectEvent: logEvent{
+				timestamp:   123456,
+				severity:    "info",
+				message:     "test 123",
+				serviceName: "my-service",
+
+				severityLevel: 9,
+			},
+		},
+		{
+			name: "logger name too large",
+			data: LogData{
+				Timestamp:  123456,
+				Severity:   "info",
+				Message:    "test 123",
+				LoggerName: strings.Repeat("a", 300),
+			},
+			expectEvent: logEvent{
+				timestamp:  123456,
+				severity:   "info",
+				message:    "test 123",
+				loggerName: strings.Repeat("a", 255),
+
+				severityLevel: 9,
+			},
+		},
+		{
+			name: "severity level",
+			data: LogData{
+				Timestamp:     123456,
+				Severity:      "notice",
+				Message:       "test 123",
+				SeverityLevel: 10,
+			},
+			expectEvent: logEvent{
+				timestamp:     123456,
+				severity:      "notice",
+				message:       "test 123",
+				severityLevel: 10,
+			},
+		},
+		{
+			name: "severity level from severity",
+			data: LogData{
+				Timestamp: 123456,
+				Severity:  "Error",
+				Message:   "test 123",
+			},
+			expectEvent: logEvent{
+				timestamp:     123456,
+				severity:      "Error",
+				message:       "test 123",
+				severityLevel: 17,
+			},
+		},
 		{
 			name: "message too large",
 			data: LogData{
@@ -88,7 +254,7 @@ func TestToLogEvent(t *testing.T) {
 	}
 
 	for _, testcase := range testcases {
-		actualEvent, err := testcase.data.toLogEvent()
+		actualEvent, err := testcase.data.toLogEvent(nil)
 
 		if testcase.expectErr != err {
 			t.Error(fmt.Errorf("%s: expected error %v, got %v", testcase.name, testcase.expectErr, err))
@@ -99,9 +265,15 @@ func TestToLogEvent(t *testing.T) {
 			if expect.message != actualEvent.message {
 				t.Error(fmt.Errorf("%s: expected message %s, got %s", testcase.name, expect.message, actualEvent.message))
 			}
+			if expect.loggerName != actualEvent.loggerName {
+				t.Error(fmt.Errorf("%s: expected logger name %s, got %s", testcase.name, expect.loggerName, actualEvent.loggerName))
+			}
 			if expect.severity != actualEvent.severity {
 				t.Error(fmt.Errorf("%s: expected severity %s, got %s", testcase.name, expect.severity, actualEvent.severity))
 			}
+			if expect.severityLevel != actualEvent.severityLevel {
+				t.Error(fmt.Errorf("%s: expected severity level %d, got %d", testcase.name, expect.severityLevel, actualEvent.severityLevel))
+			}
 			if actualEvent.timestamp == 0 {
 				t.Errorf("timestamp was not set on test %s", testcase.name)
 			}
@@ -114,6 +286,97 @@ func TestToLogEvent(t *testing.T) {
 
 var letters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 
+func TestToLogEventMessageScrubber(t *testing.T) {
+	scrubber := func(msg string) string {
+		return strings.ReplaceAll(msg, "user@example.com", "[email]")
+	}
+	data := LogData{
+		Message: " contact user@example.com ",
+	}
+	event, err := data.toLogEvent(scrubber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.message != "contact [email]" {
+		t.Error(event.message)
+	}
+
+	// The scrubbed message is checked against the length limit.
+	data = LogData{
+		Message: strings.Repeat("a", MaxLogLength+1),
+	}
+	if _, err := data.toLogEvent(func(msg string) string { return msg[:MaxLogLength] }); err != nil {
+		t.Error(err)
+	}
+	data = LogData{
+		Message: "short",
+	}
+	if _, err := data.toLogEvent(func(msg string) string { return strings.Repeat(msg, MaxLogLength) }); err != errLogMessageTooLarge {
+		t.Error(err)
+	}
+}
+
+func TestToLogEventMessageScrubberPanic(t *testing.T) {
+	data := LogData{
+		Message: "secret",
+	}
+	_, err := data.toLogEvent(func(msg string) string { panic("oops") })
+	if err == nil || err.Error() != "panic in log message scrubber: oops" {
+		t.Error(err)
+	}
+}
+
+func TestToLogEventStackTrace(t *testing.T) {
+	data := LogData{
+		Severity:   "error",
+		Message:    "short",
+		StackTrace: strings.Repeat("a", MaxLogStackTraceLength+1),
+	}
+	event, err := data.toLogEvent(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(event.stackTrace) != MaxLogStackTraceLength {
+		t.Error(len(event.stackTrace))
+	}
+	if event.message != "short" {
+		t.Error(event.message)
+	}
+	if !event.correlatesWithErrors() {
+		t.Error("error log with a stack trace should correlate with errors")
+	}
+
+	data = LogData{
+		Severity:   "info",
+		StackTrace: "main.main()",
+	}
+	if event, err = data.toLogEvent(nil); err != nil {
+		t.Fatal(err)
+	}
+	if event.correlatesWithErrors() {
+		t.Error("info log should not correlate with errors")
+	}
+}
+
+func TestLogCountAttribute(t *testing.T) {
+	for level, expect := range map[int]string{
+		0:  AttributeLogCountUnknown,
+		1:  AttributeLogCountTrace,
+		5:  AttributeLogCountDebug,
+		9:  AttributeLogCountInfo,
+		10: AttributeLogCountInfo,
+		13: AttributeLogCountWarn,
+		17: AttributeLogCountError,
+		21: AttributeLogCountFatal,
+		25: AttributeLogCountUnknown,
+		-1: AttributeLogCountUnknown,
+	} {
+		if attr := logCountAttribute(level); attr != expect {
+			t.Errorf("level %d: got %s, want %s", level, attr, expect)
+		}
+	}
+}
+
 func randomString(n int) string {
 	b := make([]rune, n)
 	for i := range b {
@@ -142,6 +405,27 @@ func TestWriteJSONWithTrace(t *testing.T) {
 	}
 }
 
+func TestWriteJSONWithTraceFlags(t *testing.T) {
+	event := logEvent{
+		severity:   "INFO",
+		message:    "test message",
+		timestamp:  123456,
+		traceID:    "123Ad234",
+		spanID:     "adf3441",
+		traceFlags: "01",
+	}
+	actual, err := event.MarshalJSON()
+	if err != nil {
+		t.Error(err)
+	}
+
+	expect := `{"level":"INFO","message":"test message","span.id":"adf3441","trace.id":"123Ad234","trace.flags":"01","timestamp":123456}`
+	actualString := string(actual)
+	if expect != actualString {
+		t.Errorf("Log json did not build correctly: expecting %s, got %s", expect, actualString)
+	}
+}
+
 func BenchmarkToLogEvent(b *testing.B) {
 	data := LogData{
 		Timestamp: 123456,
@@ -152,13 +436,13 @@ func BenchmarkToLogEvent(b *testing.B) {
 	b.ReportAllocs()
 
 	for n := 0; n < b.N; n++ {
-		data.toLogEvent()
+		data.toLogEvent(nil)
 	}
 
 }
 
 func recordLogBenchmarkHelper(b *testing.B, data *LogData, h *harvest) {
-	event, _ := data.toLogEvent()
+	event, _ := data.toLogEvent(nil)
 	event.MergeIntoHarvest(h)
 }
 
@@ -185,7 +469,7 @@ func BenchmarkWriteJSON(b *testing.B) {
 		Message:   "This is a log message that represents an estimate for how long the average log message is. The average log payload is 700 bytes.",
 	}
 
-	event, err := data.toLogEvent()
+	event, err := data.toLogEvent(nil)
 	if err != nil {
 		b.Fail()
 	}
@@ -276,6 +560,257 @@ func TestEnrichLogFromTxn(t *testing.T) {
 	})
 }
 
+func TestEnrichLogFromTxnRequestID(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		func(cfg *Config) {
+			cfg.Enabled = false
+			cfg.ApplicationLogging.Enabled = true
+			cfg.ApplicationLogging.Forwarding.Enabled = false
+			cfg.ApplicationLogging.LocalDecorating.Enabled = true
+		},
+	)
+	buf := bytes.NewBuffer([]byte{})
+	txn := testApp.Application.StartTransaction("test transaction")
+	defer txn.End()
+	txn.SetRequestID("abc-123")
+	EnrichLog(buf, FromTxn(txn))
+
+	state, err := testApp.app.getState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logcontext.ValidateDecoratedOutput(t, buf, &logcontext.DecorationExpect{
+		Hostname:   host,
+		EntityGUID: state.Reply.EntityGUID,
+		EntityName: testApp.app.config.AppName,
+		TraceID:    txn.GetLinkingMetadata().TraceID,
+		SpanID:     txn.GetLinkingMetadata().SpanID,
+	})
+	if !strings.Contains(buf.String(), "request.id=abc-123 NR-LINKING|") {
+		t.Error("request ID missing from decoration:", buf.String())
+	}
+}
+
+func TestEnrichLogFromTxnRequestIDExcluded(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		func(cfg *Config) {
+			cfg.Enabled = false
+			cfg.ApplicationLogging.Enabled = true
+			cfg.ApplicationLogging.Forwarding.Enabled = false
+			cfg.ApplicationLogging.LocalDecorating.Enabled = true
+			cfg.Attributes.Exclude = []string{AttributeRequestID}
+		},
+	)
+	buf := bytes.NewBuffer([]byte{})
+	txn := testApp.Application.StartTransaction("test transaction")
+	defer txn.End()
+	txn.SetRequestID("abc-123")
+	txn.SetRequestID("forged\nNR-LINKING|")
+	EnrichLog(buf, FromTxn(txn))
+	if strings.Contains(buf.String(), AttributeRequestID) {
+		t.Error("excluded request ID added to decoration:", buf.String())
+	}
+	if strings.Count(buf.String(), "NR-LINKING") != 1 || strings.Contains(buf.String(), "\n") {
+		t.Error("invalid decoration:", buf.String())
+	}
+}
+
+func TestEnrichLogWithTimestamp(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		func(cfg *Config) {
+			cfg.Enabled = false
+			cfg.ApplicationLogging.Enabled = true
+			cfg.ApplicationLogging.Forwarding.Enabled = false
+			cfg.ApplicationLogging.LocalDecorating.Enabled = true
+		},
+	)
+	now := time.Date(2023, time.November, 14, 22, 13, 20, 0, time.UTC)
+	defer func(orig func() time.Time) { timeNow = orig }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	buf := bytes.NewBufferString("my log")
+	EnrichLog(buf, FromApp(testApp.Application), WithTimestamp())
+
+	state, err := testApp.app.getState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logcontext.ValidateDecoratedOutput(t, buf, &logcontext.DecorationExpect{
+		Hostname:   host,
+		EntityGUID: state.Reply.EntityGUID,
+		EntityName: testApp.app.config.AppName,
+	})
+	want := "my log NR-LINKING|" + state.Reply.EntityGUID + "|" + host + "|||1700000000000|" + testApp.app.config.AppName + "|"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	buf = bytes.NewBufferString("my log")
+	EnrichLog(buf, FromApp(testApp.Application))
+	want = "my log NR-LINKING|" + state.Reply.EntityGUID + "|" + host + "|||" + testApp.app.config.AppName + "|"
+	if buf.String() != want {
+		t.Errorf("timestamp should only be written when requested: got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestEnrichLogWithSeverity(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		func(cfg *Config) {
+			cfg.Enabled = false
+			cfg.ApplicationLogging.Enabled = true
+			cfg.ApplicationLogging.Forwarding.Enabled = false
+			cfg.ApplicationLogging.LocalDecorating.Enabled = true
+		},
+	)
+
+	testcases := map[string]string{
+		"warning":  "WARN",
+		" Error ":  "ERROR",
+		"critical": "FATAL",
+		"verbose":  "UNKNOWN",
+		"":         "UNKNOWN",
+	}
+	for severity, token := range testcases {
+		buf := bytes.NewBufferString("my log")
+		EnrichLog(buf, FromApp(testApp.Application), WithSeverity(severity))
+		if want := "my log level=" + token + " NR-LINKING|"; !strings.HasPrefix(buf.String(), want) {
+			t.Errorf("severity %q: got %q, want prefix %q", severity, buf.String(), want)
+		}
+	}
+
+	buf := bytes.NewBufferString("my log")
+	EnrichLog(buf, FromApp(testApp.Application))
+	if strings.Contains(buf.String(), "level=") {
+		t.Error("severity should only be written when requested:", buf.String())
+	}
+}
+
+func TestEnrichLogJSONFormat(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		func(cfg *Config) {
+			cfg.Enabled = false
+			cfg.ApplicationLogging.Enabled = true
+			cfg.ApplicationLogging.Forwarding.Enabled = false
+			cfg.ApplicationLogging.LocalDecorating.Enabled = true
+		},
+	)
+	now := time.Date(2023, time.November, 14, 22, 13, 20, 0, time.UTC)
+	defer func(orig func() time.Time) { timeNow = orig }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	state, err := testApp.app.getState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn := testApp.Application.StartTransaction("test transaction")
+	defer txn.End()
+	txn.SetRequestID("abc-123")
+	md := txn.GetLinkingMetadata()
+
+	buf := bytes.NewBufferString(`{"message":"my log"}` + "\n")
+	decorated, err := EnrichLogWithResult(buf, FromTxn(txn), WithJSONFormat(), WithTimestamp(), WithSeverity("warning"))
+	if !decorated || err != nil {
+		t.Fatal(decorated, err)
+	}
+	if !strings.HasSuffix(buf.String(), "}\n") || strings.Contains(buf.String(), "NR-LINKING") {
+		t.Error("invalid decoration:", buf.String())
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		t.Fatal(err, buf.String())
+	}
+	expect := map[string]interface{}{
+		"message":          "my log",
+		"entity.guid":      state.Reply.EntityGUID,
+		"entity.name":      testApp.app.config.AppName,
+		"hostname":         host,
+		"trace.id":         md.TraceID,
+		"span.id":          md.SpanID,
+		"timestamp":        float64(1700000000000),
+		AttributeRequestID: "abc-123",
+		"level":            "WARN",
+	}
+	if !reflect.DeepEqual(fields, expect) {
+		t.Errorf("got %v, want %v", fields, expect)
+	}
+
+	// Optional fields are only written when requested, and an empty object
+	// does not get a leading comma.
+	buf = bytes.NewBufferString("{ }")
+	EnrichLog(buf, FromApp(testApp.Application), WithJSONFormat())
+	want := `{ "entity.guid":` + strconv.Quote(state.Reply.EntityGUID) + `,"hostname":` + strconv.Quote(host) +
+		`,"entity.name":` + strconv.Quote(testApp.app.config.AppName) + `}`
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestEnrichLogJSONFormatNotObject(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		func(cfg *Config) {
+			cfg.Enabled = false
+			cfg.ApplicationLogging.Enabled = true
+			cfg.ApplicationLogging.Forwarding.Enabled = false
+			cfg.ApplicationLogging.LocalDecorating.Enabled = true
+		},
+	)
+	for _, log := range []string{"", "my log", "[1,2]", "{\"message\":\"my log\"} trailing"} {
+		buf := bytes.NewBufferString(log)
+		decorated, err := EnrichLogWithResult(buf, FromApp(testApp.Application), WithJSONFormat())
+		if decorated || err != ErrNotJSONObject {
+			t.Errorf("log %q: expected false and ErrNotJSONObject, got %t and %v", log, decorated, err)
+		}
+		if buf.String() != log {
+			t.Errorf("log %q: buffer modified: %q", log, buf.String())
+		}
+	}
+}
+
+func TestEnrichLogFromMetadata(t *testing.T) {
+	buf := bytes.NewBufferString("my log")
+	if err := EnrichLogFromMetadata(buf, "my-guid", "my app", "my-host", "trace-1", "span-1"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "my log NR-LINKING|my-guid|my-host|trace-1|span-1|my app|"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	buf = bytes.NewBufferString("my log")
+	if err := EnrichLogFromMetadata(buf, "my-guid", "my app", "my-host", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if want := "my log NR-LINKING|my-guid|my-host|||my app|"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestEnrichLogFromMetadataMissingFields(t *testing.T) {
+	if err := EnrichLogFromMetadata(nil, "my-guid", "my app", "my-host", "", ""); err != ErrNilLogBuffer {
+		t.Error(err)
+	}
+	for _, md := range [][3]string{
+		{"", "my app", "my-host"},
+		{"my-guid", "", "my-host"},
+		{"my-guid", "my app", ""},
+	} {
+		buf := bytes.NewBufferString("my log")
+		if err := EnrichLogFromMetadata(buf, md[0], md[1], md[2], "trace-1", "span-1"); err != ErrMissingLinkingMetadata {
+			t.Error(md, err)
+		}
+		if buf.String() != "my log" {
+			t.Error("log should not be modified:", buf.String())
+		}
+	}
+}
+
 func TestEnrichLogFromTxnDisabled(t *testing.T) {
 	testApp := newTestApp(
 		sampleEverythingReplyFn,
@@ -296,6 +831,78 @@ func TestEnrichLogFromTxnDisabled(t *testing.T) {
 	})
 }
 
+func TestEnrichLogWithResult(t *testing.T) {
+	for _, decorating := range []bool{true, false} {
+		testApp := newTestApp(
+			sampleEverythingReplyFn,
+			func(cfg *Config) {
+				cfg.Enabled = false
+				cfg.ApplicationLogging.Enabled = true
+				cfg.ApplicationLogging.Forwarding.Enabled = false
+				cfg.ApplicationLogging.LocalDecorating.Enabled = decorating
+			},
+		)
+		buf := bytes.NewBuffer([]byte("test log message"))
+		decorated, err := EnrichLogWithResult(buf, FromApp(testApp.Application))
+		if err != nil {
+			t.Error(err)
+		}
+		if decorated != decorating {
+			t.Errorf("expected decorated to be %t, got %t", decorating, decorated)
+		}
+		if decorated != strings.Contains(buf.String(), "NR-LINKING") {
+			t.Errorf("decorated result does not match buffer: %s", buf.String())
+		}
+	}
+
+	decorated, err := EnrichLogWithResult(nil, FromApp(nil))
+	if decorated || err != ErrNilLogBuffer {
+		t.Errorf("expected false and ErrNilLogBuffer, got %t and %v", decorated, err)
+	}
+}
+
+func TestEnrichLogFromAppAndTxn(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		func(cfg *Config) {
+			cfg.Enabled = false
+			cfg.ApplicationLogging.Enabled = true
+			cfg.ApplicationLogging.Forwarding.Enabled = false
+			cfg.ApplicationLogging.LocalDecorating.Enabled = true
+		},
+	)
+	txn := testApp.Application.StartTransaction("test transaction")
+	defer txn.End()
+
+	state, err := testApp.app.getState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := &logcontext.DecorationExpect{
+		Hostname:   host,
+		EntityGUID: state.Reply.EntityGUID,
+		EntityName: testApp.app.config.AppName,
+		TraceID:    txn.GetLinkingMetadata().TraceID,
+		SpanID:     txn.GetLinkingMetadata().SpanID,
+	}
+
+	// FromTxn takes precedence regardless of the order of the options.
+	buf := bytes.NewBuffer([]byte{})
+	EnrichLog(buf, FromApp(testApp.Application), FromTxn(txn))
+	logcontext.ValidateDecoratedOutput(t, buf, expect)
+
+	buf = bytes.NewBuffer([]byte{})
+	EnrichLog(buf, FromTxn(txn), FromApp(testApp.Application))
+	logcontext.ValidateDecoratedOutput(t, buf, expect)
+}
+
+func TestEnrichLogNoOptions(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	if err := EnrichLog(buf); err != ErrNoApplication {
+		t.Errorf("expected ErrNoApplication, got %v", err)
+	}
+}
+
 func BenchmarkAppendLinkingMetadata(b *testing.B) {
 	buf := bytes.NewBuffer([]byte("test log message"))
 	md := linkingMetadata{
@@ -313,3 +920,43 @@ func BenchmarkAppendLinkingMetadata(b *testing.B) {
 		md.appendLinkingMetadata(buf)
 	}
 }
+
+func TestWriteJSONWithSeverityLevel(t *testing.T) {
+	event := logEvent{
+		severity:      "WARN",
+		message:       "test message",
+		timestamp:     123456,
+		severityLevel: 13,
+	}
+
+	actual, err := event.MarshalJSON()
+	if err != nil {
+		t.Error(err)
+	}
+
+	expect := `{"level":"WARN","level.number":13,"message":"test message","timestamp":123456}`
+	actualString := string(actual)
+	if expect != actualString {
+		t.Errorf("Log json did not build correctly: expecting %s, got %s", expect, actualString)
+	}
+}
+
+func TestLogSeverityLevel(t *testing.T) {
+	for severity, level := range map[string]int{
+		"trace":   1,
+		"DEBUG":   5,
+		" info ":  9,
+		"warning": 13,
+		"error":   17,
+		"FATAL":   21,
+		"UNKNOWN": 0,
+		"":        0,
+	} {
+		if l := LogSeverityLevel(severity); l != level {
+			t.Errorf("%q: expected %d, got %d", severity, level, l)
+		}
+	}
+	if LogSeverityLevel("debug") >= LogSeverityLevel("info") {
+		t.Error("debug should be less severe than info")
+	}
+}
@@ -176,7 +176,7 @@ func (events *logEvents) CollectorJSON(agentRunID string) ([]byte, error) {
 		// If severity is empty string, then this is not a user provided entry, and is empty.
 		// Do not write json to buffer in this case.
 		if e.severity != "" {
-			e.WriteJSON(buf)
+			e.writeJSON(buf, events.config.rfc3339Time)
 			if i != len(events.logs)-1 {
 				buf.WriteByte(',')
 			}
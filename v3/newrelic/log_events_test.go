@@ -70,6 +70,29 @@ func TestBasicLogEvents(t *testing.T) {
 	}
 }
 
+func TestLogEventsRFC3339Timestamp(t *testing.T) {
+	cfg := loggingConfigEnabled(5)
+	cfg.rfc3339Time = true
+	events := newLogEvents(testCommonAttributes, cfg)
+	events.Add(sampleLogEvent(0.5, infoLevel, "message1"))
+
+	json, err := events.CollectorJSON(agentRunID)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	timestamp := time.UnixMilli(123456).Format(logTimestampRFC3339)
+	expected := commonJSON +
+		`{"level":"INFO","message":"message1","timestamp":"` + timestamp + `"}]}]`
+
+	if string(json) != expected {
+		t.Error(string(json), expected)
+	}
+	if _, err := time.Parse(time.RFC3339, timestamp); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestEmptyLogEvents(t *testing.T) {
 	events := newLogEvents(testCommonAttributes, loggingConfigEnabled(10))
 	json, err := events.CollectorJSON(agentRunID)
@@ -473,6 +496,15 @@ func BenchmarkRecordLoggingMetrics(b *testing.B) {
 			fmt.Sprintf("User 'xyz' logged in %d", i),
 			"123456789ADF",
 			"ADF09876565",
+			"",
+			"",
+			0,
+			"",
+			0,
+			"",
+			"",
+			"",
+			"",
 		}
 
 		h.LogEvents.Add(&logEvent)
@@ -22,8 +22,25 @@ const (
 	// therefore should only be made for web transactions.
 	dispatcherMetric = "HttpDispatcher"
 
+	// "HttpResponseClass/" metrics count web transactions by the class of
+	// their response code, eg. "HttpResponseClass/2xx".
+	httpResponseClassPrefix = "HttpResponseClass/"
+
+	// "HttpMethod/" metrics time web transactions by their request method,
+	// eg. "HttpMethod/POST".
+	httpMethodPrefix = "HttpMethod/"
+
+	// "BusyTime/" metrics time the processing time given to
+	// Transaction.AddBusyTime, eg. "BusyTime/OtherTransaction/Go/job".
+	busyTimePrefix = "BusyTime/"
+
 	queueMetric = "WebFrontend/QueueTime"
 
+	// "WebFrontend/TotalResponseTime" is the queue time plus the duration
+	// of the transaction: the response time observed by the front-end
+	// server which added the queue start header.
+	totalResponseTimeMetric = "WebFrontend/TotalResponseTime"
+
 	// Transaction name prefixes are located in connect_reply.go.
 
 	instanceReporting = "Instance/Reporting"
@@ -46,6 +63,27 @@ const (
 
 	supportabilityDropped = "Supportability/MetricsDropped"
 
+	supportUserAttrsDropped = "Supportability/Go/UserAttributes/LimitExceeded"
+
+	// Transactions which were not reported because they were ignored,
+	// either by Transaction.Ignore or by naming rules producing an empty
+	// name.
+	supportTxnIgnoredExplicit  = "Supportability/Go/Transaction/Ignored/Explicit"
+	supportTxnIgnoredEmptyName = "Supportability/Go/Transaction/Ignored/EmptyName"
+
+	// Transactions in which a panic was recovered, either by a deferred
+	// Transaction.End or in a goroutine started with Transaction.Go.
+	supportTxnPanic = "Supportability/Go/Transaction/Panic"
+
+	// Transactions dropped by Transaction.End because the application's
+	// data channel was full, when Config.NonBlockingTransactionEnd is
+	// enabled.
+	supportHarvestOverflow = "Supportability/Go/Harvest/Overflow"
+
+	// Writes to the ResponseWriter after the transaction ended, recorded
+	// when Config.WritesAfterEnd.RecordMetric is enabled.
+	supportWriteAfterEnd = "Supportability/Go/ResponseWriter/WriteAfterEnd"
+
 	// Runtime/System Metrics
 	memoryPhysical       = "Memory/Physical"
 	heapObjectsAllocated = "Memory/Heap/AllocatedObjects"
@@ -96,6 +134,7 @@ type loggingConfig struct {
 	collectMetrics  bool // collection of log metric data is enabled
 	localEnrichment bool // local log enrichment is enabled
 	maxLogEvents    int  // maximum number of log events allowed to be collected
+	rfc3339Time     bool // log event timestamps are written as RFC 3339 strings
 }
 
 // Logging metrics that are generated at connect response
@@ -223,12 +262,19 @@ func customSegmentMetric(s string) string {
 	return "Custom/" + s
 }
 
+// defaultCustomMetricPrefix is the default value of Config.CustomMetricPrefix.
+const defaultCustomMetricPrefix = "Custom/"
+
 // customMetricName is used to construct custom metrics from the input given to
-// Application.RecordCustomMetric.  Note that the "Custom/" prefix helps prevent
-// collision with other agent metrics, but does not eliminate the possibility
-// since "Custom/" is also used for segments.
-func customMetricName(customerInput string) string {
-	return "Custom/" + customerInput
+// Application.RecordCustomMetric and the configured prefix.  Note that the
+// default "Custom/" prefix helps prevent collision with other agent metrics,
+// but does not eliminate the possibility since "Custom/" is also used for
+// segments.
+func customMetricName(prefix, customerInput string) string {
+	if prefix == "" {
+		prefix = defaultCustomMetricPrefix
+	}
+	return prefix + customerInput
 }
 
 // datastoreMetricKey contains the fields by which datastore metrics are
@@ -5,6 +5,7 @@ package newrelic
 
 import (
 	"bytes"
+	"sort"
 	"time"
 
 	"github.com/newrelic/go-agent/v3/internal"
@@ -70,6 +71,25 @@ func (mt *metricTable) full() bool {
 	return len(mt.metrics) >= mt.maxTableSize
 }
 
+// names returns the sorted, de-duplicated names of the metrics in the table.
+// Scoped and unscoped metrics of the same name are reported once.
+func (mt *metricTable) names() []string {
+	if nil == mt {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(mt.metrics))
+	names := make([]string, 0, len(mt.metrics))
+	for id := range mt.metrics {
+		if _, ok := seen[id.Name]; ok {
+			continue
+		}
+		seen[id.Name] = struct{}{}
+		names = append(names, id.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (data *metricData) aggregate(src metricData) {
 	data.countSatisfied += src.countSatisfied
 	data.totalTolerated += src.totalTolerated
@@ -34,6 +34,22 @@ func isValidJSON(data []byte) error {
 	return json.Unmarshal(data, &v)
 }
 
+func TestMetricTableNames(t *testing.T) {
+	mt := newMetricTable(20, start)
+	mt.addDuration("zip", "", 1*time.Second, 0, forced)
+	mt.addDuration("zip", "scope", 1*time.Second, 0, forced)
+	mt.addDuration("zap", "scope", 1*time.Second, 0, unforced)
+	names := mt.names()
+	if fmt.Sprint(names) != "[zap zip]" {
+		t.Error(names)
+	}
+
+	var nilTable *metricTable
+	if names := nilTable.names(); names != nil {
+		t.Error(names)
+	}
+}
+
 func TestMetrics(t *testing.T) {
 	mt := newMetricTable(20, start)
 
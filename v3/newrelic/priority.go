@@ -6,6 +6,7 @@ package newrelic
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"strings"
 )
 
@@ -43,6 +44,18 @@ func (p priority) isLowerPriority(y priority) bool {
 	return p < y
 }
 
+// keptAtSampleRate deterministically decides whether data with this priority
+// is kept when only the given fraction of data should be kept.  The sampled
+// boost added to priorities is ignored so that the decision depends only on
+// the random component.
+func (p priority) keptAtSampleRate(rate float64) bool {
+	if rate >= 1.0 {
+		return true
+	}
+	f := float64(p)
+	return f-math.Floor(f) < rate
+}
+
 // MarshalJSON limits the number of decimals.
 func (p priority) MarshalJSON() ([]byte, error) {
 	return []byte(fmt.Sprintf(priorityFormat, p)), nil
@@ -25,6 +25,29 @@ func TestIsLowerPriority(t *testing.T) {
 	}
 }
 
+func TestKeptAtSampleRate(t *testing.T) {
+	testcases := []struct {
+		priority priority
+		rate     float64
+		kept     bool
+	}{
+		{priority: 0.2, rate: 1.0, kept: true},
+		{priority: 0.2, rate: 0.0, kept: false},
+		{priority: 0.2, rate: 0.5, kept: true},
+		{priority: 0.7, rate: 0.5, kept: false},
+		{priority: 1.2, rate: 0.5, kept: true},
+		{priority: 1.7, rate: 0.5, kept: false},
+		{priority: 1.7, rate: 2.0, kept: true},
+	}
+
+	for _, tc := range testcases {
+		if kept := tc.priority.keptAtSampleRate(tc.rate); kept != tc.kept {
+			t.Errorf("wrong sample decision for priority=%f rate=%f: expected=%t actual=%t",
+				tc.priority, tc.rate, tc.kept, kept)
+		}
+	}
+}
+
 func TestTraceStateFormat(t *testing.T) {
 	testcases := []struct {
 		input    float64
@@ -0,0 +1,79 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"sync"
+	"time"
+)
+
+// TransactionSummary describes a finished transaction.  Summaries are
+// returned by Application.RecentTransactions when
+// Config.RecentTransactionBufferSize is set.
+type TransactionSummary struct {
+	// Name is the final name of the transaction, eg.
+	// "WebTransaction/Go/hello".
+	Name string
+	// Duration is the time elapsed between the start and end of the
+	// transaction.
+	Duration time.Duration
+	// ApdexZone is the Apdex classification of the transaction.  It is
+	// ApdexNone for non-web transactions.
+	ApdexZone ApdexZone
+	// ErrorsSeen is the number of errors noticed by the transaction,
+	// including those beyond the number of errors captured.  Expected
+	// errors and errors recorded with Transaction.RecordErrorInfoOnly are
+	// not counted.
+	ErrorsSeen int
+}
+
+// recentTransactions is a fixed size ring of the most recently finished
+// transaction summaries.
+type recentTransactions struct {
+	sync.Mutex
+	summaries []TransactionSummary
+	next      int
+	full      bool
+}
+
+// newRecentTransactions returns nil if size is not positive.
+func newRecentTransactions(size int) *recentTransactions {
+	if size <= 0 {
+		return nil
+	}
+	return &recentTransactions{
+		summaries: make([]TransactionSummary, size),
+	}
+}
+
+func (r *recentTransactions) add(s TransactionSummary) {
+	if nil == r {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+
+	r.summaries[r.next] = s
+	r.next++
+	if r.next == len(r.summaries) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// snapshot returns a copy of the summaries, oldest first.
+func (r *recentTransactions) snapshot() []TransactionSummary {
+	if nil == r {
+		return nil
+	}
+	r.Lock()
+	defer r.Unlock()
+
+	if !r.full {
+		return append([]TransactionSummary(nil), r.summaries[:r.next]...)
+	}
+	out := make([]TransactionSummary, 0, len(r.summaries))
+	out = append(out, r.summaries[r.next:]...)
+	return append(out, r.summaries[:r.next]...)
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecentTransactionsDisabled(t *testing.T) {
+	r := newRecentTransactions(0)
+	if r != nil {
+		t.Fatal("expected nil ring for zero size")
+	}
+	r.add(TransactionSummary{Name: "a"})
+	if s := r.snapshot(); s != nil {
+		t.Error(s)
+	}
+}
+
+func TestRecentTransactionsWraps(t *testing.T) {
+	r := newRecentTransactions(3)
+	if s := r.snapshot(); len(s) != 0 {
+		t.Error(s)
+	}
+	for _, name := range []string{"a", "b"} {
+		r.add(TransactionSummary{Name: name})
+	}
+	expect := []TransactionSummary{{Name: "a"}, {Name: "b"}}
+	if s := r.snapshot(); !reflect.DeepEqual(s, expect) {
+		t.Error(s)
+	}
+	for _, name := range []string{"c", "d", "e"} {
+		r.add(TransactionSummary{Name: name})
+	}
+	expect = []TransactionSummary{{Name: "c"}, {Name: "d"}, {Name: "e"}}
+	s := r.snapshot()
+	if !reflect.DeepEqual(s, expect) {
+		t.Error(s)
+	}
+	s[0].Name = "modified"
+	if s := r.snapshot(); s[0].Name != "c" {
+		t.Error("snapshot is not a copy", s)
+	}
+}
+
+func TestApplicationRecentTransactions(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.RecentTransactionBufferSize = 2
+	}, t)
+	txn := app.StartTransaction("one")
+	txn.End()
+	txn = app.StartTransaction("two")
+	txn.SetWebRequestHTTP(helloRequest)
+	txn.NoticeError(myError{})
+	txn.End()
+	txn = app.StartTransaction("three")
+	txn.End()
+
+	s := app.RecentTransactions()
+	if len(s) != 2 {
+		t.Fatal(s)
+	}
+	if s[0].Name != "WebTransaction/Go/two" || s[0].ApdexZone != ApdexFailing || s[0].ErrorsSeen != 1 {
+		t.Error(s[0])
+	}
+	if s[1].Name != "OtherTransaction/Go/three" || s[1].ApdexZone != ApdexNone || s[1].ErrorsSeen != 0 {
+		t.Error(s[1])
+	}
+}
+
+func TestApplicationRecentTransactionsErrorsSeen(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.RecentTransactionBufferSize = 1
+	}, t)
+	txn := app.StartTransaction("hello")
+	for i := 0; i < maxTxnErrors+2; i++ {
+		txn.NoticeError(myError{})
+	}
+	txn.NoticeExpectedError(myError{})
+	txn.RecordErrorInfoOnly(myError{})
+	txn.End()
+
+	s := app.RecentTransactions()
+	if len(s) != 1 {
+		t.Fatal(s)
+	}
+	if s[0].ErrorsSeen != maxTxnErrors+2 {
+		t.Error(s[0].ErrorsSeen)
+	}
+}
+
+func TestApplicationRecentTransactionsDisabled(t *testing.T) {
+	app := testApp(nil, nil, t)
+	txn := app.StartTransaction("one")
+	txn.End()
+	if s := app.RecentTransactions(); s != nil {
+		t.Error(s)
+	}
+	var nilApp *Application
+	if s := nilApp.RecentTransactions(); s != nil {
+		t.Error(s)
+	}
+}
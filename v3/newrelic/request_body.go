@@ -0,0 +1,61 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// requestBodyCapture records the first maxCapturedRequestBody bytes read from
+// a request body.  It is written by the goroutine reading the body and read
+// when the transaction's errors are harvested, so it has its own lock.
+type requestBodyCapture struct {
+	sync.Mutex
+	buf []byte
+}
+
+// Write never fails so that reading the body is not affected by the capture.
+func (c *requestBodyCapture) Write(p []byte) (int, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if room := maxCapturedRequestBody - len(c.buf); len(p) > room {
+		c.buf = append(c.buf, p[:room]...)
+	} else {
+		c.buf = append(c.buf, p...)
+	}
+	return len(p), nil
+}
+
+func (c *requestBodyCapture) String() string {
+	if nil == c {
+		return ""
+	}
+	c.Lock()
+	defer c.Unlock()
+
+	return string(c.buf)
+}
+
+// capturedBody replaces a request body to capture the bytes read from it.
+type capturedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// captureRequestBody replaces the body of the request so that the bytes read
+// from it are captured.  It returns nil if the request has no body.
+func captureRequestBody(req *http.Request) *requestBodyCapture {
+	if nil == req.Body || http.NoBody == req.Body {
+		return nil
+	}
+	c := &requestBodyCapture{}
+	req.Body = capturedBody{
+		Reader: io.TeeReader(req.Body, c),
+		Closer: req.Body,
+	}
+	return c
+}
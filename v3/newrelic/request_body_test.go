@@ -0,0 +1,186 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestCaptureRequestBody(t *testing.T) {
+	req, err := http.NewRequest("POST", "/hello", strings.NewReader(strings.Repeat("a", maxCapturedRequestBody+10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := captureRequestBody(req)
+	if s := c.String(); s != "" {
+		t.Error(s)
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(body) != maxCapturedRequestBody+10 {
+		t.Error(len(body))
+	}
+	if s := c.String(); s != strings.Repeat("a", maxCapturedRequestBody) {
+		t.Error(len(s))
+	}
+	if err := req.Body.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCaptureRequestBodyNoBody(t *testing.T) {
+	req, err := http.NewRequest("GET", "/hello", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := captureRequestBody(req); nil != c {
+		t.Error(c)
+	}
+	var c *requestBodyCapture
+	if s := c.String(); s != "" {
+		t.Error(s)
+	}
+}
+
+func captureRequestBodyTxn(app expectApp, body string) {
+	req, _ := http.NewRequest("POST", "/hello", strings.NewReader(body))
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(req)
+	io.ReadAll(req.Body)
+	txn.NoticeError(myError{})
+	txn.End()
+}
+
+func TestErrorRequestBody(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.CaptureRequestBody = true
+	}, t)
+	captureRequestBodyTxn(app, `{"zip":"zap"}`)
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+		AgentAttributes: map[string]interface{}{
+			AttributeRequestMethod: "POST",
+			AttributeRequestURI:    "/hello",
+			AttributeRequestBody:   `{"zip":"zap"}`,
+		},
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "WebTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeRequestMethod: "POST",
+			AttributeRequestURI:    "/hello",
+		},
+	}})
+}
+
+func TestErrorRequestBodyDisabled(t *testing.T) {
+	enabled := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.CaptureRequestBody = true
+	}
+	testcases := []struct {
+		name    string
+		replyfn func(*internal.ConnectReply)
+		cfgfn   func(*Config)
+	}{
+		{
+			name: "disabled",
+			cfgfn: func(cfg *Config) {
+				cfg.DistributedTracer.Enabled = false
+			},
+		},
+		{
+			name: "high security",
+			cfgfn: func(cfg *Config) {
+				enabled(cfg)
+				cfg.HighSecurity = true
+			},
+		},
+		{
+			name: "attributes exclude",
+			cfgfn: func(cfg *Config) {
+				enabled(cfg)
+				cfg.Attributes.Exclude = []string{AttributeRequestBody}
+			},
+		},
+		{
+			name: "error collector attributes exclude",
+			cfgfn: func(cfg *Config) {
+				enabled(cfg)
+				cfg.ErrorCollector.Attributes.Exclude = []string{"request.*"}
+				cfg.ErrorCollector.Attributes.Include = []string{AttributeRequestMethod, AttributeRequestURI}
+			},
+		},
+		{
+			name: "attribute filter",
+			cfgfn: func(cfg *Config) {
+				enabled(cfg)
+				cfg.AttributeFilter = func(key string, val interface{}, dest AttributeDestination) bool {
+					return key != AttributeRequestBody
+				}
+			},
+		},
+		{
+			name: "attributes include policy",
+			replyfn: func(reply *internal.ConnectReply) {
+				reply.SecurityPolicies.AttributesInclude.SetEnabled(false)
+			},
+			cfgfn: enabled,
+		},
+		{
+			name: "custom parameters policy",
+			replyfn: func(reply *internal.ConnectReply) {
+				reply.SecurityPolicies.CustomParameters.SetEnabled(false)
+			},
+			cfgfn: enabled,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := testApp(tc.replyfn, tc.cfgfn, t)
+			captureRequestBodyTxn(app, `{"zip":"zap"}`)
+			app.ExpectErrors(t, []internal.WantError{{
+				TxnName: "WebTransaction/Go/hello",
+				Klass:   "newrelic.myError",
+				AgentAttributes: map[string]interface{}{
+					AttributeRequestMethod: "POST",
+					AttributeRequestURI:    "/hello",
+				},
+			}})
+		})
+	}
+}
+
+func TestErrorRequestBodyNotTruncated(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.ErrorCollector.CaptureRequestBody = true
+	}, t)
+	body := strings.Repeat("a", maxCapturedRequestBody)
+	captureRequestBodyTxn(app, body)
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/hello",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+		AgentAttributes: map[string]interface{}{
+			AttributeRequestMethod: "POST",
+			AttributeRequestURI:    "/hello",
+			AttributeRequestBody:   body,
+		},
+	}})
+}
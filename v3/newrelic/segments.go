@@ -70,6 +70,13 @@ type DatastoreSegment struct {
 	// being executed.  This becomes the db.instance attribute on Span events
 	// and Transaction Trace segments.
 	DatabaseName string
+	// RowsAffected and RowsReturned optionally record the number of rows
+	// the query affected or returned.  These become the db.rowsAffected and
+	// db.rowsReturned attributes on Span events and Transaction Trace
+	// segments.  Leave them nil, or set them to a negative value, if the
+	// count is unknown.
+	RowsAffected *int64
+	RowsReturned *int64
 
 	// secureAgentEvent is used when vulnerability scanning is enabled to
 	// record security-related information about the datastore operations.
@@ -125,6 +132,10 @@ type ExternalSegment struct {
 	// secureAgentEvent records security information when vulnerability
 	// scanning is enabled.
 	secureAgentEvent any
+
+	// timings records the connection timings of the request when the
+	// segment is started with WithConnectionTimings.
+	timings *externalTimings
 }
 
 // MessageProducerSegment instruments calls to add messages to a queueing system.
@@ -327,9 +338,11 @@ func StartSegment(txn *Transaction, name string) *Segment {
 // nil then StartExternalSegment will look for a Transaction in the request's
 // context using FromContext.
 //
+// Options, such as WithConnectionTimings, are applied in order.
+//
 // Using the same http.Client for all of your external requests?  Check out
 // NewRoundTripper: You may not need to use StartExternalSegment at all!
-func StartExternalSegment(txn *Transaction, request *http.Request) *ExternalSegment {
+func StartExternalSegment(txn *Transaction, request *http.Request, options ...ExternalSegmentOption) *ExternalSegment {
 	if nil == txn {
 		txn = transactionFromRequestContext(request)
 	}
@@ -337,6 +350,11 @@ func StartExternalSegment(txn *Transaction, request *http.Request) *ExternalSegm
 		StartTime: txn.StartSegmentNow(),
 		Request:   request,
 	}
+	for _, opt := range options {
+		if nil != opt {
+			opt(s)
+		}
+	}
 	if IsSecurityAgentPresent() {
 		s.secureAgentEvent = secureAgent.SendEvent("OUTBOUND", request)
 	}
@@ -4,10 +4,14 @@
 package newrelic
 
 import (
+	"bufio"
 	"bytes"
+	"os"
 	"path"
 	"runtime"
 	"strings"
+
+	"github.com/newrelic/go-agent/v3/internal/jsonx"
 )
 
 // stackTrace is a stack trace.
@@ -115,3 +119,78 @@ func (st stackTrace) MarshalJSON() ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+// sourceContextLines is the number of source lines captured on each side of
+// the failing line when Config.ErrorCollector.CaptureSourceContext is enabled.
+const sourceContextLines = 2
+
+// sourceContext contains the source lines surrounding the line at which an
+// error occurred.
+type sourceContext struct {
+	File      string
+	Line      int64
+	StartLine int64
+	Lines     []string
+}
+
+// sourceContext returns the source surrounding the first non-agent frame of
+// the stack trace, or nil if the source file cannot be read.
+func (st stackTrace) sourceContext() *sourceContext {
+	return sourceContextFromFrames(st.frames())
+}
+
+func sourceContextFromFrames(frames []StacktraceFrame) *sourceContext {
+	for len(frames) > 0 && (frames[0].isAgent() || strings.HasPrefix(frames[0].Name, "runtime.")) {
+		frames = frames[1:]
+	}
+	if len(frames) == 0 || frames[0].File == "" || frames[0].Line <= 0 {
+		return nil
+	}
+	frame := frames[0]
+
+	f, err := os.Open(frame.File)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	sc := &sourceContext{
+		File:      frame.File,
+		Line:      frame.Line,
+		StartLine: frame.Line - sourceContextLines,
+	}
+	if sc.StartLine < 1 {
+		sc.StartLine = 1
+	}
+	scanner := bufio.NewScanner(f)
+	for n := int64(1); n <= frame.Line+sourceContextLines && scanner.Scan(); n++ {
+		if n >= sc.StartLine {
+			sc.Lines = append(sc.Lines, truncateStringValueIfLong(scanner.Text()))
+		}
+	}
+	if int64(len(sc.Lines)) <= frame.Line-sc.StartLine {
+		// The file is shorter than expected, so it does not match the
+		// running binary.
+		return nil
+	}
+	return sc
+}
+
+// WriteJSON adds the source context to the buffer.
+func (sc *sourceContext) WriteJSON(buf *bytes.Buffer) {
+	buf.WriteByte('{')
+	w := jsonFieldsWriter{buf: buf}
+	w.stringField("filepath", sc.File)
+	w.intField("line", sc.Line)
+	w.intField("start_line", sc.StartLine)
+	w.addKey("lines")
+	buf.WriteByte('[')
+	for idx, line := range sc.Lines {
+		if idx > 0 {
+			buf.WriteByte(',')
+		}
+		jsonx.AppendString(buf, line)
+	}
+	buf.WriteByte(']')
+	buf.WriteByte('}')
+}
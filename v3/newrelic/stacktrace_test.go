@@ -6,6 +6,7 @@ package newrelic
 import (
 	"bytes"
 	"encoding/json"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -228,3 +229,52 @@ func TestFramesCount(t *testing.T) {
 		t.Error("Invalid # of frames", len(st), len(frames))
 	}
 }
+
+func TestSourceContextFromFrames(t *testing.T) {
+	_, file, line, _ := runtime.Caller(0)
+	frames := []StacktraceFrame{
+		{Name: "github.com/newrelic/go-agent/v3/newrelic.(*Transaction).NoticeError", File: "agent.go", Line: 1},
+		{Name: "main.handler", File: file, Line: int64(line)},
+	}
+	sc := sourceContextFromFrames(frames)
+	if sc == nil {
+		t.Fatal("expected source context")
+	}
+	if sc.File != file || sc.Line != int64(line) || sc.StartLine != int64(line-sourceContextLines) {
+		t.Errorf("unexpected source context location: %s:%d start=%d", sc.File, sc.Line, sc.StartLine)
+	}
+	if len(sc.Lines) != 2*sourceContextLines+1 {
+		t.Fatalf("unexpected number of lines: %d", len(sc.Lines))
+	}
+	if !strings.Contains(sc.Lines[sourceContextLines], "runtime.Caller(0)") {
+		t.Errorf("unexpected failing line: %q", sc.Lines[sourceContextLines])
+	}
+
+	buf := &bytes.Buffer{}
+	sc.WriteJSON(buf)
+	var js map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &js); err != nil {
+		t.Fatal(err, buf.String())
+	}
+	if js["filepath"] != file || len(js["lines"].([]interface{})) != 2*sourceContextLines+1 {
+		t.Error(buf.String())
+	}
+}
+
+func TestSourceContextFromFramesMissingFile(t *testing.T) {
+	frames := []StacktraceFrame{
+		{Name: "main.handler", File: "/does/not/exist.go", Line: 10},
+	}
+	if sc := sourceContextFromFrames(frames); sc != nil {
+		t.Error(sc)
+	}
+	frames = []StacktraceFrame{
+		{Name: "main.handler", File: "", Line: 10},
+	}
+	if sc := sourceContextFromFrames(frames); sc != nil {
+		t.Error(sc)
+	}
+	if sc := sourceContextFromFrames(nil); sc != nil {
+		t.Error(sc)
+	}
+}
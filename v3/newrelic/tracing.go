@@ -78,7 +78,16 @@ type txnData struct {
 	IsWeb              bool
 	SlowQueriesEnabled bool
 	noticeErrors       bool // If errors are not expected or ignored, then true
+	unhandledErrors    bool // If noticed errors were not recorded as handled, then true
 	expectedErrors     bool
+	responseCode       int    // The response code written, or zero if none was written
+	requestMethod      string // The request method given to SetWebRequest
+	userAttrsDropped   int    // The number of user attributes dropped due to the limit
+
+	// busyTime is the total time given to Transaction.AddBusyTime.
+	busyTime time.Duration
+	// panicked is true if a panic was recovered during the transaction.
+	panicked bool
 
 	stamp           segmentStamp
 	threadIDCounter uint64
@@ -108,6 +117,7 @@ type txnData struct {
 	logs                    logEventHeap
 
 	customSegments    map[string]*metricData
+	scopedMetrics     map[string]*metricData
 	datastoreSegments map[datastoreMetricKey]*metricData
 	externalSegments  map[externalMetricKey]*metricData
 	messageSegments   map[internal.MessageMetricKey]*metricData
@@ -201,6 +211,25 @@ func (b boolJSONWriter) WriteJSON(buf *bytes.Buffer) {
 	}
 }
 
+// spanAttributeValue returns the value held by a span or segment attribute
+// writer, as passed to Config.AttributeFilter.
+func spanAttributeValue(w jsonWriter) interface{} {
+	switch v := w.(type) {
+	case stringJSONWriter:
+		return string(v)
+	case intJSONWriter:
+		return int(v)
+	case floatJSONWriter:
+		return float64(v)
+	case boolJSONWriter:
+		return bool(v)
+	case queryParameters:
+		return map[string]interface{}(v)
+	default:
+		return w
+	}
+}
+
 // spanAttributeMap is used for span attributes and segment attributes. The
 // value is a jsonWriter to allow for segment query parameters.
 type spanAttributeMap map[string]jsonWriter
@@ -215,6 +244,14 @@ func (m *spanAttributeMap) addInt(key string, val int) {
 	m.add(key, intJSONWriter(val))
 }
 
+// addRowCount adds a datastore row count unless it is nil or negative,
+// either of which means the count is unknown.
+func (m *spanAttributeMap) addRowCount(key string, val *int64) {
+	if val != nil && *val >= 0 {
+		m.add(key, intJSONWriter(*val))
+	}
+}
+
 func (m *spanAttributeMap) addBool(key string, val bool) {
 	m.add(key, boolJSONWriter(val))
 }
@@ -346,6 +383,17 @@ func (t *txnData) HasErrors() bool {
 	return len(t.Errors) > 0
 }
 
+// hasMetricErrors indicates whether the transaction had errors other than those
+// recorded with Transaction.RecordErrorInfoOnly.
+func (t *txnData) hasMetricErrors() bool {
+	for _, e := range t.Errors {
+		if !e.InfoOnly {
+			return true
+		}
+	}
+	return false
+}
+
 // HasExpectedErrors is a special case where the txn has errors but we dont increment error metrics
 func (t *txnData) HasExpectedErrors() bool {
 	return t.expectedErrors
@@ -495,6 +543,11 @@ func endSegment(t *txnData, thread *tracingThread, start segmentStartTime, now t
 	}
 
 	s.threadID = thread.threadID
+	if s.threadID > 0 {
+		// Segments recorded by goroutines created with
+		// Transaction.NewGoroutine are tagged with their thread index.
+		s.agentAttributes.addInt(SpanAttributeThreadIndex, int(s.threadID))
+	}
 
 	thread.RecordActivity(s.start.Time)
 	thread.RecordActivity(s.stop.Time)
@@ -549,6 +602,7 @@ type endExternalParams struct {
 	Library    string
 	Method     string
 	StatusCode *int
+	Timings    *externalTimings
 }
 
 // endExternalSegment ends an external segment.
@@ -621,6 +675,7 @@ func endExternalSegment(p endExternalParams) error {
 		if p.Library == "http" {
 			attributes.addString(SpanAttributeHTTPURL, safeURL(p.URL))
 		}
+		p.Timings.addAttributes(&attributes)
 		t.saveTraceSegment(end, key.scopedMetric(), attributes, transactionGUID)
 	}
 
@@ -638,6 +693,7 @@ func endExternalSegment(p endExternalParams) error {
 		} else if p.Response != nil {
 			evt.AgentAttributes.addInt(SpanAttributeHTTPStatusCode, p.Response.StatusCode)
 		}
+		p.Timings.addAttributes(&evt.AgentAttributes)
 		t.saveSpanEvent(evt)
 	}
 
@@ -714,6 +770,8 @@ type endDatastoreParams struct {
 	Host               string
 	PortPathOrID       string
 	Database           string
+	RowsAffected       *int64
+	RowsReturned       *int64
 	ThisHost           string
 }
 
@@ -815,6 +873,8 @@ func endDatastoreSegment(p endDatastoreParams) error {
 		attributes.addString(SpanAttributeDBInstance, p.Database)
 		attributes.addString(SpanAttributePeerAddress, datastoreSpanAddress(p.Host, p.PortPathOrID))
 		attributes.addString(SpanAttributePeerHostname, p.Host)
+		attributes.addRowCount(SpanAttributeDBRowsAffected, p.RowsAffected)
+		attributes.addRowCount(SpanAttributeDBRowsReturned, p.RowsReturned)
 		if len(queryParams) > 0 {
 			attributes.add(spanAttributeQueryParameters, queryParams)
 		}
@@ -847,6 +907,8 @@ func endDatastoreSegment(p endDatastoreParams) error {
 		evt.AgentAttributes.addString(SpanAttributePeerAddress, datastoreSpanAddress(p.Host, p.PortPathOrID))
 		evt.AgentAttributes.addString(SpanAttributePeerHostname, p.Host)
 		evt.AgentAttributes.addString(SpanAttributeDBCollection, p.Collection)
+		evt.AgentAttributes.addRowCount(SpanAttributeDBRowsAffected, p.RowsAffected)
+		evt.AgentAttributes.addRowCount(SpanAttributeDBRowsReturned, p.RowsReturned)
 		p.TxnData.saveSpanEvent(evt)
 	}
 
@@ -866,6 +928,12 @@ func mergeBreakdownMetrics(t *txnData, metrics *metricTable) {
 		metrics.add(name, scope, *data, unforced)
 	}
 
+	// Scoped Custom Metrics
+	for name, data := range t.scopedMetrics {
+		metrics.add(name, "", *data, unforced)
+		metrics.add(name, scope, *data, unforced)
+	}
+
 	// External Segment Metrics
 	for key, data := range t.externalSegments {
 		metrics.add(externalRollupMetric.all, "", *data, forced)
@@ -4,6 +4,7 @@
 package newrelic
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -32,12 +33,10 @@ func (txn *Transaction) End() {
 		return
 	}
 
-	var r any
-	if txn.thread.Config.ErrorCollector.RecordPanics {
-		// recover must be called in the function directly being deferred,
-		// not any nested call!
-		r = recover()
-	}
+	// recover must be called in the function directly being deferred,
+	// not any nested call!  The panic is always re-panicked by End, and
+	// is only recorded as an error when RecordPanics is enabled.
+	r := recover()
 	if txn.thread.IsWeb && IsSecurityAgentPresent() {
 		secureAgent.SendEvent("INBOUND_END", "")
 	}
@@ -74,6 +73,19 @@ func (txn *Transaction) SetName(name string) {
 	txn.thread.logAPIError(txn.thread.SetName(name), "set transaction name", nil)
 }
 
+// ClearName removes any name previously given to the transaction, including
+// the name provided to StartTransaction.  The transaction will then receive
+// the default name for its type ("WebTransaction/Go/" or
+// "OtherTransaction/Go/") unless SetName is called again before End.  This
+// is useful when a preliminary name was set by middleware but the request
+// was not matched by a route.
+func (txn *Transaction) ClearName() {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.ClearName(), "clear transaction name", nil)
+}
+
 // Name returns the name currently set for the transaction, as, e.g. by a call to SetName.
 // If unable to do so (such as due to a nil transaction pointer), the empty string is returned.
 func (txn *Transaction) Name() string {
@@ -119,6 +131,65 @@ func (txn *Transaction) NoticeError(err error) {
 	txn.thread.logAPIError(txn.thread.NoticeError(err, false), "notice error", nil)
 }
 
+// NoticeErrorWithAttributes records an error along with the attributes
+// provided.  It behaves like NoticeError, and the attributes provided are
+// merged with any provided by the error's ErrorAttributes method, taking
+// precedence on conflict.
+//
+// When Config.HighSecurity is enabled, the error message is replaced and
+// string and floating point attribute values are dropped.  Boolean and integer
+// attribute values cannot contain free text, so they are still recorded.  This
+// allows non-sensitive details such as a numeric error code to be recorded in
+// high security mode.  When custom parameters are disabled by security policy,
+// no attributes are recorded.  The error class is always recorded.
+func (txn *Transaction) NoticeErrorWithAttributes(err error, attributes map[string]interface{}) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.NoticeErrorWithAttributes(err, attributes), "notice error", nil)
+}
+
+// NoticeErrorWithGroupAndAttributes records an error in the same way as
+// NoticeErrorWithAttributes, and assigns it to the error group given, which
+// is recorded on the error event as "error.group.name".  The group takes
+// precedence over any returned by Config.ErrorCollector.ErrorGroupCallback;
+// if it is empty, the callback is used as usual.
+func (txn *Transaction) NoticeErrorWithGroupAndAttributes(err error, group string, attributes map[string]interface{}) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.NoticeErrorWithGroupAndAttributes(err, group, attributes), "notice error", nil)
+}
+
+// NoticeErrorWithID records an error in the same way as NoticeError, and
+// returns a newly generated identifier which is recorded on the error event
+// and error trace as "error.id".  Showing this identifier to the user, eg. on
+// an error page, allows the recorded error to be found from the identifier
+// the user reports.  An empty string is returned if the error was not
+// recorded, including when its class is excluded by
+// Config.ErrorCollector.RecordOnlyClasses or when the transaction has already
+// recorded the maximum number of errors.
+func (txn *Transaction) NoticeErrorWithID(err error) string {
+	if txn == nil || txn.thread == nil {
+		return ""
+	}
+	id, e := txn.thread.NoticeErrorWithID(err)
+	txn.thread.logAPIError(e, "notice error", nil)
+	return id
+}
+
+// NoticeErrorWithSeverity records an error in the same way as NoticeError,
+// along with a severity, such as "warning" or "critical", which is recorded
+// on the error event as "error.severity".  Errors whose severity is listed in
+// Config.ErrorCollector.ExpectSeverities are treated as expected errors, see
+// NoticeExpectedError.
+func (txn *Transaction) NoticeErrorWithSeverity(err error, severity string) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.NoticeErrorWithSeverity(err, severity), "notice error", nil)
+}
+
 // NoticeExpectedError records an error that was expected to occur. Errors recoreded with this
 // method will not trigger any error alerts or count towards your error metrics.
 // The Transaction saves the first five errors.
@@ -153,6 +224,36 @@ func (txn *Transaction) NoticeExpectedError(err error) {
 	txn.thread.logAPIError(txn.thread.NoticeError(err, true), "notice error", nil)
 }
 
+// RecordErrorInfoOnly records an error purely for information.  The error
+// is added to error traces and error events, if they are enabled, and is
+// marked as expected, but it has no other effect on the transaction.
+//
+// This differs from NoticeExpectedError: an expected error still counts
+// towards the ErrorsExpected/all metric, the transaction's error count, and
+// marks the transaction event and current span as having an error.  An
+// error recorded with RecordErrorInfoOnly does none of these things.  Like
+// an expected error, it does not affect apdex.  The error still counts
+// towards the limit of errors saved per transaction.
+func (txn *Transaction) RecordErrorInfoOnly(err error) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.RecordErrorInfoOnly(err), "record error", nil)
+}
+
+// NoticeHandledError records an error that was recovered from rather than
+// returned to the client.  Handled errors are recorded in the same way as
+// errors passed to NoticeError, and the resulting error event is marked with
+// an "error.handled" attribute.  To prevent handled errors from placing the
+// transaction in the failing Apdex zone, set
+// Config.ErrorCollector.ExcludeHandledFromApdex.
+func (txn *Transaction) NoticeHandledError(err error) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.NoticeHandledError(err), "notice error", nil)
+}
+
 // AddAttribute adds a key value pair to the transaction event, errors,
 // and traces.
 //
@@ -171,6 +272,11 @@ func (txn *Transaction) AddAttribute(key string, value any) {
 // SetUserID is used to track the user that a transaction, and all data that is recorded as a subset of that transaction,
 // belong to or interact with. This will propogate an attribute containing this information to all events that are
 // a child of this transaction, like errors and spans.
+//
+// The user ID is recorded as the "enduser.id" agent attribute, which is
+// subject to attribute destination configuration.  Since it may identify a
+// person, it is not recorded when Config.HighSecurity is enabled.  User IDs
+// longer than 255 bytes are not recorded.
 func (txn *Transaction) SetUserID(userID string) {
 	if txn == nil || txn.thread == nil {
 		return
@@ -179,6 +285,197 @@ func (txn *Transaction) SetUserID(userID string) {
 	txn.thread.logAPIError(txn.thread.AddUserID(userID), "set user ID", nil)
 }
 
+// SetRequestID records an identifier for the request handled by the
+// Transaction, such as one generated by upstream middleware, as the
+// "request.id" attribute.  The identifier is included on the transaction
+// event, error events, and traces, and is returned by GetLinkingMetadata and
+// added to logs decorated by EnrichLog so that logs can be correlated with the
+// Transaction without distributed tracing.
+//
+// Since the identifier is written into decorated log lines, identifiers
+// containing whitespace, control characters, or '|' are rejected.  The
+// identifier is not recorded when Config.HighSecurity is enabled, and is not
+// added to logs or linking metadata when the "request.id" attribute is
+// excluded from all destinations.
+func (txn *Transaction) SetRequestID(id string) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.SetRequestID(id), "set request ID", nil)
+}
+
+// CurrentApdexZone returns the Apdex zone the Transaction would be placed in
+// if it ended now, based on the time elapsed since it started, its Apdex
+// threshold, and any errors noticed so far.  The Transaction is not modified.
+// Once the Transaction has ended, its final zone is returned.  ApdexNone is
+// returned for non-web transactions.
+func (txn *Transaction) CurrentApdexZone() ApdexZone {
+	if txn == nil || txn.thread == nil {
+		return ApdexNone
+	}
+	return txn.thread.CurrentApdexZone()
+}
+
+// RecordBytesRead records that n bytes have been read from the request body.
+// Successive calls are summed and the total is recorded as the
+// "request.bodyBytesRead" attribute when the Transaction ends.  This is
+// useful when the Content-Length header does not reflect the amount of data
+// consumed, such as for chunked uploads.  Non-positive values are ignored.
+func (txn *Transaction) RecordBytesRead(n int64) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.RecordBytesRead(n), "record bytes read", nil)
+}
+
+// Elapsed returns the time elapsed since the Transaction started.  Once the
+// Transaction has ended, its final duration is returned.  Elapsed is safe to
+// call from multiple goroutines and is useful for logging the progress of
+// long running transactions.
+func (txn *Transaction) Elapsed() time.Duration {
+	if txn == nil || txn.thread == nil {
+		return 0
+	}
+	return txn.thread.Elapsed()
+}
+
+// MarkSuccess indicates that the Transaction completed successfully even
+// though errors were noticed.  When called before End, noticed errors no
+// longer place the Transaction in the failing Apdex zone; the zone is instead
+// calculated from the Transaction's duration alone.  The noticed errors are
+// still recorded.
+func (txn *Transaction) MarkSuccess() {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.MarkSuccess(), "mark transaction success", nil)
+}
+
+// DisableAttributes prevents the Transaction from recording any attributes.
+// Attributes captured automatically, such as those from SetWebRequest and
+// SetWebResponse, and attributes added with AddAttribute are discarded,
+// whether they were added before or after this call.  The transaction event,
+// errors, and traces for the Transaction are recorded with empty attribute
+// sets.  Use this for endpoints handling sensitive data.
+func (txn *Transaction) DisableAttributes() {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.DisableAttributes(), "disable attributes", nil)
+}
+
+// SetRoutePattern records the route template that matched the request, such as
+// "/users/:id", as the "http.route" attribute.  The route pattern is recorded
+// independently of the transaction name, allowing transactions to be queried
+// by route even when SetName has been used.  Patterns longer than 255 bytes
+// are not recorded.
+func (txn *Transaction) SetRoutePattern(pattern string) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.SetRoutePattern(pattern), "set route pattern", nil)
+}
+
+// SetOperationName records a stable identifier for the work performed by a
+// background transaction, such as a job type, as the "operation.name"
+// attribute.  This allows retries of the same job to be grouped even when
+// their transaction names differ.  If a background transaction's name is
+// empty when it ends, the operation name is used as its name.  Operation
+// names longer than 255 bytes are not recorded.
+func (txn *Transaction) SetOperationName(op string) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.SetOperationName(op), "set operation name", nil)
+}
+
+// SetLatencyTarget sets a target duration for the transaction, such as one
+// taken from a latency service level objective.  When the transaction ends,
+// whether its duration was within the target is recorded as the
+// AttributeMetLatencyTarget attribute on the transaction event.  The target
+// is independent of the Apdex threshold.  The target must be positive.
+func (txn *Transaction) SetLatencyTarget(target time.Duration) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.SetLatencyTarget(target), "set latency target", nil)
+}
+
+// SetAttemptNumber records the attempt number of the work performed by the
+// transaction, such as the number of times a background job has been tried,
+// as the "attemptNumber" attribute on the transaction event.  This allows
+// first attempt successes to be distinguished from eventual successes.  The
+// attempt number must not be negative.
+func (txn *Transaction) SetAttemptNumber(n int) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.SetAttemptNumber(n), "set attempt number", nil)
+}
+
+// AddBusyTime adds to the time the transaction spent actively processing,
+// as opposed to waiting, such as the time a worker pool job spent running
+// between yielding and resuming.  The total is recorded as the "busyTime"
+// attribute and the "BusyTime/" metric for the transaction when it ends,
+// allowing jobs that are slow because they are blocked to be distinguished
+// from those that are processing heavy.  The duration must not be negative.
+func (txn *Transaction) AddBusyTime(d time.Duration) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.AddBusyTime(d), "add busy time", nil)
+}
+
+// PreserveErrorMessages keeps the messages of the errors recorded by this
+// transaction when Config.HighSecurity would otherwise replace them.  Use it
+// only for transactions known to handle no sensitive data.  It has no effect
+// unless Config.AllowPerTxnSecurityOverride is enabled, and messages are still
+// removed if required by security policies.
+func (txn *Transaction) PreserveErrorMessages() {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.PreserveErrorMessages(), "preserve error messages", nil)
+}
+
+// RecordScopedMetric records a custom timing metric, with the value given in
+// seconds, both unscoped and scoped to the transaction's name so that it
+// appears in the transaction's breakdown.  Like Application.RecordCustomMetric,
+// the metric name is prefixed by Config.CustomMetricPrefix, which is "Custom/"
+// by default.  The metrics are recorded when the transaction ends.
+func (txn *Transaction) RecordScopedMetric(name string, value float64) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.RecordScopedMetric(name, value), "record scoped metric", map[string]interface{}{
+		"metric-name": name,
+	})
+}
+
+// SetColdStart records whether the transaction is the first invocation after
+// a cold start of a serverless function as the "aws.lambda.coldStart"
+// attribute.  The attribute is absent unless SetColdStart is called, and is
+// subject to the usual attribute destination configuration.
+func (txn *Transaction) SetColdStart(coldStart bool) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.SetColdStart(coldStart), "set cold start", nil)
+}
+
+// SetStartTime overrides the time at which the transaction started, which
+// is used both for the transaction's duration and as the timestamp of its
+// transaction event.  This is useful when replaying recorded requests, so
+// that data is reported with the time of the original request.
+// SetStartTime must be called before any segments are started or a response
+// code is written, and the time must be non-zero and not in the future.
+func (txn *Transaction) SetStartTime(start time.Time) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.SetStartTime(start), "set start time", nil)
+}
+
 // RecordLog records the data from a single log line.
 // This consumes a LogData object that should be configured
 // with data taken from a logging framework.
@@ -188,7 +485,13 @@ func (txn *Transaction) SetUserID(userID string) {
 // as well as log metrics depending on how your application is
 // configured.
 func (txn *Transaction) RecordLog(log LogData) {
-	event, err := log.toLogEvent()
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	if txn.thread.Config.ApplicationLogging.Forwarding.CaptureSource {
+		log.captureLogSource()
+	}
+	event, err := log.toLogEvent(txn.thread.Config.ApplicationLogging.Forwarding.MessageScrubber)
 	if err != nil {
 		txn.Application().app.Error("unable to record log", map[string]any{
 			"reason": err.Error(),
@@ -199,6 +502,9 @@ func (txn *Transaction) RecordLog(log LogData) {
 	metadata := txn.GetTraceMetadata()
 	event.spanID = metadata.SpanID
 	event.traceID = metadata.TraceID
+	if event.traceID != "" {
+		event.traceFlags = w3cTraceFlags(txn.IsSampled())
+	}
 	txn.thread.StoreLog(&event)
 }
 
@@ -207,23 +513,33 @@ func (txn *Transaction) RecordLog(log LogData) {
 // details on request attributes, url, and method.  If headers are
 // present, the agent will look for distributed tracing headers using
 // Transaction.AcceptDistributedTraceHeaders.
+//
+// When Config.TransactionTypeResolver is set, it is called with the non-nil
+// request to decide whether the transaction is a web transaction.
 func (txn *Transaction) SetWebRequestHTTP(r *http.Request) {
 	if r == nil {
 		txn.SetWebRequest(WebRequest{})
 		return
 	}
+	if txn == nil || txn.thread == nil {
+		return
+	}
 	wr := WebRequest{
 		Header:        r.Header,
 		URL:           r.URL,
 		Method:        r.Method,
 		Transport:     transport(r),
 		Host:          r.Host,
+		TLS:           r.TLS,
 		Body:          reqBody(r),
 		ServerName:    serverName(r),
 		Type:          "HTTP",
 		RemoteAddress: r.RemoteAddr,
 	}
-	txn.SetWebRequest(wr)
+	if IsSecurityAgentPresent() {
+		secureAgent.SendEvent("INBOUND", wr)
+	}
+	txn.thread.logAPIError(txn.thread.SetWebRequestHTTP(r, wr), "set web request", nil)
 }
 
 func transport(r *http.Request) TransportType {
@@ -383,6 +699,58 @@ func (txn *Transaction) AcceptDistributedTraceHeadersFromJSON(t TransportType, j
 	return nil
 }
 
+// CreateDistributedTracePayload returns the distributed trace headers that
+// InsertDistributedTraceHeaders would add, serialized as a JSON object.  It
+// is intended for outbound calls which do not carry HTTP headers, such as
+// queue messages: the receiving service should pass the payload to
+// Transaction.AcceptDistributedTracePayload.  Like
+// InsertDistributedTraceHeaders, it should be called for every outbound call.
+// An empty string is returned if the Distributed Tracer is disabled or the
+// application is not yet connected.
+func (txn *Transaction) CreateDistributedTracePayload() string {
+	if txn == nil || txn.thread == nil {
+		return ""
+	}
+	hdrs := http.Header{}
+	txn.thread.CreateDistributedTracePayload(hdrs)
+	return distributedTraceHeadersToJSON(hdrs)
+}
+
+// AcceptDistributedTracePayload links transactions by accepting a payload
+// created by Transaction.CreateDistributedTracePayload in another service,
+// in the same way as AcceptDistributedTraceHeaders.  Once accepted, the
+// trace ID returned by GetTraceMetadata and recorded on events and logs is
+// that of the calling service.
+//
+// A payload which is not a valid JSON object of headers is ignored and
+// recorded as the
+// "Supportability/DistributedTrace/AcceptPayload/ParseException" metric
+// rather than reported as an error.
+func (txn *Transaction) AcceptDistributedTracePayload(payload string) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.AcceptDistributedTracePayload(payload), "accept trace payload", nil)
+}
+
+// distributedTraceHeadersToJSON serializes the headers in the format read by
+// DistributedTraceHeadersFromJSON, returning an empty string if there are
+// none.
+func distributedTraceHeadersToJSON(hdrs http.Header) string {
+	if len(hdrs) == 0 {
+		return ""
+	}
+	m := make(map[string]string, len(hdrs))
+	for k := range hdrs {
+		m[strings.ToLower(k)] = hdrs.Get(k)
+	}
+	js, err := json.Marshal(m)
+	if nil != err {
+		return ""
+	}
+	return string(js)
+}
+
 // DistributedTraceHeadersFromJSON takes a set of distributed trace headers as a JSON-encoded string
 // and emits a http.Header value suitable for passing on to the
 // txn.AcceptDistributedTraceHeaders() function.
@@ -512,6 +880,31 @@ func (txn *Transaction) NewGoroutine() *Transaction {
 	return newTxn
 }
 
+// Go runs fn in a new goroutine using a Transaction reference returned by
+// NewGoroutine.  If fn panics, the panic is recorded as an error on the
+// Transaction and then re-panicked, unless
+// Config.ErrorCollector.RecoverPanics is set, in which case the panic is
+// swallowed.
+func (txn *Transaction) Go(fn func()) {
+	if txn == nil || txn.thread == nil {
+		go fn()
+		return
+	}
+	go txn.NewGoroutine().runRecovered(fn)
+}
+
+func (txn *Transaction) runRecovered(fn func()) {
+	defer func() {
+		if r := recover(); nil != r {
+			txn.thread.logAPIError(txn.thread.NoticePanic(r), "notice panic", nil)
+			if !txn.thread.Config.ErrorCollector.RecoverPanics {
+				panic(r)
+			}
+		}
+	}()
+	fn()
+}
+
 // GetTraceMetadata returns distributed tracing identifiers.  Empty
 // string identifiers are returned if the transaction has finished.
 func (txn *Transaction) GetTraceMetadata() TraceMetadata {
@@ -541,6 +934,45 @@ func (txn *Transaction) IsSampled() bool {
 	return txn.thread.IsSampled()
 }
 
+// ErrorsEnabled indicates whether errors noticed by the Transaction will be
+// recorded.  It reflects both the local Config.ErrorCollector.Enabled setting
+// and the error collection setting received from New Relic when the
+// application connected.  Use it to avoid building an expensive error, eg. a
+// newrelic.Error with many attributes, which would then be discarded.  False
+// is returned if the Transaction has finished.
+func (txn *Transaction) ErrorsEnabled() bool {
+	if txn == nil || txn.thread == nil {
+		return false
+	}
+	return txn.thread.ErrorsEnabled()
+}
+
+// ApdexThreshold returns the Apdex threshold that was applied to the
+// Transaction when it ended.  This is the threshold configured for the
+// application, or the threshold of the key transaction matching the
+// Transaction's final name.  Zero is returned for non-web transactions and
+// for transactions which have not yet ended.
+func (txn *Transaction) ApdexThreshold() time.Duration {
+	if txn == nil || txn.thread == nil {
+		return 0
+	}
+	return txn.thread.getApdexThreshold()
+}
+
+// ApdexThresholds returns the satisfying and tolerating Apdex thresholds that
+// were applied to the Transaction when it ended.  The satisfying threshold is
+// the value returned by ApdexThreshold, and the tolerating threshold is four
+// times that value.  Transactions with durations above the tolerating
+// threshold are considered frustrating.  Zeros are returned for non-web
+// transactions and for transactions which have not yet ended.
+func (txn *Transaction) ApdexThresholds() (satisfying, tolerating time.Duration) {
+	if txn == nil || txn.thread == nil {
+		return 0, 0
+	}
+	satisfying = txn.thread.getApdexThreshold()
+	return satisfying, apdexFailingThreshold(satisfying)
+}
+
 const (
 	// DistributedTraceNewRelicHeader is the header used by New Relic agents
 	// for automatic trace payload instrumentation.
@@ -581,6 +1013,69 @@ func (tt TransportType) toString() string {
 	}
 }
 
+// TransactionNameCallback is a user defined callback function, set using
+// Config.TransactionNameCallback, that renames transactions once it is known
+// whether they failed.  It is called by Transaction.End after any recovered
+// panic has been recorded, with the name given to StartTransaction or
+// SetName, eg. "GET /users", and whether the transaction noticed errors which
+// were neither expected nor ignored.  The returned name replaces the
+// transaction's name and is then subject to the usual naming rules.
+//
+// The callback is not called if the transaction's name was already frozen,
+// which happens when a distributed tracing payload is created or accepted
+// with cross application tracing enabled, or when the transaction is ignored.
+//
+// example function:
+//
+//	func nameFailures(name string, failed bool) string {
+//		if failed {
+//			return name + " (failed)"
+//		}
+//		return name
+//	}
+type TransactionNameCallback func(name string, failed bool) string
+
+// TransactionType classifies a transaction that was given an *http.Request
+// using Transaction.SetWebRequestHTTP.  It is returned by a
+// TransactionTypeResolver.
+type TransactionType int
+
+const (
+	// TransactionTypeDefault applies the default classification:  a
+	// transaction given a request is a web transaction.
+	TransactionTypeDefault TransactionType = iota
+	// TransactionTypeWeb marks the transaction as a web transaction.  Web
+	// transactions are named with the "WebTransaction" prefix and receive
+	// an Apdex score.
+	TransactionTypeWeb
+	// TransactionTypeBackground marks the transaction as a background
+	// transaction, named with the "OtherTransaction" prefix and without an
+	// Apdex score.  The request's attributes are still recorded.
+	TransactionTypeBackground
+	// TransactionTypeRPC marks the transaction as a remote procedure call,
+	// such as a gRPC method or GraphQL resolver, which was given a request
+	// only so that its headers could be captured.  RPC transactions are
+	// named and measured like background transactions, but unlike them
+	// the request's method and URL are not recorded as the request.method
+	// and request.uri attributes.
+	TransactionTypeRPC
+)
+
+// TransactionTypeResolver is a user defined callback function, set using
+// Config.TransactionTypeResolver, that decides the type of a transaction
+// from the request given to Transaction.SetWebRequestHTTP.  Returning
+// TransactionTypeDefault keeps the default classification.
+//
+// example function:
+//
+//	func resolveType(r *http.Request) newrelic.TransactionType {
+//		if r.Header.Get("Content-Type") == "application/grpc" {
+//			return newrelic.TransactionTypeRPC
+//		}
+//		return newrelic.TransactionTypeDefault
+//	}
+type TransactionTypeResolver func(*http.Request) TransactionType
+
 // WebRequest is used to provide request information to Transaction.SetWebRequest.
 type WebRequest struct {
 	// Header may be nil if you don't have any headers or don't want to
@@ -597,6 +1092,10 @@ type WebRequest struct {
 	// This is the value of the `Host` header. Go does not add it to the
 	// http.Header object and so must be passed separately.
 	Host string
+	// TLS contains the connection state of requests received over TLS.  It
+	// is used to record the negotiated TLS version and cipher suite.  It
+	// should be nil for plaintext requests.
+	TLS *tls.ConnectionState
 
 	// The following fields are needed for the secure agent's vulnerability
 	// detection features.
@@ -671,6 +1170,9 @@ type LinkingMetadata struct {
 	EntityGUID string
 	// Hostname is the hostname this entity is running on.
 	Hostname string
+	// RequestID is the identifier set by Transaction.SetRequestID.  This
+	// field is empty if no request ID has been set.
+	RequestID string
 }
 
 // TraceMetadata is returned by Transaction.GetTraceMetadata.  It contains
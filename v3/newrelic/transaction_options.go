@@ -0,0 +1,30 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import "time"
+
+// TransactionOptions configures a transaction when it is started with
+// Application.StartTransactionWithOptions.  The zero value starts a
+// transaction in the same way as Application.StartTransaction.
+type TransactionOptions struct {
+	// IsBackground keeps the transaction a background transaction even if
+	// SetWebRequest or SetWebRequestHTTP is later called on it.  The
+	// request attributes are still recorded.
+	IsBackground bool
+	// ApdexThreshold, if positive, overrides the apdex threshold received
+	// from New Relic for this transaction.
+	ApdexThreshold time.Duration
+	// Ignore starts the transaction ignored, as if Transaction.Ignore had
+	// been called.
+	Ignore bool
+	// DefaultAttributes are added to the transaction as if by
+	// Transaction.AddAttribute.  Invalid attributes are logged and
+	// skipped.
+	DefaultAttributes map[string]interface{}
+	// AttributeConfig, if not nil, replaces Config.Attributes for this
+	// transaction.  The destination specific attribute configuration and
+	// security policies still apply.
+	AttributeConfig *AttributeDestinationConfig
+}
@@ -0,0 +1,100 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestStartTransactionWithOptionsZeroValue(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransactionWithOptions("hello", TransactionOptions{})
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+	}})
+	app.ExpectMetrics(t, backgroundMetrics)
+}
+
+func TestStartTransactionWithOptionsIsBackground(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransactionWithOptions("hello", TransactionOptions{
+		IsBackground: true,
+	})
+	txn.SetWebRequestHTTP(helloRequest)
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+	}})
+	app.ExpectMetrics(t, backgroundMetrics)
+}
+
+func TestStartTransactionWithOptionsApdexThreshold(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransactionWithOptions("hello", TransactionOptions{
+		ApdexThreshold: time.Hour,
+	})
+	txn.SetWebRequest(WebRequest{Transport: TransportHTTP})
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"nr.apdexPerfZone": "S",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeApdexThreshold: time.Hour.Milliseconds(),
+		},
+	}})
+}
+
+func TestStartTransactionWithOptionsIgnore(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransactionWithOptions("hello", TransactionOptions{
+		Ignore: true,
+	})
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{})
+}
+
+func TestStartTransactionWithOptionsAttributes(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransactionWithOptions("hello", TransactionOptions{
+		DefaultAttributes: map[string]interface{}{
+			"zip":     "zap",
+			"secret":  "shh",
+			"invalid": struct{}{},
+		},
+		AttributeConfig: &AttributeDestinationConfig{
+			Enabled: true,
+			Exclude: []string{"secret"},
+		},
+	})
+	app.expectSingleLoggedError(t, "unable to add attribute", map[string]interface{}{
+		"reason": `attribute 'invalid' value of type struct {} is invalid`,
+	})
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		UserAttributes: map[string]interface{}{
+			"zip": "zap",
+		},
+	}})
+}
+
+func TestStartTransactionWithOptionsNilApp(t *testing.T) {
+	var app *Application
+	if txn := app.StartTransactionWithOptions("hello", TransactionOptions{}); nil != txn {
+		t.Error(txn)
+	}
+}
@@ -341,6 +341,14 @@ func TestTxnTraceAsync(t *testing.T) {
 	if spanEventT3S2.ParentID != spanEventT3S1.GUID {
 		t.Error(spanEventT3S2.ParentID, spanEventT3S1.GUID)
 	}
+	if _, ok := spanEventT1S1.AgentAttributes[SpanAttributeThreadIndex]; ok {
+		t.Error("unexpected thread index on main thread segment", spanEventT1S1.AgentAttributes)
+	}
+	for idx, e := range map[int]*spanEvent{1: spanEventT2S1, 2: spanEventT3S1} {
+		if v, ok := e.AgentAttributes[SpanAttributeThreadIndex]; !ok || v != intJSONWriter(idx) {
+			t.Error("incorrect thread index", idx, e.AgentAttributes)
+		}
+	}
 
 	ht := newHarvestTraces()
 	ht.regular.addTxnTrace(&harvestTrace{
@@ -402,20 +410,20 @@ func TestTxnTraceAsync(t *testing.T) {
 						SegmentName:         "Custom/thread2.segment1",
 						RelativeStartMillis: 3000,
 						RelativeStopMillis:  5000,
-						Attributes:          map[string]interface{}{},
+						Attributes:          map[string]interface{}{"thread.index": 1},
 						Children:            []internal.WantTraceSegment{},
 					},
 					{
 						SegmentName:         "Custom/thread3.segment1",
 						RelativeStartMillis: 6000,
 						RelativeStopMillis:  10000,
-						Attributes:          map[string]interface{}{},
+						Attributes:          map[string]interface{}{"thread.index": 2},
 						Children: []internal.WantTraceSegment{
 							{
 								SegmentName:         "Custom/thread3.segment2",
 								RelativeStartMillis: 7000,
 								RelativeStopMillis:  9000,
-								Attributes:          map[string]interface{}{},
+								Attributes:          map[string]interface{}{"thread.index": 2},
 								Children:            []internal.WantTraceSegment{},
 							},
 						},